@@ -1,6 +1,6 @@
 module github.com/cody6750/web-crawler
 
-go 1.16
+go 1.20
 
 require (
 	github.com/aws/aws-sdk-go v1.43.14
@@ -8,3 +8,8 @@ require (
 	github.com/sirupsen/logrus v1.8.1
 	golang.org/x/net v0.0.0-20220127200216-cd36cc0744dd
 )
+
+require (
+	github.com/jmespath/go-jmespath v0.4.0 // indirect
+	golang.org/x/sys v0.0.0-20211216021012-1d35b9e2eb4e // indirect
+)
@@ -0,0 +1,139 @@
+package webcrawler
+
+import (
+	"context"
+	"errors"
+
+	webscraper "github.com/cody6750/web-crawler/pkg/webScraper"
+)
+
+// StopReason explains why a crawl ended.
+type StopReason string
+
+const (
+	// StopReasonCompleted is a crawl that ran out of urls to crawl.
+	StopReasonCompleted StopReason = "completed"
+
+	// StopReasonMaxItemsFound is a crawl that found Options.MaxItemsFound items.
+	StopReasonMaxItemsFound StopReason = "max_items_found"
+
+	// StopReasonMaxVisitedUrls is a crawl that visited Options.MaxVisitedUrls urls.
+	StopReasonMaxVisitedUrls StopReason = "max_visited_urls"
+
+	// StopReasonMaxBytesDownloaded is a crawl that downloaded Options.MaxBytesDownloaded bytes.
+	StopReasonMaxBytesDownloaded StopReason = "max_bytes_downloaded"
+
+	// StopReasonMaxDuration is a crawl that ran for Options.MaxDuration.
+	StopReasonMaxDuration StopReason = "max_duration"
+
+	// StopReasonErrorBudget is a crawl that exceeded its error budget, see ErrErrorBudgetExceeded.
+	StopReasonErrorBudget StopReason = "error_budget"
+
+	// StopReasonStalled is a crawl that failed its liveness check, it visited no url for longer than the longest delay
+	// a url can wait for.
+	StopReasonStalled StopReason = "stalled"
+
+	// StopReasonCanceled is a crawl whose context was cancelled or whose deadline was exceeded.
+	StopReasonCanceled StopReason = "canceled"
+
+	// StopReasonError is a crawl that failed with any other error.
+	StopReasonError StopReason = "error"
+)
+
+// hostUsage tracks how much of its per host budget a host has used.
+type hostUsage struct {
+	urls  int
+	bytes int64
+}
+
+// checkBudgets checks the metrics of the crawl against the crawl budgets of the options. Returns the reason to stop
+// the crawl for the first exceeded budget, or an empty reason. A budget of 0 means no limit.
+func (wc *WebCrawler) checkBudgets() StopReason {
+	metrics := wc.Metrics()
+	switch {
+	case wc.Options.MaxItemsFound > 0 && metrics.ItemsFound >= wc.Options.MaxItemsFound:
+		return StopReasonMaxItemsFound
+	case wc.Options.MaxVisitedUrls > 0 && metrics.UrlsVisited >= wc.Options.MaxVisitedUrls:
+		return StopReasonMaxVisitedUrls
+	case wc.Options.MaxBytesDownloaded > 0 && metrics.BytesDownloaded >= wc.Options.MaxBytesDownloaded:
+		return StopReasonMaxBytesDownloaded
+	}
+	return ""
+}
+
+// stopCrawl stops the crawl because of the reason. The crawl ends without an error and the partial response is
+// returned. Only the first reason is kept.
+func (wc *WebCrawler) stopCrawl(reason StopReason) {
+	wc.budgetLock.Lock()
+	if wc.stopReason == "" {
+		wc.stopReason = reason
+		wc.Logger.WithField("reason", reason).Info("Stopping crawl")
+	}
+	wc.budgetLock.Unlock()
+	wc.cancel()
+}
+
+// stopIfBudgetExceeded stops the crawl if one of its crawl budgets has been exceeded.
+func (wc *WebCrawler) stopIfBudgetExceeded() {
+	if reason := wc.checkBudgets(); reason != "" {
+		wc.stopCrawl(reason)
+	}
+}
+
+// crawlStopReason returns the reason the crawl ended, given the context of the crawl and the error it ended with.
+func (wc *WebCrawler) crawlStopReason(ctx context.Context, err error) StopReason {
+	switch {
+	case errors.Is(err, ErrErrorBudgetExceeded):
+		return StopReasonErrorBudget
+	case ctx.Err() != nil:
+		return StopReasonCanceled
+	case err != nil:
+		return StopReasonError
+	}
+	wc.budgetLock.Lock()
+	defer wc.budgetLock.Unlock()
+	if wc.stopReason != "" {
+		return wc.stopReason
+	}
+	return StopReasonCompleted
+}
+
+// withinHostBudget checks the host of the url against Options.MaxVisitedUrlsPerHost and
+// Options.MaxBytesDownloadedPerHost, so that a single large host cannot use up the budgets of the whole crawl. A url
+// within the budget is counted towards it.
+func (wc *WebCrawler) withinHostBudget(u *webscraper.URL) bool {
+	if wc.Options.MaxVisitedUrlsPerHost <= 0 && wc.Options.MaxBytesDownloadedPerHost <= 0 {
+		return true
+	}
+	wc.budgetLock.Lock()
+	defer wc.budgetLock.Unlock()
+	usage := wc.usageOf(hostOf(u.CurrentURL))
+	if wc.Options.MaxVisitedUrlsPerHost > 0 && usage.urls >= wc.Options.MaxVisitedUrlsPerHost {
+		return false
+	}
+	if wc.Options.MaxBytesDownloadedPerHost > 0 && usage.bytes >= wc.Options.MaxBytesDownloadedPerHost {
+		return false
+	}
+	usage.urls++
+	return true
+}
+
+// addHostBytes counts the bytes downloaded from the url towards the budget of its host.
+func (wc *WebCrawler) addHostBytes(u *webscraper.URL, bytes int64) {
+	if wc.Options.MaxBytesDownloadedPerHost <= 0 || bytes == 0 {
+		return
+	}
+	wc.budgetLock.Lock()
+	wc.usageOf(hostOf(u.CurrentURL)).bytes += bytes
+	wc.budgetLock.Unlock()
+}
+
+// usageOf returns the usage of the host, creating it on first use. Requires the budgetLock to be held.
+func (wc *WebCrawler) usageOf(host string) *hostUsage {
+	usage, ok := wc.hostUsages[host]
+	if !ok {
+		usage = &hostUsage{}
+		wc.hostUsages[host] = usage
+	}
+	return usage
+}
@@ -0,0 +1,111 @@
+package webcrawler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	options "github.com/cody6750/web-crawler/pkg/options"
+)
+
+func TestWebCrawler_checkBudgets(t *testing.T) {
+	tests := []struct {
+		name    string
+		options func(o *options.Options)
+		metrics Metrics
+		want    StopReason
+	}{
+		{name: "Within budgets", options: func(o *options.Options) {}, metrics: Metrics{UrlsVisited: 1, ItemsFound: 1}, want: ""},
+		{name: "No limits", options: func(o *options.Options) {
+			o.MaxVisitedUrls = 0
+			o.MaxItemsFound = 0
+		}, metrics: Metrics{UrlsVisited: 100000, ItemsFound: 100000}, want: ""},
+		{name: "Max items found", options: func(o *options.Options) { o.MaxItemsFound = 10 }, metrics: Metrics{ItemsFound: 10}, want: StopReasonMaxItemsFound},
+		{name: "Max visited urls", options: func(o *options.Options) { o.MaxVisitedUrls = 10 }, metrics: Metrics{UrlsVisited: 10}, want: StopReasonMaxVisitedUrls},
+		{name: "Max bytes downloaded", options: func(o *options.Options) { o.MaxBytesDownloaded = 1024 }, metrics: Metrics{BytesDownloaded: 2048}, want: StopReasonMaxBytesDownloaded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := options.New()
+			tt.options(o)
+			wc := NewWithOptions(o)
+			wc.metrics = tt.metrics
+			if got := wc.checkBudgets(); got != tt.want {
+				t.Errorf("WebCrawler.checkBudgets() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebCrawler_CrawlBudgets(t *testing.T) {
+	site := newTestSite(t, map[string]string{"/index.html": `<a href="/a">a</a><a href="/b">b</a><a href="/c">c</a><a href="/d">d</a>`})
+
+	tests := []struct {
+		name           string
+		options        func(o *options.Options)
+		wantVisited    int
+		wantOverBudget int
+		wantStopReason StopReason
+	}{
+		{name: "Completed", options: func(o *options.Options) {}, wantVisited: 5, wantStopReason: StopReasonCompleted},
+		{name: "Max visited urls", options: func(o *options.Options) { o.MaxVisitedUrls = 1 }, wantVisited: 1, wantStopReason: StopReasonMaxVisitedUrls},
+		{name: "Max bytes downloaded", options: func(o *options.Options) { o.MaxBytesDownloaded = 10 }, wantVisited: 1, wantStopReason: StopReasonMaxBytesDownloaded},
+		{name: "Max visited urls per host", options: func(o *options.Options) { o.MaxVisitedUrlsPerHost = 3 }, wantVisited: 3, wantOverBudget: 2, wantStopReason: StopReasonCompleted},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := options.New()
+			o.CrawlDelay = 0
+			o.AllowEmptyItem = true
+			o.MaxConcurrentRequestsPerHost = 0
+			tt.options(o)
+			wc := NewWithOptions(o)
+			got, err := wc.CrawlContext(context.Background(), site.URL+"/index.html", nil)
+			if err != nil {
+				t.Fatalf("WebCrawler.CrawlContext() error = %v", err)
+			}
+			if got.StopReason != tt.wantStopReason {
+				t.Errorf("WebCrawler.CrawlContext() stop reason = %v, want %v", got.StopReason, tt.wantStopReason)
+			}
+			if got.Metrics.UrlsVisited != tt.wantVisited || got.Metrics.OverBudgetUrlsFound != tt.wantOverBudget {
+				t.Errorf("WebCrawler.CrawlContext() urls visited = %v, over budget urls = %v, want %v and %v", got.Metrics.UrlsVisited, got.Metrics.OverBudgetUrlsFound, tt.wantVisited, tt.wantOverBudget)
+			}
+		})
+	}
+}
+
+func TestWebCrawler_CrawlBudgets_maxDuration(t *testing.T) {
+	// The server never answers until the client goes away, so only the max duration stops the crawl.
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			http.NotFound(rw, r)
+			return
+		}
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	o := options.New()
+	o.CrawlDelay = 0
+	o.MaxDuration = 100 * time.Millisecond
+	got, err := NewWithOptions(o).CrawlContext(context.Background(), server.URL+"/index.html", nil)
+	if err != nil {
+		t.Fatalf("WebCrawler.CrawlContext() error = %v", err)
+	}
+	if got.StopReason != StopReasonMaxDuration || got.Metrics.UrlsVisited != 0 {
+		t.Errorf("WebCrawler.CrawlContext() stop reason = %v, urls visited = %v, want %v and 0", got.StopReason, got.Metrics.UrlsVisited, StopReasonMaxDuration)
+	}
+}
+
+func TestWebCrawler_livenessWindow(t *testing.T) {
+	o := options.New()
+	o.CrawlDelay = 0
+	o.MaxCrawlDelay = 5 * time.Minute
+	o.Transport.Timeout = 30 * time.Second
+	o.RetryPolicy.MaxBackoff = 10 * time.Second
+	if got, want := NewWithOptions(o).livenessWindow(), 6*time.Minute+40*time.Second; got != want {
+		t.Errorf("WebCrawler.livenessWindow() = %v, want %v", got, want)
+	}
+}
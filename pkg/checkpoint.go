@@ -0,0 +1,396 @@
+package webcrawler
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	webscraper "github.com/cody6750/web-crawler/pkg/webScraper"
+	"github.com/sirupsen/logrus"
+)
+
+// checkpointCompaction is the number of deltas appended to an IncrementalStateStore before a full checkpoint is saved
+// again.
+const checkpointCompaction = 10
+
+// ErrCrawlNotFound is returned by a state store when no checkpoint exists for the given crawl ID.
+var ErrCrawlNotFound = errors.New("crawl not found")
+
+// CrawlState is a checkpoint of a crawl. It holds everything needed to resume the crawl in a new process: the scrape
+// configuration, the frontier of urls still to be crawled, the visited urls and the metrics so far. Frontier holds the
+// urls that have already been deduplicated, Pending the urls that have not. Visited is the visited set of the crawl
+// in the binary format of its VisitedSet type, VisitedLog the urls visited after Visited was saved. FeedStates holds the
+// newest entry crawled from every feed, they are saved to the FeedStateStore once the resumed crawl has stopped.
+type CrawlState struct {
+	CrawlID    string
+	URL        string
+	Seeds      []Seed
+	ItemsToGet []webscraper.ScrapeItemConfig
+	URLsToGet  []webscraper.ScrapeURLConfig
+	Frontier   []*webscraper.URL
+	Pending    []*webscraper.URL
+	VisitedSet string
+	Visited    []byte
+	VisitedLog []string
+	Metrics    Metrics
+	FeedStates map[string]time.Time
+	UpdatedAt  time.Time
+}
+
+// CrawlStateDelta holds the changes of a crawl since its previous checkpoint: the urls visited, the urls of the
+// frontier that were added or changed and the urls that left the frontier.
+type CrawlStateDelta struct {
+	CrawlID    string
+	Visited    []string
+	Frontier   []*webscraper.URL
+	Pending    []*webscraper.URL
+	Removed    []string
+	Metrics    Metrics
+	FeedStates map[string]time.Time
+	UpdatedAt  time.Time
+}
+
+// Apply updates the checkpoint with the changes of the delta.
+func (s *CrawlState) Apply(delta *CrawlStateDelta) {
+	frontier := make(map[string]*webscraper.URL, len(s.Frontier)+len(s.Pending))
+	submitted := make(map[string]bool, len(s.Frontier)+len(s.Pending))
+	add := func(urls []*webscraper.URL, isSubmitted bool) {
+		for _, u := range urls {
+			frontier[u.CurrentURL], submitted[u.CurrentURL] = u, isSubmitted
+		}
+	}
+	add(s.Frontier, true)
+	add(s.Pending, false)
+	for _, rawURL := range delta.Removed {
+		delete(frontier, rawURL)
+	}
+	add(delta.Frontier, true)
+	add(delta.Pending, false)
+	s.Frontier, s.Pending = nil, nil
+	for rawURL, u := range frontier {
+		if submitted[rawURL] {
+			s.Frontier = append(s.Frontier, u)
+		} else {
+			s.Pending = append(s.Pending, u)
+		}
+	}
+	sortByDepth(s.Frontier)
+	sortByDepth(s.Pending)
+	s.VisitedLog = append(s.VisitedLog, delta.Visited...)
+	s.Metrics = delta.Metrics
+	s.FeedStates = delta.FeedStates
+	s.UpdatedAt = delta.UpdatedAt
+}
+
+// StateStore persists the checkpoints of crawls. Implementations must be safe for concurrent use.
+type StateStore interface {
+	Save(state *CrawlState) error
+	Load(crawlID string) (*CrawlState, error)
+	Delete(crawlID string) error
+}
+
+// IncrementalStateStore is a StateStore that can also save the changes of a crawl since its previous checkpoint, so
+// that a checkpoint does not rewrite the whole crawl state. Load must return the last checkpoint saved with every
+// delta appended since applied.
+type IncrementalStateStore interface {
+	StateStore
+	Append(delta *CrawlStateDelta) error
+}
+
+// FileStateStore is an IncrementalStateStore that keeps every checkpoint in a JSON file in a directory, and the deltas
+// appended since in a log file next to it, one JSON record per line. A checkpoint is written to a temporary file first
+// and renamed, so a crash while saving leaves the previous checkpoint intact, and a record cut short by a crash is
+// ignored when loading. It also implements FeedStateStore, the feeds are kept in a single JSON file shared by every
+// crawl.
+type FileStateStore struct {
+	dir string
+
+	// feedLock used to block concurrent updates of the feeds file.
+	feedLock sync.Mutex
+}
+
+// NewFileStateStore creates a state store in the directory, creating the directory if it does not exist.
+func NewFileStateStore(dir string) (*FileStateStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileStateStore{dir: dir}, nil
+}
+
+// Save writes the checkpoint of the crawl, replacing the previous one and the deltas appended since. The log of the
+// deltas is removed first, so a crash in between leaves an older checkpoint rather than deltas applied twice.
+func (s *FileStateStore) Save(state *CrawlState) error {
+	path, err := s.path(state.CrawlID)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(s.logPath(path)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return s.writeFile(path, state)
+}
+
+// Append implements IncrementalStateStore. Returns ErrCrawlNotFound if no checkpoint of the crawl was saved.
+func (s *FileStateStore) Append(delta *CrawlStateDelta) error {
+	path, err := s.path(delta.CrawlID)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%w: %v", ErrCrawlNotFound, delta.CrawlID)
+	} else if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(s.logPath(path), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return err
+	}
+	if err := json.NewEncoder(file).Encode(delta); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// writeFile writes the value as JSON to a temporary file and renames it to the path.
+func (s *FileStateStore) writeFile(path string, value interface{}) error {
+	tmp, err := os.CreateTemp(s.dir, filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := json.NewEncoder(tmp).Encode(value); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Load reads the checkpoint of the crawl. Returns ErrCrawlNotFound if there is none.
+func (s *FileStateStore) Load(crawlID string) (*CrawlState, error) {
+	path, err := s.path(crawlID)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %v", ErrCrawlNotFound, crawlID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	state := &CrawlState{}
+	if err := json.NewDecoder(file).Decode(state); err != nil {
+		return nil, fmt.Errorf("unable to decode checkpoint of crawl %v: %w", crawlID, err)
+	}
+	if err := s.applyLog(s.logPath(path), state); err != nil {
+		return nil, fmt.Errorf("unable to decode deltas of crawl %v: %w", crawlID, err)
+	}
+	return state, nil
+}
+
+// applyLog applies the deltas of the log file to the checkpoint. A last record cut short by a crash is ignored.
+func (s *FileStateStore) applyLog(path string, state *CrawlState) error {
+	file, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	decoder := json.NewDecoder(file)
+	for {
+		delta := &CrawlStateDelta{}
+		err := decoder.Decode(delta)
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		state.Apply(delta)
+	}
+}
+
+// Delete removes the checkpoint of the crawl. Returns ErrCrawlNotFound if there is none.
+func (s *FileStateStore) Delete(crawlID string) error {
+	path, err := s.path(crawlID)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(s.logPath(path)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := os.Remove(path); errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("%w: %v", ErrCrawlNotFound, crawlID)
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// LoadFeedState implements FeedStateStore.
+func (s *FileStateStore) LoadFeedState(feedURL string) (time.Time, error) {
+	s.feedLock.Lock()
+	defer s.feedLock.Unlock()
+	feeds, err := s.loadFeeds()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return feeds[feedURL], nil
+}
+
+// SaveFeedState implements FeedStateStore.
+func (s *FileStateStore) SaveFeedState(feedURL string, newest time.Time) error {
+	s.feedLock.Lock()
+	defer s.feedLock.Unlock()
+	feeds, err := s.loadFeeds()
+	if err != nil {
+		return err
+	}
+	feeds[feedURL] = newest
+	return s.writeFile(s.feedsPath(), feeds)
+}
+
+// loadFeeds reads the feeds file, empty if it does not exist.
+func (s *FileStateStore) loadFeeds() (map[string]time.Time, error) {
+	feeds := make(map[string]time.Time)
+	data, err := ioutil.ReadFile(s.feedsPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return feeds, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &feeds); err != nil {
+		return nil, fmt.Errorf("unable to decode feeds: %w", err)
+	}
+	return feeds, nil
+}
+
+// feedsPath returns the feeds file. It starts with a dot, so it never collides with the checkpoint of a crawl.
+func (s *FileStateStore) feedsPath() string {
+	return filepath.Join(s.dir, ".feeds.json")
+}
+
+// path returns the file of the checkpoint of the crawl.
+func (s *FileStateStore) path(crawlID string) (string, error) {
+	if crawlID == "" || crawlID != filepath.Base(crawlID) || strings.HasPrefix(crawlID, ".") {
+		return "", fmt.Errorf("invalid crawl id %q", crawlID)
+	}
+	return filepath.Join(s.dir, crawlID+".json"), nil
+}
+
+// logPath returns the log file of the deltas of the checkpoint file.
+func (s *FileStateStore) logPath(path string) string {
+	return strings.TrimSuffix(path, ".json") + ".log"
+}
+
+// newCrawlID returns a random crawl ID.
+func newCrawlID() (string, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(id), nil
+}
+
+// stateStore returns the state store of the web crawler, creating a FileStateStore in Options.CheckpointDir if none is
+// set. Returns nil if checkpointing is disabled.
+func (wc *WebCrawler) stateStore() (StateStore, error) {
+	wc.stateStoreLock.Lock()
+	defer wc.stateStoreLock.Unlock()
+	if wc.StateStore == nil && wc.Options.CheckpointDir != "" {
+		store, err := NewFileStateStore(wc.Options.CheckpointDir)
+		if err != nil {
+			return nil, err
+		}
+		wc.StateStore = store
+	}
+	return wc.StateStore, nil
+}
+
+// saveCheckpoint saves the current state of the crawl to the state store. If the store is an IncrementalStateStore,
+// only the changes since the previous checkpoint are appended, unless full is set, no checkpoint was saved yet or
+// checkpointCompaction deltas were appended since the last full checkpoint.
+func (wc *WebCrawler) saveCheckpoint(store StateStore, checkpoint CrawlState, full bool) {
+	wc.checkpointLock.Lock()
+	defer wc.checkpointLock.Unlock()
+	if incremental, ok := store.(IncrementalStateStore); ok && !full && wc.checkpointSaved && wc.appendedCheckpoints < checkpointCompaction {
+		wc.appendCheckpoint(incremental, checkpoint.CrawlID)
+		return
+	}
+	// A failed checkpoint may have lost tracked changes, the next one is full.
+	wc.checkpointSaved, wc.appendedCheckpoints = false, 0
+	var err error
+	checkpoint.Frontier, checkpoint.Pending, checkpoint.Visited, err = wc.state.snapshot()
+	if err != nil {
+		wc.Logger.WithError(err).WithField("crawl", checkpoint.CrawlID).Warn("Unable to save visited urls to checkpoint")
+		return
+	}
+	checkpoint.Metrics = wc.Metrics()
+	checkpoint.FeedStates = wc.copyFeedStates()
+	checkpoint.UpdatedAt = time.Now()
+	if err := store.Save(&checkpoint); err != nil {
+		wc.Logger.WithError(err).WithField("crawl", checkpoint.CrawlID).Warn("Unable to save checkpoint")
+		return
+	}
+	wc.checkpointSaved = true
+	wc.Logger.WithFields(logrus.Fields{"crawl": checkpoint.CrawlID, "frontier": len(checkpoint.Frontier) + len(checkpoint.Pending), "visited": checkpoint.Metrics.VisitedSet.Len}).Debug("Saved checkpoint")
+}
+
+// appendCheckpoint appends the changes of the crawl since the previous checkpoint to the state store. Must be called
+// under the checkpoint lock.
+func (wc *WebCrawler) appendCheckpoint(store IncrementalStateStore, crawlID string) {
+	delta := &CrawlStateDelta{CrawlID: crawlID}
+	delta.Visited, delta.Frontier, delta.Pending, delta.Removed = wc.state.delta()
+	delta.Metrics = wc.Metrics()
+	delta.FeedStates = wc.copyFeedStates()
+	delta.UpdatedAt = time.Now()
+	if err := store.Append(delta); err != nil {
+		wc.checkpointSaved = false
+		wc.Logger.WithError(err).WithField("crawl", crawlID).Warn("Unable to append checkpoint")
+		return
+	}
+	wc.appendedCheckpoints++
+	wc.Logger.WithFields(logrus.Fields{"crawl": crawlID, "frontier": len(delta.Frontier) + len(delta.Pending) + len(delta.Removed), "visited": len(delta.Visited)}).Debug("Appended checkpoint")
+}
+
+// runCheckpoints saves the state of the crawl every Options.CheckpointInterval until the crawl has stopped.
+func (wc *WebCrawler) runCheckpoints(store StateStore, checkpoint CrawlState) {
+	if wc.Options.CheckpointInterval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(wc.Options.CheckpointInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			wc.saveCheckpoint(store, checkpoint, false)
+		case <-wc.ctx.Done():
+			return
+		}
+	}
+}
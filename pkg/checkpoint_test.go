@@ -0,0 +1,234 @@
+package webcrawler
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	options "github.com/cody6750/web-crawler/pkg/options"
+	webscraper "github.com/cody6750/web-crawler/pkg/webScraper"
+)
+
+func TestFileStateStore(t *testing.T) {
+	state := &CrawlState{
+		CrawlID:    "crawl",
+		URL:        "https://www.example.com/",
+		Frontier:   []*webscraper.URL{{RootURL: "https://www.example.com/", CurrentURL: "https://www.example.com/a", CurrentDepth: 1, MaxDepth: 1}},
+		Pending:    []*webscraper.URL{{RootURL: "https://www.example.com/", CurrentURL: "https://www.example.com/b", CurrentDepth: 1, MaxDepth: 1}},
+		VisitedSet: options.VisitedSetExact,
+		Visited:    []byte("https://www.example.com/\nhttps://www.example.com/a\n"),
+		Metrics:    Metrics{UrlsVisited: 1},
+	}
+	tests := []struct {
+		name    string
+		crawlID string
+		save    bool
+		want    *CrawlState
+		wantErr error
+	}{
+		{name: "Saved checkpoint is loaded", crawlID: "crawl", save: true, want: state},
+		{name: "Missing checkpoint", crawlID: "missing", wantErr: ErrCrawlNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := NewFileStateStore(t.TempDir())
+			if err != nil {
+				t.Fatalf("NewFileStateStore() error = %v", err)
+			}
+			if tt.save {
+				if err := store.Save(state); err != nil {
+					t.Fatalf("FileStateStore.Save() error = %v", err)
+				}
+			}
+			got, err := store.Load(tt.crawlID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("FileStateStore.Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.want != nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FileStateStore.Load() = %+v, want %+v", got, tt.want)
+			}
+			if err := store.Delete(tt.crawlID); !errors.Is(err, tt.wantErr) {
+				t.Errorf("FileStateStore.Delete() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestFileStateStore_invalidCrawlID(t *testing.T) {
+	store, err := NewFileStateStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStateStore() error = %v", err)
+	}
+	for _, crawlID := range []string{"", "../crawl", ".crawl", "a/b"} {
+		if err := store.Save(&CrawlState{CrawlID: crawlID}); err == nil {
+			t.Errorf("FileStateStore.Save() crawl id %q, want error", crawlID)
+		}
+	}
+}
+
+func TestFileStateStore_Append(t *testing.T) {
+	u := func(path string, depth int) *webscraper.URL {
+		return &webscraper.URL{RootURL: "https://www.example.com/", CurrentURL: "https://www.example.com/" + path, CurrentDepth: depth, MaxDepth: 2}
+	}
+	state := &CrawlState{
+		CrawlID:    "crawl",
+		Frontier:   []*webscraper.URL{u("a", 1)},
+		Pending:    []*webscraper.URL{u("b", 1)},
+		VisitedSet: options.VisitedSetExact,
+		Visited:    []byte("https://www.example.com/\nhttps://www.example.com/a\n"),
+		Metrics:    Metrics{UrlsVisited: 1},
+	}
+	deltas := []*CrawlStateDelta{
+		{CrawlID: "crawl", Visited: []string{"https://www.example.com/b"}, Frontier: []*webscraper.URL{u("b", 1)}, Pending: []*webscraper.URL{u("c", 2)}, Removed: []string{"https://www.example.com/a"}, Metrics: Metrics{UrlsVisited: 2}},
+		{CrawlID: "crawl", Visited: []string{"https://www.example.com/c"}, Frontier: []*webscraper.URL{u("c", 2)}, Removed: []string{"https://www.example.com/b"}, Metrics: Metrics{UrlsVisited: 3}},
+	}
+	want := &CrawlState{
+		CrawlID:    "crawl",
+		Frontier:   []*webscraper.URL{u("c", 2)},
+		VisitedSet: options.VisitedSetExact,
+		Visited:    state.Visited,
+		VisitedLog: []string{"https://www.example.com/b", "https://www.example.com/c"},
+		Metrics:    Metrics{UrlsVisited: 3},
+	}
+
+	dir := t.TempDir()
+	store, err := NewFileStateStore(dir)
+	if err != nil {
+		t.Fatalf("NewFileStateStore() error = %v", err)
+	}
+	if err := store.Append(deltas[0]); !errors.Is(err, ErrCrawlNotFound) {
+		t.Errorf("FileStateStore.Append() without checkpoint error = %v, want %v", err, ErrCrawlNotFound)
+	}
+	if err := store.Save(state); err != nil {
+		t.Fatalf("FileStateStore.Save() error = %v", err)
+	}
+	for _, delta := range deltas {
+		if err := store.Append(delta); err != nil {
+			t.Fatalf("FileStateStore.Append() error = %v", err)
+		}
+	}
+	// A record cut short by a crash is ignored.
+	log, err := os.OpenFile(filepath.Join(dir, "crawl.log"), os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		t.Fatalf("os.OpenFile() error = %v", err)
+	}
+	log.Write([]byte(`{"CrawlID":"crawl","Visited":["https://www.exa`))
+	log.Close()
+	got, err := store.Load("crawl")
+	if err != nil {
+		t.Fatalf("FileStateStore.Load() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FileStateStore.Load() = %+v, want %+v", got, want)
+	}
+
+	// Saving a full checkpoint drops the deltas.
+	if err := store.Save(want); err != nil {
+		t.Fatalf("FileStateStore.Save() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "crawl.log")); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("FileStateStore.Save() log error = %v, want %v", err, os.ErrNotExist)
+	}
+	if got, err := store.Load("crawl"); err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("FileStateStore.Load() = %+v, %v, want %+v", got, err, want)
+	}
+}
+
+func Test_crawlState_delta(t *testing.T) {
+	u := func(path string, depth int) *webscraper.URL {
+		return &webscraper.URL{CurrentURL: "https://www.example.com/" + path, CurrentDepth: depth}
+	}
+	state := newCrawlState(options.VisitedSetExact, NewExactVisitedSet())
+	state.trackChanges()
+	state.push(u("a", 0))
+	state.visit(u("a", 0))
+	if _, _, _, err := state.snapshot(); err != nil {
+		t.Fatalf("crawlState.snapshot() error = %v", err)
+	}
+	state.push(u("b", 1))
+	state.push(u("c", 1))
+	state.visit(u("b", 1))
+	state.done(u("a", 0))
+
+	visited, submitted, pending, removed := state.delta()
+	if want := []string{"https://www.example.com/b"}; !reflect.DeepEqual(visited, want) {
+		t.Errorf("crawlState.delta() visited = %v, want %v", visited, want)
+	}
+	if want := []*webscraper.URL{u("b", 1)}; !reflect.DeepEqual(submitted, want) {
+		t.Errorf("crawlState.delta() submitted = %v, want %v", submitted, want)
+	}
+	if want := []*webscraper.URL{u("c", 1)}; !reflect.DeepEqual(pending, want) {
+		t.Errorf("crawlState.delta() pending = %v, want %v", pending, want)
+	}
+	if want := []string{"https://www.example.com/a"}; !reflect.DeepEqual(removed, want) {
+		t.Errorf("crawlState.delta() removed = %v, want %v", removed, want)
+	}
+	if visited, submitted, pending, removed := state.delta(); visited != nil || submitted != nil || pending != nil || removed != nil {
+		t.Errorf("crawlState.delta() = %v, %v, %v, %v, want no changes since the previous delta", visited, submitted, pending, removed)
+	}
+
+	// The urls visited after the visited set was saved are restored from the log.
+	restored := newCrawlState(options.VisitedSetExact, NewExactVisitedSet())
+	if err := restored.restore(&CrawlState{Visited: []byte("https://www.example.com/a\n"), VisitedLog: []string{"https://www.example.com/b"}}); err != nil {
+		t.Fatalf("crawlState.restore() error = %v", err)
+	}
+	for _, path := range []string{"a", "b"} {
+		restored.push(u(path, 1))
+		if added, err := restored.visit(u(path, 1)); added || err != nil {
+			t.Errorf("crawlState.visit(%v) = %v, %v, want restored as visited", path, added, err)
+		}
+	}
+}
+
+func TestWebCrawler_Resume(t *testing.T) {
+	tests := []struct {
+		name               string
+		visitedSet         string
+		maxVisitedUrls     int
+		wantFirstReason    StopReason
+		wantResumedReason  StopReason
+		wantResumedVisited int
+	}{
+		{name: "Resumed crawl skips visited urls", visitedSet: options.VisitedSetExact, maxVisitedUrls: 2, wantFirstReason: StopReasonMaxVisitedUrls, wantResumedReason: StopReasonCompleted, wantResumedVisited: 5},
+		{name: "Resumed crawl restores bloom visited set", visitedSet: options.VisitedSetBloom, maxVisitedUrls: 2, wantFirstReason: StopReasonMaxVisitedUrls, wantResumedReason: StopReasonCompleted, wantResumedVisited: 5},
+		{name: "Resumed crawl restores disk visited set", visitedSet: options.VisitedSetDisk, maxVisitedUrls: 2, wantFirstReason: StopReasonMaxVisitedUrls, wantResumedReason: StopReasonCompleted, wantResumedVisited: 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			site := newTestSite(t, map[string]string{"/index.html": `<a href="/a">a</a><a href="/b">b</a><a href="/c">c</a><a href="/d">d</a>`})
+			dir := t.TempDir()
+			newCrawler := func(maxVisitedUrls int) *WebCrawler {
+				o := options.New()
+				o.CrawlDelay = 0
+				o.AllowEmptyItem = true
+				o.CheckpointDir = dir
+				o.MaxVisitedUrls = maxVisitedUrls
+				o.VisitedSet = tt.visitedSet
+				o.VisitedSetDir = dir
+				return NewWithOptions(o)
+			}
+
+			first, err := newCrawler(tt.maxVisitedUrls).CrawlContext(context.Background(), site.URL+"/index.html", nil)
+			if err != nil {
+				t.Fatalf("WebCrawler.CrawlContext() error = %v", err)
+			}
+			if first.StopReason != tt.wantFirstReason || first.CrawlID == "" {
+				t.Fatalf("WebCrawler.CrawlContext() stop reason = %v, crawl id = %q, want %v and a crawl id", first.StopReason, first.CrawlID, tt.wantFirstReason)
+			}
+
+			resumed, err := newCrawler(0).Resume(first.CrawlID)
+			if err != nil {
+				t.Fatalf("WebCrawler.Resume() error = %v", err)
+			}
+			if resumed.StopReason != tt.wantResumedReason || resumed.Metrics.UrlsVisited != tt.wantResumedVisited {
+				t.Errorf("WebCrawler.Resume() stop reason = %v, urls visited = %v, want %v and %v", resumed.StopReason, resumed.Metrics.UrlsVisited, tt.wantResumedReason, tt.wantResumedVisited)
+			}
+			if got := site.hitCount("/index.html"); got != 1 {
+				t.Errorf("WebCrawler.Resume() root url fetched %v times, want 1", got)
+			}
+		})
+	}
+}
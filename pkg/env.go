@@ -2,7 +2,10 @@ package webcrawler
 
 import (
 	"os"
+	"strings"
+	"time"
 
+	webscraper "github.com/cody6750/web-crawler/pkg/webScraper"
 	env "github.com/cody6750/web-crawler/shared"
 )
 
@@ -12,6 +15,14 @@ func (wc *WebCrawler) getEnvVariables() {
 	var err error
 	wc.Logger.Info("Getting environment variables")
 
+	if os.Getenv("ADAPTIVE_THROTTLING") != "" {
+		wc.Options.AdaptiveThrottling, err = env.GetEnvBool("ADAPTIVE_THROTTLING")
+		if err != nil {
+			wc.Logger.WithError(err).Fatal("Failed to convert ADAPTIVE_THROTTLING from string to bool")
+		}
+		wc.Logger.WithField("ADAPTIVE_THROTTLING: ", wc.Options.AdaptiveThrottling).Info("Successfully got environment variable")
+	}
+
 	if os.Getenv("ALLOW_EMPTY_ITEM") != "" {
 		wc.Options.AllowEmptyItem, err = env.GetEnvBool("ALLOW_EMPTY_ITEM")
 		if err != nil {
@@ -20,6 +31,11 @@ func (wc *WebCrawler) getEnvVariables() {
 		wc.Logger.WithField("ALLOW_EMPTY_ITEM: ", wc.Options.AllowEmptyItem).Info("Successfully got environment variable")
 	}
 
+	if os.Getenv("ALLOWED_HOSTS") != "" {
+		wc.Options.AllowedHosts = strings.Split(os.Getenv("ALLOWED_HOSTS"), ",")
+		wc.Logger.WithField("ALLOWED_HOSTS: ", wc.Options.AllowedHosts).Info("Successfully got environment variable")
+	}
+
 	if os.Getenv("AWS_WRITE_OUTPUT_TO_S3") != "" {
 		wc.Options.AWSWriteOutputToS3, err = env.GetEnvBool("AWS_WRITE_OUTPUT_TO_S3")
 		if err != nil {
@@ -36,12 +52,50 @@ func (wc *WebCrawler) getEnvVariables() {
 		wc.Logger.WithField("AWS_MAX_RERIES: ", wc.Options.AWSMaxRetries).Info("Successfully got environment variable")
 	}
 
+	if os.Getenv("CHECKPOINT_DIR") != "" {
+		wc.Options.CheckpointDir = os.Getenv("CHECKPOINT_DIR")
+		wc.Logger.WithField("CHECKPOINT_DIR: ", wc.Options.CheckpointDir).Info("Successfully got environment variable")
+	}
+
+	if os.Getenv("CHECKPOINT_INTERVAL") != "" {
+		wc.Options.CheckpointInterval, err = env.GetEnvDuration("CHECKPOINT_INTERVAL")
+		if err != nil {
+			wc.Logger.WithError(err).Fatal("Failed to convert CHECKPOINT_INTERVAL from string to duration")
+		}
+		wc.Logger.WithField("CHECKPOINT_INTERVAL: ", wc.Options.CheckpointInterval).Info("Successfully got environment variable")
+	}
+
+	if os.Getenv("CRAWL_ORDER") != "" {
+		wc.Options.CrawlOrder = os.Getenv("CRAWL_ORDER")
+		wc.Logger.WithField("CRAWL_ORDER: ", wc.Options.CrawlOrder).Info("Successfully got environment variable")
+	}
+
 	if os.Getenv("CRAWL_DELAY") != "" {
-		wc.Options.CrawlDelay, err = env.GetEnvInt("CRAWL_DELAY")
+		wc.Options.CrawlDelayDuration, err = env.GetEnvDuration("CRAWL_DELAY")
+		if err != nil {
+			wc.Logger.WithError(err).Fatal("Failed to convert CRAWL_DELAY from string to duration")
+		}
+		// A delay of 0 disables the delay instead of falling back to the default of CrawlDelay.
+		wc.Options.CrawlDelay = int(wc.Options.CrawlDelayDuration / time.Second)
+		wc.Logger.WithField("CRAWL_DELAY: ", wc.Options.CrawlDelayDuration).Info("Successfully got environment variable")
+	}
+
+	if os.Getenv("INCLUDE_URL_PATTERNS") != "" {
+		wc.Options.IncludeURLPatterns = strings.Split(os.Getenv("INCLUDE_URL_PATTERNS"), ",")
+		wc.Logger.WithField("INCLUDE_URL_PATTERNS: ", wc.Options.IncludeURLPatterns).Info("Successfully got environment variable")
+	}
+
+	if os.Getenv("IGNORED_QUERY_PARAMS") != "" {
+		wc.Options.IgnoredQueryParams = strings.Split(os.Getenv("IGNORED_QUERY_PARAMS"), ",")
+		wc.Logger.WithField("IGNORED_QUERY_PARAMS: ", wc.Options.IgnoredQueryParams).Info("Successfully got environment variable")
+	}
+
+	if os.Getenv("MAX_CRAWL_DELAY") != "" {
+		wc.Options.MaxCrawlDelay, err = env.GetEnvDuration("MAX_CRAWL_DELAY")
 		if err != nil {
-			wc.Logger.WithError(err).Fatal("Failed to convert  from string to int")
+			wc.Logger.WithError(err).Fatal("Failed to convert MAX_CRAWL_DELAY from string to duration")
 		}
-		wc.Logger.WithField("CRAWL_DELAY: ", wc.Options.CrawlDelay).Info("Successfully got environment variable")
+		wc.Logger.WithField("MAX_CRAWL_DELAY: ", wc.Options.MaxCrawlDelay).Info("Successfully got environment variable")
 	}
 
 	if os.Getenv("MAX_DEPTH") != "" {
@@ -52,6 +106,22 @@ func (wc *WebCrawler) getEnvVariables() {
 		wc.Logger.WithField("MAX_DEPTH: ", wc.Options.MaxDepth).Info("Successfully got environment variable")
 	}
 
+	if os.Getenv("MAX_FAILED_URLS") != "" {
+		wc.Options.MaxFailedUrls, err = env.GetEnvInt("MAX_FAILED_URLS")
+		if err != nil {
+			wc.Logger.WithError(err).Fatal("Failed to convert MAX_FAILED_URLS from string to int")
+		}
+		wc.Logger.WithField("MAX_FAILED_URLS: ", wc.Options.MaxFailedUrls).Info("Successfully got environment variable")
+	}
+
+	if os.Getenv("MAX_FAILURE_RATE") != "" {
+		wc.Options.MaxFailureRate, err = env.GetEnvFloat("MAX_FAILURE_RATE")
+		if err != nil {
+			wc.Logger.WithError(err).Fatal("Failed to convert MAX_FAILURE_RATE from string to float")
+		}
+		wc.Logger.WithField("MAX_FAILURE_RATE: ", wc.Options.MaxFailureRate).Info("Successfully got environment variable")
+	}
+
 	if os.Getenv("MAX_GO_ROUTINES") != "" {
 		wc.Options.MaxGoRoutines, err = env.GetEnvInt("MAX_GO_ROUTINES")
 		if err != nil {
@@ -68,6 +138,80 @@ func (wc *WebCrawler) getEnvVariables() {
 		wc.Logger.WithField("MAX_VISITED_URLS: ", wc.Options.MaxVisitedUrls).Info("Successfully got environment variable")
 	}
 
+	if os.Getenv("MAX_VISITED_URLS_PER_HOST") != "" {
+		wc.Options.MaxVisitedUrlsPerHost, err = env.GetEnvInt("MAX_VISITED_URLS_PER_HOST")
+		if err != nil {
+			wc.Logger.WithError(err).Fatal("Failed to convert MAX_VISITED_URLS_PER_HOST from string to int")
+		}
+		wc.Logger.WithField("MAX_VISITED_URLS_PER_HOST: ", wc.Options.MaxVisitedUrlsPerHost).Info("Successfully got environment variable")
+	}
+
+	if os.Getenv("MAX_CONCURRENT_REQUESTS_PER_HOST") != "" {
+		wc.Options.MaxConcurrentRequestsPerHost, err = env.GetEnvInt("MAX_CONCURRENT_REQUESTS_PER_HOST")
+		if err != nil {
+			wc.Logger.WithError(err).Fatal("Failed to convert MAX_CONCURRENT_REQUESTS_PER_HOST from string to int")
+		}
+		wc.Logger.WithField("MAX_CONCURRENT_REQUESTS_PER_HOST: ", wc.Options.MaxConcurrentRequestsPerHost).Info("Successfully got environment variable")
+	}
+
+	if os.Getenv("MAX_THROTTLED_RETRIES") != "" {
+		wc.Options.MaxThrottledRetries, err = env.GetEnvInt("MAX_THROTTLED_RETRIES")
+		if err != nil {
+			wc.Logger.WithError(err).Fatal("Failed to convert MAX_THROTTLED_RETRIES from string to int")
+		}
+		wc.Logger.WithField("MAX_THROTTLED_RETRIES: ", wc.Options.MaxThrottledRetries).Info("Successfully got environment variable")
+	}
+
+	if os.Getenv("MAX_FETCH_ATTEMPTS") != "" {
+		wc.retryPolicy().MaxAttempts, err = env.GetEnvInt("MAX_FETCH_ATTEMPTS")
+		if err != nil {
+			wc.Logger.WithError(err).Fatal("Failed to convert MAX_FETCH_ATTEMPTS from string to int")
+		}
+		wc.Logger.WithField("MAX_FETCH_ATTEMPTS: ", wc.Options.RetryPolicy.MaxAttempts).Info("Successfully got environment variable")
+	}
+
+	if os.Getenv("RETRY_BACKOFF") != "" {
+		wc.retryPolicy().InitialBackoff, err = env.GetEnvDuration("RETRY_BACKOFF")
+		if err != nil {
+			wc.Logger.WithError(err).Fatal("Failed to convert RETRY_BACKOFF from string to duration")
+		}
+		wc.Logger.WithField("RETRY_BACKOFF: ", wc.Options.RetryPolicy.InitialBackoff).Info("Successfully got environment variable")
+	}
+
+	if os.Getenv("MAX_RETRY_BACKOFF") != "" {
+		wc.retryPolicy().MaxBackoff, err = env.GetEnvDuration("MAX_RETRY_BACKOFF")
+		if err != nil {
+			wc.Logger.WithError(err).Fatal("Failed to convert MAX_RETRY_BACKOFF from string to duration")
+		}
+		wc.Logger.WithField("MAX_RETRY_BACKOFF: ", wc.Options.RetryPolicy.MaxBackoff).Info("Successfully got environment variable")
+	}
+
+	if os.Getenv("MAX_BYTES_DOWNLOADED") != "" {
+		maxBytesDownloaded, err := env.GetEnvInt("MAX_BYTES_DOWNLOADED")
+		if err != nil {
+			wc.Logger.WithError(err).Fatal("Failed to convert MAX_BYTES_DOWNLOADED from string to int")
+		}
+		wc.Options.MaxBytesDownloaded = int64(maxBytesDownloaded)
+		wc.Logger.WithField("MAX_BYTES_DOWNLOADED: ", wc.Options.MaxBytesDownloaded).Info("Successfully got environment variable")
+	}
+
+	if os.Getenv("MAX_BYTES_DOWNLOADED_PER_HOST") != "" {
+		maxBytesDownloadedPerHost, err := env.GetEnvInt("MAX_BYTES_DOWNLOADED_PER_HOST")
+		if err != nil {
+			wc.Logger.WithError(err).Fatal("Failed to convert MAX_BYTES_DOWNLOADED_PER_HOST from string to int")
+		}
+		wc.Options.MaxBytesDownloadedPerHost = int64(maxBytesDownloadedPerHost)
+		wc.Logger.WithField("MAX_BYTES_DOWNLOADED_PER_HOST: ", wc.Options.MaxBytesDownloadedPerHost).Info("Successfully got environment variable")
+	}
+
+	if os.Getenv("MAX_DURATION") != "" {
+		wc.Options.MaxDuration, err = env.GetEnvDuration("MAX_DURATION")
+		if err != nil {
+			wc.Logger.WithError(err).Fatal("Failed to convert MAX_DURATION from string to duration")
+		}
+		wc.Logger.WithField("MAX_DURATION: ", wc.Options.MaxDuration).Info("Successfully got environment variable")
+	}
+
 	if os.Getenv("MAX_ITEMS_FOUND") != "" {
 		wc.Options.MaxItemsFound, err = env.GetEnvInt("MAX_ITEMS_FOUND")
 		if err != nil {
@@ -76,6 +220,32 @@ func (wc *WebCrawler) getEnvVariables() {
 		wc.Logger.WithField("MAX_ITEMS_FOUND: ", wc.Options.MaxItemsFound).Info("Successfully got environment variable")
 	}
 
+	if os.Getenv("VISITED_SET") != "" {
+		wc.Options.VisitedSet = os.Getenv("VISITED_SET")
+		wc.Logger.WithField("VISITED_SET: ", wc.Options.VisitedSet).Info("Successfully got environment variable")
+	}
+
+	if os.Getenv("VISITED_SET_CAPACITY") != "" {
+		wc.Options.VisitedSetCapacity, err = env.GetEnvInt("VISITED_SET_CAPACITY")
+		if err != nil {
+			wc.Logger.WithError(err).Fatal("Failed to convert VISITED_SET_CAPACITY from string to int")
+		}
+		wc.Logger.WithField("VISITED_SET_CAPACITY: ", wc.Options.VisitedSetCapacity).Info("Successfully got environment variable")
+	}
+
+	if os.Getenv("VISITED_SET_FALSE_POSITIVE_RATE") != "" {
+		wc.Options.VisitedSetFalsePositiveRate, err = env.GetEnvFloat("VISITED_SET_FALSE_POSITIVE_RATE")
+		if err != nil {
+			wc.Logger.WithError(err).Fatal("Failed to convert VISITED_SET_FALSE_POSITIVE_RATE from string to float")
+		}
+		wc.Logger.WithField("VISITED_SET_FALSE_POSITIVE_RATE: ", wc.Options.VisitedSetFalsePositiveRate).Info("Successfully got environment variable")
+	}
+
+	if os.Getenv("VISITED_SET_DIR") != "" {
+		wc.Options.VisitedSetDir = os.Getenv("VISITED_SET_DIR")
+		wc.Logger.WithField("VISITED_SET_DIR: ", wc.Options.VisitedSetDir).Info("Successfully got environment variable")
+	}
+
 	if os.Getenv("WEB_SCRAPER_WORKER_COUNT") != "" {
 		wc.Options.WebScraperWorkerCount, err = env.GetEnvInt("WEB_SCRAPER_WORKER_COUNT")
 		if err != nil {
@@ -94,6 +264,118 @@ func (wc *WebCrawler) getEnvVariables() {
 		wc.Logger.WithField("AWS_S3_BUCKET: ", wc.Options.AWSS3Bucket).Info("Successfully got environment variable")
 	}
 
+	if os.Getenv("DENIED_HOSTS") != "" {
+		wc.Options.DeniedHosts = strings.Split(os.Getenv("DENIED_HOSTS"), ",")
+		wc.Logger.WithField("DENIED_HOSTS: ", wc.Options.DeniedHosts).Info("Successfully got environment variable")
+	}
+
+	if os.Getenv("EXCLUDE_URL_PATTERNS") != "" {
+		wc.Options.ExcludeURLPatterns = strings.Split(os.Getenv("EXCLUDE_URL_PATTERNS"), ",")
+		wc.Logger.WithField("EXCLUDE_URL_PATTERNS: ", wc.Options.ExcludeURLPatterns).Info("Successfully got environment variable")
+	}
+
+	if os.Getenv("HTTP_TIMEOUT") != "" {
+		wc.Options.Transport.Timeout, err = env.GetEnvDuration("HTTP_TIMEOUT")
+		if err != nil {
+			wc.Logger.WithError(err).Fatal("Failed to convert HTTP_TIMEOUT from string to duration")
+		}
+		wc.Logger.WithField("HTTP_TIMEOUT: ", wc.Options.Transport.Timeout).Info("Successfully got environment variable")
+	}
+
+	if os.Getenv("MAX_IDLE_CONNS") != "" {
+		wc.Options.Transport.MaxIdleConns, err = env.GetEnvInt("MAX_IDLE_CONNS")
+		if err != nil {
+			wc.Logger.WithError(err).Fatal("Failed to convert MAX_IDLE_CONNS from string to int")
+		}
+		wc.Logger.WithField("MAX_IDLE_CONNS: ", wc.Options.Transport.MaxIdleConns).Info("Successfully got environment variable")
+	}
+
+	if os.Getenv("MAX_IDLE_CONNS_PER_HOST") != "" {
+		wc.Options.Transport.MaxIdleConnsPerHost, err = env.GetEnvInt("MAX_IDLE_CONNS_PER_HOST")
+		if err != nil {
+			wc.Logger.WithError(err).Fatal("Failed to convert MAX_IDLE_CONNS_PER_HOST from string to int")
+		}
+		wc.Logger.WithField("MAX_IDLE_CONNS_PER_HOST: ", wc.Options.Transport.MaxIdleConnsPerHost).Info("Successfully got environment variable")
+	}
+
+	if os.Getenv("MAX_BODY_SIZE") != "" {
+		maxBodySize, err := env.GetEnvInt("MAX_BODY_SIZE")
+		if err != nil {
+			wc.Logger.WithError(err).Fatal("Failed to convert MAX_BODY_SIZE from string to int")
+		}
+		wc.Options.Transport.MaxBodySize = int64(maxBodySize)
+		wc.Logger.WithField("MAX_BODY_SIZE: ", wc.Options.Transport.MaxBodySize).Info("Successfully got environment variable")
+	}
+
+	if os.Getenv("DISABLE_HTTP2") != "" {
+		wc.Options.Transport.DisableHTTP2, err = env.GetEnvBool("DISABLE_HTTP2")
+		if err != nil {
+			wc.Logger.WithError(err).Fatal("Failed to convert DISABLE_HTTP2 from string to bool")
+		}
+		wc.Logger.WithField("DISABLE_HTTP2: ", wc.Options.Transport.DisableHTTP2).Info("Successfully got environment variable")
+	}
+
+	if os.Getenv("INSECURE_SKIP_VERIFY") != "" {
+		wc.Options.Transport.InsecureSkipVerify, err = env.GetEnvBool("INSECURE_SKIP_VERIFY")
+		if err != nil {
+			wc.Logger.WithError(err).Fatal("Failed to convert INSECURE_SKIP_VERIFY from string to bool")
+		}
+		wc.Logger.WithField("INSECURE_SKIP_VERIFY: ", wc.Options.Transport.InsecureSkipVerify).Info("Successfully got environment variable")
+	}
+
+	if os.Getenv("SAME_HOST") != "" {
+		wc.Options.SameHost, err = env.GetEnvBool("SAME_HOST")
+		if err != nil {
+			wc.Logger.WithError(err).Fatal("Failed to convert SAME_HOST from string to bool")
+		}
+		wc.Logger.WithField("SAME_HOST: ", wc.Options.SameHost).Info("Successfully got environment variable")
+	}
+
+	if os.Getenv("SAME_DOMAIN") != "" {
+		wc.Options.SameDomain, err = env.GetEnvBool("SAME_DOMAIN")
+		if err != nil {
+			wc.Logger.WithError(err).Fatal("Failed to convert SAME_DOMAIN from string to bool")
+		}
+		wc.Logger.WithField("SAME_DOMAIN: ", wc.Options.SameDomain).Info("Successfully got environment variable")
+	}
+
+	if os.Getenv("SITEMAPS") != "" {
+		wc.Options.Sitemaps, err = env.GetEnvBool("SITEMAPS")
+		if err != nil {
+			wc.Logger.WithError(err).Fatal("Failed to convert SITEMAPS from string to bool")
+		}
+		wc.Logger.WithField("SITEMAPS: ", wc.Options.Sitemaps).Info("Successfully got environment variable")
+	}
+
+	if os.Getenv("SITEMAP_MODIFIED_SINCE") != "" {
+		wc.Options.SitemapModifiedSince, err = time.Parse(time.RFC3339, os.Getenv("SITEMAP_MODIFIED_SINCE"))
+		if err != nil {
+			wc.Logger.WithError(err).Fatal("Failed to convert SITEMAP_MODIFIED_SINCE from string to time")
+		}
+		wc.Logger.WithField("SITEMAP_MODIFIED_SINCE: ", wc.Options.SitemapModifiedSince).Info("Successfully got environment variable")
+	}
+
+	if os.Getenv("EXTRACT_STRUCTURED_DATA") != "" {
+		wc.Options.ExtractStructuredData, err = env.GetEnvBool("EXTRACT_STRUCTURED_DATA")
+		if err != nil {
+			wc.Logger.WithError(err).Fatal("Failed to convert EXTRACT_STRUCTURED_DATA from string to bool")
+		}
+		wc.Logger.WithField("EXTRACT_STRUCTURED_DATA: ", wc.Options.ExtractStructuredData).Info("Successfully got environment variable")
+	}
+
+	if os.Getenv("FEED_URLS") != "" {
+		wc.Options.FeedURLs = strings.Split(os.Getenv("FEED_URLS"), ",")
+		wc.Logger.WithField("FEED_URLS: ", wc.Options.FeedURLs).Info("Successfully got environment variable")
+	}
+
+	if os.Getenv("ONLY_NEW_FEED_ENTRIES") != "" {
+		wc.Options.OnlyNewFeedEntries, err = env.GetEnvBool("ONLY_NEW_FEED_ENTRIES")
+		if err != nil {
+			wc.Logger.WithError(err).Fatal("Failed to convert ONLY_NEW_FEED_ENTRIES from string to bool")
+		}
+		wc.Logger.WithField("ONLY_NEW_FEED_ENTRIES: ", wc.Options.OnlyNewFeedEntries).Info("Successfully got environment variable")
+	}
+
 	if os.Getenv("HEADER_KEY") != "" {
 		wc.Options.HeaderKey = os.Getenv("HEADER_KEY")
 		wc.Logger.WithField("HEADER_KEY: ", wc.Options.HeaderKey).Info("Successfully got environment variable")
@@ -107,3 +389,11 @@ func (wc *WebCrawler) getEnvVariables() {
 	wc.Logger.Info("Successfully got environment variables")
 
 }
+
+// retryPolicy returns the retry policy of the options, creating the default retry policy if none is set.
+func (wc *WebCrawler) retryPolicy() *webscraper.RetryPolicy {
+	if wc.Options.RetryPolicy == nil {
+		wc.Options.RetryPolicy = webscraper.DefaultRetryPolicy()
+	}
+	return wc.Options.RetryPolicy
+}
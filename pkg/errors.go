@@ -0,0 +1,55 @@
+package webcrawler
+
+import (
+	"errors"
+	"fmt"
+
+	webscraper "github.com/cody6750/web-crawler/pkg/webScraper"
+)
+
+const (
+	// errorBudgetMinUrls is the minimum number of crawled urls before Options.MaxFailureRate is enforced, so that a
+	// single early failure does not abort the crawl.
+	errorBudgetMinUrls = 10
+)
+
+// ErrErrorBudgetExceeded is returned when more urls failed than the error budget of the options allows.
+var ErrErrorBudgetExceeded = errors.New("error budget exceeded")
+
+// CrawlError represents a url that could not be crawled.
+type CrawlError struct {
+	URL        string
+	ParentURL  string
+	Depth      int
+	StatusCode int
+	ErrorKind  webscraper.ErrorKind
+	Message    string
+}
+
+// newCrawlError creates the error record of the url. The status code is 0 if no response was received.
+func newCrawlError(u *webscraper.URL, statusCode int, err error) *CrawlError {
+	return &CrawlError{
+		URL:        u.CurrentURL,
+		ParentURL:  u.ParentURL,
+		Depth:      u.CurrentDepth,
+		StatusCode: statusCode,
+		ErrorKind:  webscraper.ClassifyError(err),
+		Message:    err.Error(),
+	}
+}
+
+// checkErrorBudget checks the failed urls of the crawl against Options.MaxFailedUrls and Options.MaxFailureRate.
+// Returns ErrErrorBudgetExceeded once either is exceeded.
+func (wc *WebCrawler) checkErrorBudget() error {
+	metrics := wc.Metrics()
+	failed, crawled := metrics.FailedUrls, metrics.FailedUrls+metrics.UrlsVisited
+	if wc.Options.MaxFailedUrls > 0 && failed > wc.Options.MaxFailedUrls {
+		return fmt.Errorf("%w: %v urls failed, max failed urls: %v", ErrErrorBudgetExceeded, failed, wc.Options.MaxFailedUrls)
+	}
+	if wc.Options.MaxFailureRate > 0 && crawled >= errorBudgetMinUrls {
+		if rate := float64(failed) / float64(crawled); rate > wc.Options.MaxFailureRate {
+			return fmt.Errorf("%w: %v of %v urls failed, max failure rate: %v", ErrErrorBudgetExceeded, failed, crawled, wc.Options.MaxFailureRate)
+		}
+	}
+	return nil
+}
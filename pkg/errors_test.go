@@ -0,0 +1,86 @@
+package webcrawler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	options "github.com/cody6750/web-crawler/pkg/options"
+	webscraper "github.com/cody6750/web-crawler/pkg/webScraper"
+)
+
+func TestWebCrawler_checkErrorBudget(t *testing.T) {
+	tests := []struct {
+		name           string
+		maxFailedUrls  int
+		maxFailureRate float64
+		metrics        Metrics
+		wantErr        error
+	}{
+		{name: "No budget", metrics: Metrics{FailedUrls: 100}, wantErr: nil},
+		{name: "Within max failed urls", maxFailedUrls: 2, metrics: Metrics{FailedUrls: 2, UrlsVisited: 1}, wantErr: nil},
+		{name: "Max failed urls exceeded", maxFailedUrls: 2, metrics: Metrics{FailedUrls: 3, UrlsVisited: 10}, wantErr: ErrErrorBudgetExceeded},
+		{name: "Failure rate below minimum urls", maxFailureRate: 0.5, metrics: Metrics{FailedUrls: 3}, wantErr: nil},
+		{name: "Within failure rate", maxFailureRate: 0.5, metrics: Metrics{FailedUrls: 5, UrlsVisited: 5}, wantErr: nil},
+		{name: "Failure rate exceeded", maxFailureRate: 0.5, metrics: Metrics{FailedUrls: 6, UrlsVisited: 4}, wantErr: ErrErrorBudgetExceeded},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := options.New()
+			o.MaxFailedUrls = tt.maxFailedUrls
+			o.MaxFailureRate = tt.maxFailureRate
+			wc := NewWithOptions(o)
+			wc.metrics = tt.metrics
+			if err := wc.checkErrorBudget(); !errors.Is(err, tt.wantErr) {
+				t.Errorf("WebCrawler.checkErrorBudget() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWebCrawler_CrawlErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			http.NotFound(rw, r)
+			return
+		}
+		rw.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		url        string
+		wantErrors []*CrawlError
+		wantErr    error
+	}{
+		{
+			name: "Failed url is recorded",
+			url:  server.URL + "/index.html",
+			wantErrors: []*CrawlError{{
+				URL:        server.URL + "/index.html",
+				StatusCode: http.StatusBadGateway,
+				ErrorKind:  webscraper.ErrorKindStatus,
+				Message:    "request to " + server.URL + "/index.html failed with status code 502",
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := options.New()
+			o.CrawlDelay = 0
+			o.RetryPolicy.MaxAttempts = 1
+			wc := NewWithOptions(o)
+			got, err := wc.CrawlContext(context.Background(), tt.url, nil)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("WebCrawler.CrawlContext() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got.Errors, tt.wantErrors) {
+				t.Errorf("WebCrawler.CrawlContext() errors = %+v, want %+v", got.Errors, tt.wantErrors)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package webcrawler
+
+import (
+	webscraper "github.com/cody6750/web-crawler/pkg/webScraper"
+)
+
+// EventType identifies the kind of an event delivered by CrawlStream.
+type EventType string
+
+const (
+	// EventResponse is sent for every web scraper response.
+	EventResponse EventType = "response"
+
+	// EventItem is sent for every item extracted in a web scraper response.
+	EventItem EventType = "item"
+
+	// EventProgress is sent for every url that has been crawled.
+	EventProgress EventType = "progress"
+
+	// EventFailed is sent for every url that could not be crawled.
+	EventFailed EventType = "failed"
+
+	// EventDone is the last event of a crawl.
+	EventDone EventType = "done"
+)
+
+// Event represents a single result of a streamed crawl. Only the fields matching the event type are set.
+type Event struct {
+	Type EventType
+
+	// Response is set for EventResponse events.
+	Response *webscraper.Response
+
+	// Item is set for EventItem events.
+	Item *webscraper.Item
+
+	// Progress is set for EventProgress events.
+	Progress *Progress
+
+	// Error is set for EventFailed events.
+	Error *CrawlError
+
+	// Metrics holds the final metrics of the crawl, set for EventDone events.
+	Metrics *Metrics
+
+	// StopReason explains why the crawl ended, set for EventDone events.
+	StopReason StopReason
+
+	// Err holds the error that ended the crawl, set for EventDone events.
+	Err error
+}
+
+// Progress describes a single crawled url along with the running metrics of the crawl at the time it was crawled.
+type Progress struct {
+	ScraperNumber int
+	URL           string
+	ParentURL     string
+	Depth         int
+	StatusCode    int
+	UrlsFound     int
+	ItemsFound    int
+	Metrics       Metrics
+}
@@ -0,0 +1,260 @@
+package webcrawler
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/url"
+	"strings"
+	"time"
+
+	webscraper "github.com/cody6750/web-crawler/pkg/webScraper"
+	"github.com/sirupsen/logrus"
+)
+
+// maxFeedSize is the maximum size of an RSS or Atom feed.
+const maxFeedSize = 10 * 1024 * 1024
+
+// feedDateLayouts are the date layouts of RSS 2.0 <pubDate>, which follows RFC 822 loosely, and of Atom and Dublin
+// Core dates.
+var feedDateLayouts = []string{
+	time.RFC1123Z, time.RFC1123, "Mon, 2 Jan 2006 15:04:05 -0700", "Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700", "2 Jan 2006 15:04:05 MST", time.RFC822Z, time.RFC822,
+	time.RFC3339Nano, "2006-01-02T15:04Z07:00", "2006-01-02",
+}
+
+// FeedStateStore is implemented by state stores that remember the publish date of the newest entry of every feed
+// between crawls, required by Options.OnlyNewFeedEntries. FileStateStore implements it.
+type FeedStateStore interface {
+	// LoadFeedState returns the publish date of the newest entry of the feed, zero if the feed has not been read.
+	LoadFeedState(feedURL string) (time.Time, error)
+
+	// SaveFeedState saves the publish date of the newest entry of the feed.
+	SaveFeedState(feedURL string, newest time.Time) error
+}
+
+// feed is an RSS 2.0, RSS 1.0 or Atom feed.
+type feed struct {
+	Items    []rssItem   `xml:"channel>item"`
+	RDFItems []rssItem   `xml:"item"`
+	Entries  []atomEntry `xml:"entry"`
+}
+
+// rssItem is an <item> of an RSS feed.
+type rssItem struct {
+	Title   string `xml:"title"`
+	Link    string `xml:"link"`
+	GUID    string `xml:"guid"`
+	PubDate string `xml:"pubDate"`
+	Date    string `xml:"date"`
+}
+
+// atomEntry is an <entry> of an Atom feed.
+type atomEntry struct {
+	Title     string     `xml:"title"`
+	Links     []atomLink `xml:"link"`
+	Published string     `xml:"published"`
+	Updated   string     `xml:"updated"`
+}
+
+// atomLink is a <link> of an Atom entry.
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+}
+
+// feedEntry is an entry of a feed, whatever its format.
+type feedEntry struct {
+	Link      string
+	Title     string
+	Published time.Time
+}
+
+// parseFeed parses the entries of an RSS or Atom feed.
+func parseFeed(body []byte) ([]feedEntry, error) {
+	f := &feed{}
+	decoder := xml.NewDecoder(bytes.NewReader(body))
+	decoder.CharsetReader = charsetReader
+	if err := decoder.Decode(f); err != nil {
+		return nil, fmt.Errorf("invalid feed: %w", err)
+	}
+	var entries []feedEntry
+	for _, item := range append(f.Items, f.RDFItems...) {
+		link := item.Link
+		if strings.TrimSpace(link) == "" {
+			link = item.GUID
+		}
+		published := parseFeedDate(item.PubDate)
+		if published.IsZero() {
+			published = parseFeedDate(item.Date)
+		}
+		entries = append(entries, feedEntry{Link: strings.TrimSpace(link), Title: strings.TrimSpace(item.Title), Published: published})
+	}
+	for _, entry := range f.Entries {
+		published := parseFeedDate(entry.Published)
+		if published.IsZero() {
+			published = parseFeedDate(entry.Updated)
+		}
+		entries = append(entries, feedEntry{Link: atomEntryLink(entry.Links), Title: strings.TrimSpace(entry.Title), Published: published})
+	}
+	return entries, nil
+}
+
+// atomEntryLink returns the alternate link of an Atom entry, the first link if it has none.
+func atomEntryLink(links []atomLink) string {
+	for _, link := range links {
+		if link.Rel == "" || link.Rel == "alternate" {
+			return strings.TrimSpace(link.Href)
+		}
+	}
+	if len(links) > 0 {
+		return strings.TrimSpace(links[0].Href)
+	}
+	return ""
+}
+
+// parseFeedDate parses the date of a feed entry, zero if it is missing or invalid.
+func parseFeedDate(value string) time.Time {
+	value = strings.TrimSpace(value)
+	for _, layout := range feedDateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date
+		}
+	}
+	return time.Time{}
+}
+
+// charsetReader decodes the ISO-8859-1 and US-ASCII feeds that the xml decoder cannot decode on its own.
+func charsetReader(charset string, input io.Reader) (io.Reader, error) {
+	switch strings.ToLower(charset) {
+	case "utf-8", "utf8", "us-ascii", "ascii":
+		return input, nil
+	case "iso-8859-1", "iso8859-1", "latin1":
+		latin1, err := ioutil.ReadAll(input)
+		if err != nil {
+			return nil, err
+		}
+		runes := make([]rune, len(latin1))
+		for i, b := range latin1 {
+			runes[i] = rune(b)
+		}
+		return strings.NewReader(string(runes)), nil
+	}
+	return nil, fmt.Errorf("unsupported charset %v", charset)
+}
+
+// processFeeds seeds the frontier with the entries of the feeds. Every feed entry is found from its feed seed: it is
+// crawled at depth 0 up to the max depth of the feed, is checked against the scope rules relative to the feed and
+// counts towards the metrics of the feed. With Options.OnlyNewFeedEntries, entries that are not newer than the newest
+// entry crawled by a previous crawl are skipped. Entries without a publish date are always kept.
+func (wc *WebCrawler) processFeeds(store StateStore, feeds []Seed) {
+	feedStore, _ := store.(FeedStateStore)
+	for _, feed := range feeds {
+		if wc.ctx.Err() != nil {
+			return
+		}
+		feed.URL = strings.TrimSpace(feed.URL)
+		feedURL := feed.URL
+		if !wc.isAllowedByRobots(feedURL) {
+			wc.Logger.WithField("url", feedURL).Debug("Feed is disallowed by robots.txt")
+			continue
+		}
+		var since time.Time
+		if wc.Options.OnlyNewFeedEntries && feedStore != nil {
+			var err error
+			if since, err = feedStore.LoadFeedState(feedURL); err != nil {
+				wc.Logger.WithError(err).WithField("url", feedURL).Warn("Unable to load feed state")
+				continue
+			}
+		}
+		body, err := wc.download(feedURL, maxFeedSize)
+		if err != nil {
+			wc.Logger.WithError(err).WithField("url", feedURL).Warn("Unable to read feed")
+			continue
+		}
+		entries, err := parseFeed(body)
+		if err != nil {
+			wc.Logger.WithError(err).WithField("url", feedURL).Warn("Unable to read feed")
+			continue
+		}
+
+		base, _ := url.Parse(feedURL)
+		root := wc.seedRoot(feed)
+		found := make([]*webscraper.URL, 0, len(entries))
+		for _, entry := range entries {
+			link := resolveLoc(base, entry.Link)
+			if link == "" {
+				continue
+			}
+			if !since.IsZero() && !entry.Published.IsZero() && !entry.Published.After(since) {
+				continue
+			}
+			found = append(found, &webscraper.URL{
+				RootURL:      root.RootURL,
+				ParentURL:    feedURL,
+				CurrentURL:   link,
+				CurrentDepth: 0,
+				MaxDepth:     root.MaxDepth,
+				Tag:          root.Tag,
+				Title:        entry.Title,
+				Published:    entry.Published,
+			})
+		}
+		wc.Logger.WithFields(logrus.Fields{"url": feedURL, "urls": len(found), "entries": len(entries)}).Debug("Successfully read feed")
+		wc.incrementMetrics(&Metrics{FeedUrlsFound: len(found)})
+		wc.processScrapedUrls(found)
+	}
+}
+
+// feedEntryCrawled records the publish date of a feed entry that has been crawled, so that Options.OnlyNewFeedEntries
+// skips it in the next crawl.
+func (wc *WebCrawler) feedEntryCrawled(u *webscraper.URL) {
+	if !wc.Options.OnlyNewFeedEntries || u.CurrentDepth != 0 || u.ParentURL == "" || u.Published.IsZero() {
+		return
+	}
+	wc.feedLock.Lock()
+	defer wc.feedLock.Unlock()
+	if u.Published.After(wc.feedStates[u.ParentURL]) {
+		wc.feedStates[u.ParentURL] = u.Published
+	}
+}
+
+// restoreFeedStates restores the feed states of a checkpoint.
+func (wc *WebCrawler) restoreFeedStates(feedStates map[string]time.Time) {
+	wc.feedLock.Lock()
+	defer wc.feedLock.Unlock()
+	for feedURL, newest := range feedStates {
+		wc.feedStates[feedURL] = newest
+	}
+}
+
+// copyFeedStates returns a copy of the feed states of the crawl, nil if no feed has been read.
+func (wc *WebCrawler) copyFeedStates() map[string]time.Time {
+	wc.feedLock.Lock()
+	defer wc.feedLock.Unlock()
+	if len(wc.feedStates) == 0 {
+		return nil
+	}
+	feedStates := make(map[string]time.Time, len(wc.feedStates))
+	for feedURL, newest := range wc.feedStates {
+		feedStates[feedURL] = newest
+	}
+	return feedStates
+}
+
+// saveFeedStates saves the newest entry crawled of every feed to the state store. It is called once the crawl has
+// stopped, unless it failed. Entries that were not crawled are read again by the next crawl, unless a newer entry of
+// the feed was crawled.
+func (wc *WebCrawler) saveFeedStates(store StateStore) {
+	feedStore, ok := store.(FeedStateStore)
+	if !ok {
+		return
+	}
+	for feedURL, newest := range wc.copyFeedStates() {
+		if err := feedStore.SaveFeedState(feedURL, newest); err != nil {
+			wc.Logger.WithError(err).WithField("url", feedURL).Warn("Unable to save feed state")
+		}
+	}
+}
@@ -0,0 +1,215 @@
+package webcrawler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	options "github.com/cody6750/web-crawler/pkg/options"
+	webscraper "github.com/cody6750/web-crawler/pkg/webScraper"
+)
+
+func Test_parseFeed(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    []feedEntry
+		wantErr bool
+	}{
+		{
+			name: "RSS 2.0",
+			body: `<?xml version="1.0"?><rss version="2.0"><channel><title>Deals</title>
+				<item><title>First</title><link>https://www.example.com/1</link><pubDate>Mon, 02 Jan 2006 15:04:05 -0700</pubDate></item>
+				<item><title>Second</title><guid>https://www.example.com/2</guid><pubDate>Tue, 3 Jan 2006 15:04:05 GMT</pubDate></item>
+			</channel></rss>`,
+			want: []feedEntry{
+				{Link: "https://www.example.com/1", Title: "First", Published: time.Date(2006, 1, 2, 22, 4, 5, 0, time.UTC)},
+				{Link: "https://www.example.com/2", Title: "Second", Published: time.Date(2006, 1, 3, 15, 4, 5, 0, time.UTC)},
+			},
+		},
+		{
+			name: "Atom",
+			body: `<feed xmlns="http://www.w3.org/2005/Atom"><title>Blog</title>
+				<entry><title>Post</title><link rel="self" href="https://www.example.com/feed/1"/><link href="https://www.example.com/1"/><published>2006-01-02T15:04:05Z</published></entry>
+				<entry><title>Update</title><link rel="edit" href="https://www.example.com/2"/><updated>2006-01-03T15:04:05Z</updated></entry>
+			</feed>`,
+			want: []feedEntry{
+				{Link: "https://www.example.com/1", Title: "Post", Published: time.Date(2006, 1, 2, 15, 4, 5, 0, time.UTC)},
+				{Link: "https://www.example.com/2", Title: "Update", Published: time.Date(2006, 1, 3, 15, 4, 5, 0, time.UTC)},
+			},
+		},
+		{
+			name: "ISO-8859-1",
+			body: "<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?><rss><channel><item><title>Caf\xe9</title><link>https://www.example.com/1</link></item></channel></rss>",
+			want: []feedEntry{{Link: "https://www.example.com/1", Title: "Café"}},
+		},
+		{name: "Invalid feed", body: `<rss><channel>`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseFeed([]byte(tt.body))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseFeed() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("parseFeed() = %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				if got[i].Link != tt.want[i].Link || got[i].Title != tt.want[i].Title || !got[i].Published.Equal(tt.want[i].Published) {
+					t.Errorf("parseFeed() entry %v = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestWebCrawler_CrawlFeeds(t *testing.T) {
+	var (
+		lock    sync.Mutex
+		crawled []string
+		items   = `<item><title>Old</title><link>/old</link><pubDate>Mon, 02 Jan 2006 15:04:05 GMT</pubDate></item>`
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			http.NotFound(rw, r)
+		case "/feed.xml":
+			lock.Lock()
+			rw.Write([]byte(`<rss><channel>` + items + `</channel></rss>`))
+			lock.Unlock()
+		default:
+			lock.Lock()
+			crawled = append(crawled, r.URL.Path)
+			lock.Unlock()
+			rw.Write([]byte(`<div class="post"><span>content</span></div>`))
+		}
+	}))
+	defer server.Close()
+
+	itemsToGet := []webscraper.ScrapeItemConfig{{
+		ItemName:    "Post",
+		ItemToGet:   webscraper.ExtractFromTokenConfig{Tag: "div", Attribute: "class", AttributeValue: "post"},
+		ItemDetails: map[string]webscraper.ExtractFromTokenConfig{"content": {Tag: "span"}},
+	}}
+	dir := t.TempDir()
+	tests := []struct {
+		name       string
+		newItem    string
+		want       []string
+		wantTitles []string
+	}{
+		{name: "Feed entries are crawled", want: []string{"/", "/old"}, wantTitles: []string{"Old"}},
+		{
+			name:       "Only new feed entries are crawled",
+			newItem:    `<item><title>New</title><link>/new</link><pubDate>Tue, 03 Jan 2006 15:04:05 GMT</pubDate></item>`,
+			want:       []string{"/", "/new"},
+			wantTitles: []string{"New"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lock.Lock()
+			crawled = nil
+			items += tt.newItem
+			lock.Unlock()
+			o := options.New()
+			o.CrawlDelay = 0
+			o.CheckpointDir = dir
+			o.FeedURLs = []string{server.URL + "/feed.xml"}
+			o.OnlyNewFeedEntries = true
+			wc := NewWithOptions(o)
+			var titles []string
+			wc.OnEvent = func(event *Event) {
+				if event.Type == EventItem && event.Item.URL.Title != "" {
+					titles = append(titles, event.Item.URL.Title)
+				}
+			}
+			got, err := wc.CrawlContext(context.Background(), server.URL+"/", itemsToGet)
+			if err != nil {
+				t.Fatalf("WebCrawler.CrawlContext() error = %v", err)
+			}
+			lock.Lock()
+			defer lock.Unlock()
+			sort.Strings(crawled)
+			if !reflect.DeepEqual(crawled, tt.want) || got.Metrics.FeedUrlsFound != 1 {
+				t.Errorf("WebCrawler.CrawlContext() crawled = %v, feed urls found = %v, want %v and 1", crawled, got.Metrics.FeedUrlsFound, tt.want)
+			}
+			if !reflect.DeepEqual(titles, tt.wantTitles) {
+				t.Errorf("WebCrawler.CrawlContext() item titles = %v, want %v", titles, tt.wantTitles)
+			}
+		})
+	}
+}
+
+func TestWebCrawler_CrawlFeeds_withoutStateStore(t *testing.T) {
+	o := options.New()
+	o.FeedURLs = []string{"https://www.example.com/feed.xml"}
+	o.OnlyNewFeedEntries = true
+	if _, err := NewWithOptions(o).CrawlStream(context.Background(), "https://www.example.com/", nil); err == nil {
+		t.Errorf("WebCrawler.CrawlStream() error = nil, want error")
+	}
+}
+
+func TestWebCrawler_CrawlFeedSeeds(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			http.NotFound(rw, r)
+		case "/feed.xml":
+			// The second entry is on localhost, another host than the feed on 127.0.0.1.
+			other := strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+			rw.Write([]byte(`<rss><channel>` +
+				`<item><title>Post</title><link>/post</link><pubDate>Tue, 03 Jan 2006 15:04:05 GMT</pubDate></item>` +
+				`<item><title>Other</title><link>` + other + `/other</link><pubDate>Mon, 02 Jan 2006 15:04:05 GMT</pubDate></item>` +
+				`</channel></rss>`))
+		default:
+			rw.Write([]byte(`<a href="/next">next</a>`))
+		}
+	}))
+	defer server.Close()
+	feedURL := server.URL + "/feed.xml"
+
+	store, err := NewFileStateStore(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewFileStateStore() error = %v", err)
+	}
+	crawl := func(maxVisitedUrls int) *Response {
+		o := options.New()
+		o.CrawlDelay = 0
+		o.AllowEmptyItem = true
+		o.SameHost = true
+		o.MaxVisitedUrls = maxVisitedUrls
+		o.OnlyNewFeedEntries = true
+		wc := NewWithOptions(o)
+		wc.StateStore = store
+		got, err := wc.CrawlSeedsContext(context.Background(), []Seed{{URL: feedURL, Feed: true, Tag: "blog"}}, nil)
+		if err != nil {
+			t.Fatalf("WebCrawler.CrawlSeedsContext() error = %v", err)
+		}
+		return got
+	}
+
+	got := crawl(1)
+	if got.StopReason != StopReasonMaxVisitedUrls || got.Metrics.UrlsVisited != 1 || got.Metrics.OutOfScopeUrlsFound != 1 {
+		t.Errorf("WebCrawler.CrawlSeedsContext() stop reason = %v, urls visited = %v, out of scope urls = %v, want %v, 1 and 1", got.StopReason, got.Metrics.UrlsVisited, got.Metrics.OutOfScopeUrlsFound, StopReasonMaxVisitedUrls)
+	}
+	if seeds := got.Metrics.Seeds; len(seeds) != 1 || seeds[feedURL].UrlsVisited != 1 {
+		t.Errorf("WebCrawler.CrawlSeedsContext() seed metrics = %+v, want the entries counted under the feed %v", seeds, feedURL)
+	}
+	newest, err := store.LoadFeedState(feedURL)
+	if want := time.Date(2006, 1, 3, 15, 4, 5, 0, time.UTC); err != nil || !newest.Equal(want) {
+		t.Errorf("FileStateStore.LoadFeedState() = %v, %v, want the crawled entry %v although the crawl did not complete", newest, err, want)
+	}
+
+	// The crawled entry is newer than the out of scope entry, so no entry is read again.
+	if got := crawl(0); got.Metrics.UrlsVisited != 0 || got.Metrics.FeedUrlsFound != 0 {
+		t.Errorf("WebCrawler.CrawlSeedsContext() urls visited = %v, feed urls found = %v, want 0 and 0", got.Metrics.UrlsVisited, got.Metrics.FeedUrlsFound)
+	}
+}
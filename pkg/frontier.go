@@ -0,0 +1,153 @@
+package webcrawler
+
+import (
+	"container/heap"
+	"fmt"
+	"regexp"
+
+	options "github.com/cody6750/web-crawler/pkg/options"
+	webscraper "github.com/cody6750/web-crawler/pkg/webScraper"
+)
+
+// frontierItem is a url waiting to be crawled along with its place in the crawl order.
+type frontierItem struct {
+	url *webscraper.URL
+
+	// priority is the depth of the url for breadth and depth first crawls and its score for best first crawls.
+	priority float64
+
+	// seq is the order in which the urls were queued, it breaks ties between urls of the same priority.
+	seq uint64
+
+	// front marks a url that is crawled again, it goes before every other url of its host.
+	front bool
+}
+
+// frontierOrder decides the order in which the urls of the frontier are crawled, following Options.CrawlOrder.
+type frontierOrder struct {
+	order string
+	score options.URLScorer
+	seq   uint64
+}
+
+// newFrontierOrder creates the crawl order of the options. The options must have been validated by
+// validateCrawlOrder.
+func newFrontierOrder(o *options.Options) *frontierOrder {
+	return &frontierOrder{order: o.CrawlOrder, score: o.URLScorer}
+}
+
+// validateCrawlOrder checks that Options.CrawlOrder is known and that best first crawls have a Options.URLScorer.
+func validateCrawlOrder(o *options.Options) error {
+	switch o.CrawlOrder {
+	case options.CrawlOrderBreadthFirst, options.CrawlOrderDepthFirst:
+		return nil
+	case options.CrawlOrderBestFirst:
+		if o.URLScorer == nil {
+			return fmt.Errorf("crawl order %v requires a url scorer", o.CrawlOrder)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown crawl order %q, expected one of %v, %v or %v", o.CrawlOrder, options.CrawlOrderBreadthFirst, options.CrawlOrderDepthFirst, options.CrawlOrderBestFirst)
+}
+
+// item places the url in the crawl order. Not safe for concurrent use, the scheduler calls it under its lock.
+func (f *frontierOrder) item(u *webscraper.URL, front bool) *frontierItem {
+	f.seq++
+	item := &frontierItem{url: u, seq: f.seq, front: front}
+	switch f.order {
+	case options.CrawlOrderBestFirst:
+		item.priority = f.score(u)
+	case options.CrawlOrderDepthFirst:
+		item.priority = float64(u.CurrentDepth)
+	default:
+		item.priority = -float64(u.CurrentDepth)
+	}
+	return item
+}
+
+// less reports whether a is crawled before b. Urls that are crawled again go first, then urls of higher priority.
+// Ties go to the url queued first, or for depth first crawls the url queued last.
+func (f *frontierOrder) less(a, b *frontierItem) bool {
+	if a.front != b.front {
+		return a.front
+	}
+	if a.priority != b.priority {
+		return a.priority > b.priority
+	}
+	if f.order == options.CrawlOrderDepthFirst {
+		return a.seq > b.seq
+	}
+	return a.seq < b.seq
+}
+
+// frontierQueue holds the urls of a host waiting to be crawled in crawl order.
+type frontierQueue struct {
+	order *frontierOrder
+	items []*frontierItem
+}
+
+// push queues the item.
+func (q *frontierQueue) push(item *frontierItem) {
+	heap.Push(q, item)
+}
+
+// pop removes and returns the first item, nil if the queue is empty.
+func (q *frontierQueue) pop() *frontierItem {
+	if len(q.items) == 0 {
+		return nil
+	}
+	return heap.Pop(q).(*frontierItem)
+}
+
+// peek returns the first item without removing it, nil if the queue is empty.
+func (q *frontierQueue) peek() *frontierItem {
+	if len(q.items) == 0 {
+		return nil
+	}
+	return q.items[0]
+}
+
+// Len implements heap.Interface.
+func (q *frontierQueue) Len() int { return len(q.items) }
+
+// Less implements heap.Interface.
+func (q *frontierQueue) Less(i, j int) bool { return q.order.less(q.items[i], q.items[j]) }
+
+// Swap implements heap.Interface.
+func (q *frontierQueue) Swap(i, j int) { q.items[i], q.items[j] = q.items[j], q.items[i] }
+
+// Push implements heap.Interface.
+func (q *frontierQueue) Push(x interface{}) { q.items = append(q.items, x.(*frontierItem)) }
+
+// Pop implements heap.Interface.
+func (q *frontierQueue) Pop() interface{} {
+	last := len(q.items) - 1
+	item := q.items[last]
+	q.items[last] = nil
+	q.items = q.items[:last]
+	return item
+}
+
+// NewPatternScorer returns a url scorer for best first crawls. The score of a url is the sum of the weights of the
+// patterns it matches, minus its depth, so that among urls of the same score the shallow ones are crawled first.
+// Patterns follow the syntax of Options.IncludeURLPatterns, for example {"*/product/*": 10} crawls product pages
+// first.
+func NewPatternScorer(weights map[string]float64) (options.URLScorer, error) {
+	patterns := make(map[*regexp.Regexp]float64, len(weights))
+	for pattern, weight := range weights {
+		compiled, err := compilePatterns([]string{pattern})
+		if err != nil {
+			return nil, err
+		}
+		patterns[compiled[0]] = weight
+	}
+	return func(u *webscraper.URL) float64 {
+		score := -float64(u.CurrentDepth)
+		for pattern, weight := range patterns {
+			if pattern.MatchString(u.CurrentURL) {
+				score += weight
+			}
+		}
+		return score
+	}, nil
+}
@@ -0,0 +1,127 @@
+package webcrawler
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	options "github.com/cody6750/web-crawler/pkg/options"
+	webscraper "github.com/cody6750/web-crawler/pkg/webScraper"
+)
+
+func Test_frontierQueue(t *testing.T) {
+	scorer, err := NewPatternScorer(map[string]float64{"*/product/*": 10})
+	if err != nil {
+		t.Fatalf("NewPatternScorer() error = %v", err)
+	}
+	urls := []*webscraper.URL{
+		{CurrentURL: "https://www.example.com/a", CurrentDepth: 1},
+		{CurrentURL: "https://www.example.com/a/1", CurrentDepth: 2},
+		{CurrentURL: "https://www.example.com/b", CurrentDepth: 1},
+		{CurrentURL: "https://www.example.com/product/1", CurrentDepth: 2},
+	}
+	tests := []struct {
+		name   string
+		order  string
+		scorer options.URLScorer
+		want   []string
+	}{
+		{name: "Breadth first", order: options.CrawlOrderBreadthFirst, want: []string{"/a", "/b", "/a/1", "/product/1"}},
+		{name: "Depth first", order: options.CrawlOrderDepthFirst, want: []string{"/product/1", "/a/1", "/b", "/a"}},
+		{name: "Best first", order: options.CrawlOrderBestFirst, scorer: scorer, want: []string{"/product/1", "/a", "/b", "/a/1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := options.New()
+			o.CrawlOrder = tt.order
+			o.URLScorer = tt.scorer
+			order := newFrontierOrder(o)
+			q := &frontierQueue{order: order}
+			for _, u := range urls {
+				q.push(order.item(u, false))
+			}
+			var got []string
+			for item := q.pop(); item != nil; item = q.pop() {
+				got = append(got, strings.TrimPrefix(item.url.CurrentURL, "https://www.example.com"))
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("frontierQueue.pop() order = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_validateCrawlOrder(t *testing.T) {
+	tests := []struct {
+		name    string
+		order   string
+		scorer  options.URLScorer
+		wantErr bool
+	}{
+		{name: "Breadth first", order: options.CrawlOrderBreadthFirst, wantErr: false},
+		{name: "Depth first", order: options.CrawlOrderDepthFirst, wantErr: false},
+		{name: "Best first", order: options.CrawlOrderBestFirst, scorer: func(u *webscraper.URL) float64 { return 0 }, wantErr: false},
+		{name: "Best first without scorer", order: options.CrawlOrderBestFirst, wantErr: true},
+		{name: "Unknown order", order: "random", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := options.New()
+			o.CrawlOrder = tt.order
+			o.URLScorer = tt.scorer
+			if err := validateCrawlOrder(o); (err != nil) != tt.wantErr {
+				t.Errorf("validateCrawlOrder() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWebCrawler_CrawlOrder(t *testing.T) {
+	site := newTestSite(t, map[string]string{
+		"/":  `<a href="/a">a</a><a href="/b">b</a>`,
+		"/a": `<a href="/a1">1</a>`,
+		"/b": `<a href="/b1">1</a>`,
+	})
+
+	scorer, err := NewPatternScorer(map[string]float64{"*/a1": 10})
+	if err != nil {
+		t.Fatalf("NewPatternScorer() error = %v", err)
+	}
+	tests := []struct {
+		name   string
+		order  string
+		scorer options.URLScorer
+		want   []string
+	}{
+		{name: "Breadth first", order: options.CrawlOrderBreadthFirst, want: []string{"/", "/a", "/b", "/a1", "/b1"}},
+		{name: "Depth first", order: options.CrawlOrderDepthFirst, want: []string{"/", "/b", "/b1", "/a", "/a1"}},
+		{name: "Best first", order: options.CrawlOrderBestFirst, scorer: scorer, want: []string{"/", "/a", "/a1", "/b", "/b1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := options.New()
+			// The delay lets the urls found on a page reach the scheduler before the next url of the host is chosen.
+			o.CrawlDelayDuration = 100 * time.Millisecond
+			o.AdaptiveThrottling = false
+			o.AllowEmptyItem = true
+			o.MaxDepth = 2
+			o.CrawlOrder = tt.order
+			o.URLScorer = tt.scorer
+			wc := NewWithOptions(o)
+			var got []string
+			wc.OnEvent = func(event *Event) {
+				if event.Type == EventProgress {
+					got = append(got, strings.TrimPrefix(event.Progress.URL, site.URL))
+				}
+			}
+			if _, err := wc.CrawlContext(context.Background(), site.URL+"/", nil); err != nil {
+				t.Fatalf("WebCrawler.CrawlContext() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("WebCrawler.CrawlContext() crawl order = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,55 +1,196 @@
 package options
 
+import (
+	"time"
+
+	webscraper "github.com/cody6750/web-crawler/pkg/webScraper"
+)
+
+const (
+	// CrawlOrderBreadthFirst crawls the urls of lower depth first, in the order they were found.
+	CrawlOrderBreadthFirst = "breadth-first"
+
+	// CrawlOrderDepthFirst crawls the urls of higher depth first, the most recently found url first.
+	CrawlOrderDepthFirst = "depth-first"
+
+	// CrawlOrderBestFirst crawls the urls with the highest score of the URLScorer first.
+	CrawlOrderBestFirst = "best-first"
+
+	// VisitedSetExact keeps every visited url in memory, it never skips a url that has not been visited.
+	VisitedSetExact = "exact"
+
+	// VisitedSetBloom keeps the visited urls in a Bloom filter of fixed size, which may skip a small fraction of the
+	// urls that have not been visited.
+	VisitedSetBloom = "bloom"
+
+	// VisitedSetDisk keeps a fingerprint of every visited url in a file on disk.
+	VisitedSetDisk = "disk"
+)
+
+// URLScorer scores a url for best first crawls, urls with a higher score are crawled first. It is called once for
+// every url that is queued to be crawled and must not block.
+type URLScorer func(u *webscraper.URL) float64
+
 var (
-	defaultAllowEmptyItem        bool   = false
-	defaultAWSWriteOutputToS3    bool   = false
-	defaultAWSMaxRetries         int    = 5
-	defaultCrawlDelay            int    = 5
-	defaultMaxDepth              int    = 1
-	defaultMaxGoRoutines         int    = 10000
-	defaultMaxVisitedUrls        int    = 20
-	defeaultMaxItemsFound        int    = 5000
-	defaultWebScraperWorkercount int    = 5
-	defaultAWSRegion             string = "us-east-1"
-	defaultAWSS3Bucket           string = "webcrawler-results"
-	defaultHeaderKey             string = "User-Agent"
-	defaultHeaderValue           string = "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/51.0.2704.103 Safari/537.36"
+	defaultAdaptiveThrottling           bool          = true
+	defaultAllowEmptyItem               bool          = false
+	defaultAWSWriteOutputToS3           bool          = false
+	defaultExtractStructuredData        bool          = false
+	defaultSameHost                     bool          = false
+	defaultSameDomain                   bool          = false
+	defaultSitemaps                     bool          = false
+	defaultOnlyNewFeedEntries           bool          = false
+	defaultAWSMaxRetries                int           = 5
+	defaultCheckpointInterval           time.Duration = 30 * time.Second
+	defaultCrawlDelay                   int           = 5
+	defaultCrawlDelayDuration           time.Duration = 0
+	defaultMaxCrawlDelay                time.Duration = time.Minute
+	defaultMaxDuration                  time.Duration = 0
+	defaultMaxBytesDownloaded           int64         = 0
+	defaultMaxBytesDownloadedPerHost    int64         = 0
+	defaultMaxFailedUrls                int           = 0
+	defaultMaxFailureRate               float64       = 0
+	defaultMaxDepth                     int           = 1
+	defaultMaxGoRoutines                int           = 10000
+	defaultMaxVisitedUrls               int           = 20
+	defaultMaxVisitedUrlsPerHost        int           = 0
+	defaultMaxConcurrentRequestsPerHost int           = 1
+	defaultMaxThrottledRetries          int           = 3
+	defaultVisitedSetCapacity           int           = 1000000
+	defaultVisitedSetFalsePositiveRate  float64       = 0.001
+	defeaultMaxItemsFound               int           = 5000
+	defaultWebScraperWorkercount        int           = 5
+	defaultAWSRegion                    string        = "us-east-1"
+	defaultCheckpointDir                string        = ""
+	defaultCrawlOrder                   string        = CrawlOrderBreadthFirst
+	defaultVisitedSet                   string        = VisitedSetExact
+	defaultVisitedSetDir                string        = ""
+	defaultAWSS3Bucket                  string        = "webcrawler-results"
+	defaultHeaderKey                    string        = "User-Agent"
+	defaultHeaderValue                  string        = "Mozilla/5.0 (X11; Linux x86_64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/51.0.2704.103 Safari/537.36"
 )
 
 // Options ...
 type Options struct {
-	AllowEmptyItem        bool
-	AWSWriteOutputToS3    bool
-	AWSMaxRetries         int
-	CrawlDelay            int
-	MaxDepth              int
-	MaxGoRoutines         int
-	MaxVisitedUrls        int
-	MaxItemsFound         int
-	WebScraperWorkerCount int
-	BlacklistedURLPaths   map[string]struct{}
-	AWSRegion             string
-	AWSS3Bucket           string
-	HeaderKey             string
-	HeaderValue           string
+	AdaptiveThrottling           bool
+	AllowEmptyItem               bool
+	AWSWriteOutputToS3           bool
+	ExtractStructuredData        bool
+	SameHost                     bool
+	SameDomain                   bool
+	Sitemaps                     bool
+	SitemapModifiedSince         time.Time
+	OnlyNewFeedEntries           bool
+	AWSMaxRetries                int
+	CheckpointInterval           time.Duration
+	CrawlDelay                   int
+	CrawlDelayDuration           time.Duration
+	MaxCrawlDelay                time.Duration
+	MaxDuration                  time.Duration
+	MaxBytesDownloaded           int64
+	MaxBytesDownloadedPerHost    int64
+	MaxDepth                     int
+	MaxFailedUrls                int
+	MaxFailureRate               float64
+	MaxGoRoutines                int
+	MaxVisitedUrls               int
+	MaxVisitedUrlsPerHost        int
+	MaxConcurrentRequestsPerHost int
+	MaxThrottledRetries          int
+	VisitedSetCapacity           int
+	VisitedSetFalsePositiveRate  float64
+	MaxItemsFound                int
+	WebScraperWorkerCount        int
+	BlacklistedURLPaths          map[string]struct{}
+	IgnoredQueryParams           []string
+	AllowedHosts                 []string
+	DeniedHosts                  []string
+	IncludeURLPatterns           []string
+	ExcludeURLPatterns           []string
+	FeedURLs                     []string
+	RetryPolicy                  *webscraper.RetryPolicy
+	Transport                    webscraper.TransportConfig
+	AWSRegion                    string
+	CheckpointDir                string
+	CrawlOrder                   string
+	URLScorer                    URLScorer
+	VisitedSet                   string
+	VisitedSetDir                string
+	AWSS3Bucket                  string
+	HeaderKey                    string
+	HeaderValue                  string
 }
 
-//New ...
+// New ...
 func New() *Options {
 	return &Options{
-		AllowEmptyItem:        defaultAllowEmptyItem,
-		AWSWriteOutputToS3:    defaultAWSWriteOutputToS3,
-		AWSMaxRetries:         defaultAWSMaxRetries,
-		CrawlDelay:            defaultCrawlDelay,
-		MaxDepth:              defaultMaxDepth,
-		MaxGoRoutines:         defaultMaxGoRoutines,
-		MaxVisitedUrls:        defaultMaxVisitedUrls,
-		MaxItemsFound:         defeaultMaxItemsFound,
-		WebScraperWorkerCount: defaultWebScraperWorkercount,
-		BlacklistedURLPaths:   map[string]struct{}{},
-		HeaderKey:             defaultHeaderKey,
-		AWSRegion:             defaultAWSRegion,
-		AWSS3Bucket:           defaultAWSS3Bucket,
-		HeaderValue:           defaultHeaderValue,
+		AdaptiveThrottling:           defaultAdaptiveThrottling,
+		AllowEmptyItem:               defaultAllowEmptyItem,
+		AWSWriteOutputToS3:           defaultAWSWriteOutputToS3,
+		ExtractStructuredData:        defaultExtractStructuredData,
+		SameHost:                     defaultSameHost,
+		SameDomain:                   defaultSameDomain,
+		Sitemaps:                     defaultSitemaps,
+		OnlyNewFeedEntries:           defaultOnlyNewFeedEntries,
+		AWSMaxRetries:                defaultAWSMaxRetries,
+		CheckpointInterval:           defaultCheckpointInterval,
+		CrawlDelay:                   defaultCrawlDelay,
+		CrawlDelayDuration:           defaultCrawlDelayDuration,
+		MaxCrawlDelay:                defaultMaxCrawlDelay,
+		MaxDuration:                  defaultMaxDuration,
+		MaxBytesDownloaded:           defaultMaxBytesDownloaded,
+		MaxBytesDownloadedPerHost:    defaultMaxBytesDownloadedPerHost,
+		MaxDepth:                     defaultMaxDepth,
+		MaxFailedUrls:                defaultMaxFailedUrls,
+		MaxFailureRate:               defaultMaxFailureRate,
+		MaxGoRoutines:                defaultMaxGoRoutines,
+		MaxVisitedUrls:               defaultMaxVisitedUrls,
+		MaxVisitedUrlsPerHost:        defaultMaxVisitedUrlsPerHost,
+		MaxConcurrentRequestsPerHost: defaultMaxConcurrentRequestsPerHost,
+		MaxThrottledRetries:          defaultMaxThrottledRetries,
+		VisitedSetCapacity:           defaultVisitedSetCapacity,
+		VisitedSetFalsePositiveRate:  defaultVisitedSetFalsePositiveRate,
+		MaxItemsFound:                defeaultMaxItemsFound,
+		WebScraperWorkerCount:        defaultWebScraperWorkercount,
+		BlacklistedURLPaths:          map[string]struct{}{},
+		IgnoredQueryParams:           append([]string(nil), webscraper.DefaultIgnoredQueryParams...),
+		RetryPolicy:                  webscraper.DefaultRetryPolicy(),
+		Transport:                    webscraper.DefaultTransportConfig(),
+		HeaderKey:                    defaultHeaderKey,
+		AWSRegion:                    defaultAWSRegion,
+		AWSS3Bucket:                  defaultAWSS3Bucket,
+		CheckpointDir:                defaultCheckpointDir,
+		CrawlOrder:                   defaultCrawlOrder,
+		VisitedSet:                   defaultVisitedSet,
+		VisitedSetDir:                defaultVisitedSetDir,
+		HeaderValue:                  defaultHeaderValue,
+	}
+}
+
+// CrawlDelayTime returns the minimum delay between requests to the same host. CrawlDelayDuration takes precedence
+// when set, it allows delays below a second, otherwise CrawlDelay is the delay in seconds.
+func (o *Options) CrawlDelayTime() time.Duration {
+	if o.CrawlDelayDuration > 0 {
+		return o.CrawlDelayDuration
+	}
+	return time.Duration(o.CrawlDelay) * time.Second
+}
+
+// Clone returns a deep copy of the options. Used to hand every crawl its own copy of a shared options template, since
+// the web crawler mutates its options while crawling.
+func (o *Options) Clone() *Options {
+	clone := *o
+	clone.BlacklistedURLPaths = make(map[string]struct{}, len(o.BlacklistedURLPaths))
+	for path := range o.BlacklistedURLPaths {
+		clone.BlacklistedURLPaths[path] = struct{}{}
 	}
+	clone.IgnoredQueryParams = append([]string(nil), o.IgnoredQueryParams...)
+	clone.AllowedHosts = append([]string(nil), o.AllowedHosts...)
+	clone.DeniedHosts = append([]string(nil), o.DeniedHosts...)
+	clone.IncludeURLPatterns = append([]string(nil), o.IncludeURLPatterns...)
+	clone.ExcludeURLPatterns = append([]string(nil), o.ExcludeURLPatterns...)
+	clone.FeedURLs = append([]string(nil), o.FeedURLs...)
+	clone.RetryPolicy = o.RetryPolicy.Clone()
+	clone.Transport = o.Transport.Clone()
+	return &clone
 }
@@ -0,0 +1,204 @@
+package robots
+
+import (
+	"bufio"
+	"bytes"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// MaxSize is the maximum number of bytes of a robots.txt file that are parsed, as required by RFC 9309.
+	MaxSize = 500 * 1024
+
+	// wildcardAgent is the user agent of the group that applies to every crawler.
+	wildcardAgent = "*"
+)
+
+// Robots represents a parsed robots.txt file.
+type Robots struct {
+	// Groups are the groups of rules in the order they appear in the robots.txt file.
+	Groups []*Group
+
+	// Sitemaps are the urls of every Sitemap: line, they do not belong to a group.
+	Sitemaps []string
+}
+
+// Group represents the rules that apply to the user agents of a robots.txt group.
+type Group struct {
+	Agents     []string
+	Rules      []Rule
+	CrawlDelay time.Duration
+}
+
+// Rule represents an Allow: or Disallow: line of a robots.txt group. The pattern may contain * wildcards and may
+// end with a $ anchor.
+type Rule struct {
+	Allow   bool
+	Pattern string
+}
+
+// AllowAll returns robots.txt restrictions that allow every path, used when a website has no robots.txt.
+func AllowAll() *Robots {
+	return &Robots{}
+}
+
+// DisallowAll returns robots.txt restrictions that disallow every path, used when the robots.txt of a website is
+// unreachable.
+func DisallowAll() *Robots {
+	return &Robots{Groups: []*Group{{Agents: []string{wildcardAgent}, Rules: []Rule{{Allow: false, Pattern: "/"}}}}}
+}
+
+// Parse parses the content of a robots.txt file. Unknown lines and lines that do not belong to a group are ignored,
+// the parser never fails.
+func Parse(body []byte) *Robots {
+	var (
+		robots        = &Robots{}
+		group         *Group
+		groupHasRules bool
+	)
+	if len(body) > MaxSize {
+		body = body[:MaxSize]
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(body))
+	scanner.Buffer(make([]byte, 0, 64*1024), MaxSize)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if i := strings.Index(line, "#"); i >= 0 {
+			line = line[:i]
+		}
+		i := strings.Index(line, ":")
+		if i < 0 {
+			continue
+		}
+		key := strings.ToLower(strings.TrimSpace(line[:i]))
+		value := strings.TrimSpace(line[i+1:])
+
+		switch key {
+		case "user-agent":
+			// Consecutive user-agent lines share a group, a user-agent line after any rule starts a new group.
+			if group == nil || groupHasRules {
+				group = &Group{}
+				groupHasRules = false
+				robots.Groups = append(robots.Groups, group)
+			}
+			group.Agents = append(group.Agents, strings.ToLower(value))
+		case "allow", "disallow":
+			if group == nil {
+				continue
+			}
+			groupHasRules = true
+			// An empty rule matches nothing.
+			if value == "" {
+				continue
+			}
+			group.Rules = append(group.Rules, Rule{Allow: key == "allow", Pattern: value})
+		case "crawl-delay":
+			if group == nil {
+				continue
+			}
+			groupHasRules = true
+			seconds, err := strconv.ParseFloat(value, 64)
+			if err != nil || seconds < 0 {
+				continue
+			}
+			group.CrawlDelay = time.Duration(seconds * float64(time.Second))
+		case "sitemap":
+			if value != "" {
+				robots.Sitemaps = append(robots.Sitemaps, value)
+			}
+		}
+	}
+	return robots
+}
+
+// Group returns the rules that apply to the given user agent. Groups naming the product token of the user agent
+// take precedence over the * group. Matching groups are combined into one. If no group applies, the returned group
+// allows every path.
+func (r *Robots) Group(userAgent string) *Group {
+	token := ProductToken(userAgent)
+	matched := &Group{}
+	for _, agent := range []string{token, wildcardAgent} {
+		for _, group := range r.Groups {
+			for _, groupAgent := range group.Agents {
+				if groupAgent == agent {
+					matched.Agents = append(matched.Agents, groupAgent)
+					matched.Rules = append(matched.Rules, group.Rules...)
+					if group.CrawlDelay > matched.CrawlDelay {
+						matched.CrawlDelay = group.CrawlDelay
+					}
+					break
+				}
+			}
+		}
+		if len(matched.Agents) != 0 {
+			break
+		}
+	}
+	return matched
+}
+
+// Allowed returns whether the given user agent may crawl the path. The path includes the query string.
+func (r *Robots) Allowed(userAgent, path string) bool {
+	return r.Group(userAgent).Allowed(path)
+}
+
+// Allowed returns whether the path may be crawled. The rule with the longest matching pattern decides, if an allow
+// and a disallow rule are equally long the allow rule wins. /robots.txt is always allowed.
+func (g *Group) Allowed(path string) bool {
+	if path == "" {
+		path = "/"
+	}
+	if path == "/robots.txt" {
+		return true
+	}
+
+	allowed, longest := true, -1
+	for _, rule := range g.Rules {
+		if !Match(rule.Pattern, path) {
+			continue
+		}
+		if len(rule.Pattern) > longest || (len(rule.Pattern) == longest && rule.Allow) {
+			allowed, longest = rule.Allow, len(rule.Pattern)
+		}
+	}
+	return allowed
+}
+
+// Match returns whether the robots.txt pattern matches the path. * matches any sequence of characters and a trailing
+// $ anchors the pattern to the end of the path, otherwise the pattern matches as a prefix.
+func Match(pattern, path string) bool {
+	anchored := strings.HasSuffix(pattern, "$")
+	if anchored {
+		pattern = strings.TrimSuffix(pattern, "$")
+	}
+
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(path, parts[0]) {
+		return false
+	}
+	position := len(parts[0])
+	for i := 1; i < len(parts); i++ {
+		if anchored && i == len(parts)-1 {
+			return len(path)-len(parts[i]) >= position && strings.HasSuffix(path, parts[i])
+		}
+		index := strings.Index(path[position:], parts[i])
+		if index < 0 {
+			return false
+		}
+		position += index + len(parts[i])
+	}
+	return !anchored || position == len(path)
+}
+
+// ProductToken returns the lower cased product token of a user agent, for example "mybot" for
+// "MyBot/1.0 (+https://example.com/bot)".
+func ProductToken(userAgent string) string {
+	token := strings.TrimSpace(userAgent)
+	if i := strings.IndexAny(token, "/ "); i >= 0 {
+		token = token[:i]
+	}
+	return strings.ToLower(token)
+}
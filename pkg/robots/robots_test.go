@@ -0,0 +1,133 @@
+package robots
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+const robotsTxt = `# Example robots.txt
+User-agent: *
+Disallow: /cart
+Disallow: /*.pdf$
+Allow: /cart/public
+Crawl-delay: 2
+
+User-agent: MyBot
+User-agent: OtherBot
+Disallow: /private   # only for our bots
+Allow: /private/open
+Crawl-delay: 0.5
+
+User-agent: mybot
+Disallow: /secret
+
+Sitemap: https://www.example.com/sitemap.xml
+`
+
+func TestRobots_Allowed(t *testing.T) {
+	robots := Parse([]byte(robotsTxt))
+	type args struct {
+		userAgent string
+		path      string
+	}
+	tests := []struct {
+		name string
+		args args
+		want bool
+	}{
+		{
+			name: "Wildcard group disallows prefix",
+			args: args{userAgent: "Mozilla/5.0 (X11; Linux x86_64)", path: "/cart/view.html?ref=nav"},
+			want: false,
+		},
+		{
+			name: "Longest match allows",
+			args: args{userAgent: "Mozilla/5.0 (X11; Linux x86_64)", path: "/cart/public/item"},
+			want: true,
+		},
+		{
+			name: "Anchored wildcard disallows",
+			args: args{userAgent: "Mozilla/5.0", path: "/docs/manual.pdf"},
+			want: false,
+		},
+		{
+			name: "Anchored wildcard does not match longer path",
+			args: args{userAgent: "Mozilla/5.0", path: "/docs/manual.pdf?download=1"},
+			want: true,
+		},
+		{
+			name: "Agent group replaces wildcard group",
+			args: args{userAgent: "MyBot/1.0 (+https://example.com/bot)", path: "/cart"},
+			want: true,
+		},
+		{
+			name: "Agent group disallows",
+			args: args{userAgent: "MyBot/1.0", path: "/private/file"},
+			want: false,
+		},
+		{
+			name: "Agent group allows",
+			args: args{userAgent: "mybot", path: "/private/open/file"},
+			want: true,
+		},
+		{
+			name: "Groups for the same agent are combined",
+			args: args{userAgent: "MyBot/1.0", path: "/secret/file"},
+			want: false,
+		},
+		{
+			name: "Robots.txt is always allowed",
+			args: args{userAgent: "Mozilla/5.0", path: "/robots.txt"},
+			want: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := robots.Allowed(tt.args.userAgent, tt.args.path); got != tt.want {
+				t.Errorf("Robots.Allowed() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	robots := Parse([]byte(robotsTxt))
+	if want := []string{"https://www.example.com/sitemap.xml"}; !reflect.DeepEqual(robots.Sitemaps, want) {
+		t.Errorf("Parse() sitemaps = %v, want %v", robots.Sitemaps, want)
+	}
+	if got := robots.Group("Mozilla/5.0").CrawlDelay; got != 2*time.Second {
+		t.Errorf("Parse() wildcard crawl delay = %v, want %v", got, 2*time.Second)
+	}
+	if got := robots.Group("OtherBot/2.0").CrawlDelay; got != 500*time.Millisecond {
+		t.Errorf("Parse() agent crawl delay = %v, want %v", got, 500*time.Millisecond)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	type args struct {
+		pattern string
+		path    string
+	}
+	tests := []struct {
+		name string
+		args args
+		want bool
+	}{
+		{name: "Prefix", args: args{pattern: "/fish", path: "/fish.html"}, want: true},
+		{name: "Case sensitive", args: args{pattern: "/fish", path: "/Fish.asp"}, want: false},
+		{name: "Wildcard", args: args{pattern: "/*.php", path: "/folder/filename.php?parameters"}, want: true},
+		{name: "Anchor", args: args{pattern: "/*.php$", path: "/filename.php"}, want: true},
+		{name: "Anchor mismatch", args: args{pattern: "/*.php$", path: "/filename.php5"}, want: false},
+		{name: "Exact anchor", args: args{pattern: "/$", path: "/"}, want: true},
+		{name: "Exact anchor mismatch", args: args{pattern: "/$", path: "/page"}, want: false},
+		{name: "Multiple wildcards", args: args{pattern: "/fish*.php*id", path: "/fish/salmon.php?id=3"}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Match(tt.args.pattern, tt.args.path); got != tt.want {
+				t.Errorf("Match() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+package webcrawler
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/cody6750/web-crawler/pkg/robots"
+	webscraper "github.com/cody6750/web-crawler/pkg/webScraper"
+)
+
+// robotsCache fetches the robots.txt of every host once per crawl and caches the parsed restrictions.
+type robotsCache struct {
+	entries map[string]*robotsEntry
+	lock    sync.Mutex
+}
+
+// robotsEntry holds the robots.txt restrictions of a single host. ready is closed once they have been fetched, so
+// that concurrent lookups for the same host wait for a single fetch.
+type robotsEntry struct {
+	ready  chan struct{}
+	robots *robots.Robots
+}
+
+// newRobotsCache creates an empty robots.txt cache.
+func newRobotsCache() *robotsCache {
+	return &robotsCache{entries: make(map[string]*robotsEntry)}
+}
+
+// getRobots returns the robots.txt restrictions of the host of the url, fetching them on first use.
+func (wc *WebCrawler) getRobots(u *url.URL) *robots.Robots {
+	key := u.Scheme + "://" + u.Host
+	wc.robots.lock.Lock()
+	entry, exist := wc.robots.entries[key]
+	if !exist {
+		entry = &robotsEntry{ready: make(chan struct{})}
+		wc.robots.entries[key] = entry
+	}
+	wc.robots.lock.Unlock()
+
+	if exist {
+		select {
+		case <-entry.ready:
+			return entry.robots
+		case <-wc.ctx.Done():
+			return robots.DisallowAll()
+		}
+	}
+	entry.robots = wc.fetchRobots(key + "/robots.txt")
+	close(entry.ready)
+	return entry.robots
+}
+
+// fetchRobots fetches and parses robots.txt following RFC 9309. A missing robots.txt allows every path, while an
+// unreachable robots.txt, either due to a server or network error, disallows every path.
+func (wc *WebCrawler) fetchRobots(robotsURL string) *robots.Robots {
+	wc.Logger.WithField("url", robotsURL).Debugf("Initializing robots.txt restrictions")
+	resp, err := webscraper.FetchContext(wc.ctx, wc.fetcher(), robotsURL, wc.Options.HeaderKey, wc.Options.HeaderValue)
+	if err != nil {
+		wc.Logger.WithError(err).WithField("url", robotsURL).Warn("robots.txt is unreachable, disallowing website")
+		return robots.DisallowAll()
+	}
+	defer resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= http.StatusInternalServerError:
+		wc.Logger.WithField("url", robotsURL).Warn("robots.txt is unreachable, disallowing website")
+		return robots.DisallowAll()
+	case resp.StatusCode >= http.StatusBadRequest:
+		wc.Logger.WithField("url", robotsURL).Info("robots.txt does not exist for website")
+		return robots.AllowAll()
+	}
+
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, robots.MaxSize))
+	if err != nil && len(body) == 0 {
+		wc.Logger.WithError(err).WithField("url", robotsURL).Warn("Unable to read robots.txt, disallowing website")
+		return robots.DisallowAll()
+	}
+	wc.Logger.WithField("url", robotsURL).Debugf("Successfully parsed url for robots.txt restrictions")
+	return robots.Parse(body)
+}
+
+// isAllowedByRobots checks the url against the robots.txt restrictions of its host for the user agent of the web
+// crawler.
+func (wc *WebCrawler) isAllowedByRobots(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		// Urls that cannot be fetched are left for the web scraper to report.
+		return true
+	}
+	path := u.EscapedPath()
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	return wc.getRobots(u).Allowed(wc.userAgent(), path)
+}
+
+// userAgent returns the user agent the web crawler sends, used to select the robots.txt group.
+func (wc *WebCrawler) userAgent() string {
+	if strings.EqualFold(wc.Options.HeaderKey, "User-Agent") {
+		return wc.Options.HeaderValue
+	}
+	return "Go-http-client"
+}
@@ -0,0 +1,323 @@
+package webcrawler
+
+import (
+	"net/url"
+	"sync"
+	"time"
+
+	webscraper "github.com/cody6750/web-crawler/pkg/webScraper"
+)
+
+const (
+	// throttledDelay is the minimum delay applied to a host that responded with 429 or 503 without a Retry-After
+	// header.
+	throttledDelay = time.Second
+
+	// latencyThreshold is the factor by which the latency of a response must exceed the average latency of its host
+	// for the host to be slowed down.
+	latencyThreshold = 2
+)
+
+// hostScheduler sits between processCrawledUrls and the urlsToCrawl channel. Urls are queued per host and handed to
+// the web scraper workers once their host is allowed another request, which enforces a minimum delay and a maximum
+// number of concurrent requests per host while different hosts are crawled concurrently. With
+// Options.AdaptiveThrottling the delay of every host adapts to its responses. Among the hosts that are ready, the url
+// that comes first in Options.CrawlOrder is crawled first.
+type hostScheduler struct {
+	wc *WebCrawler
+
+	// hosts holds the state of every host that has been crawled, order holds the hosts with pending or in flight
+	// urls and is used to visit them round robin.
+	hosts map[string]*hostQueue
+	order []*hostQueue
+	next  int
+
+	// frontier orders the urls of every host.
+	frontier *frontierOrder
+
+	// throttled counts how many times every url has been throttled.
+	throttled map[string]int
+
+	// wake is signalled whenever a url is submitted or released, so that the scheduler re-evaluates which host is ready.
+	wake chan struct{}
+	lock sync.Mutex
+}
+
+// hostQueue holds the politeness state of a single host.
+type hostQueue struct {
+	host string
+
+	// urls are the urls of the host waiting to be crawled, in crawl order.
+	urls *frontierQueue
+
+	// queued reports whether the host is part of the round robin order.
+	queued bool
+
+	// active is the number of in flight requests to the host.
+	active int
+
+	// minDelay is the minimum delay between requests to the host, the larger of Options.CrawlDelayTime and the robots.txt
+	// Crawl-delay of the host. delay is the current delay, it only differs from minDelay while the host is slowed down.
+	minDelay time.Duration
+	delay    time.Duration
+
+	// latency is the moving average of the response latency of the host.
+	latency time.Duration
+
+	// throttled is the number of 429 and 503 responses of the host.
+	throttled int
+
+	// ready is the earliest time the next request to the host may start.
+	ready time.Time
+}
+
+// newHostScheduler creates an empty scheduler for the web crawler.
+func newHostScheduler(wc *WebCrawler) *hostScheduler {
+	return &hostScheduler{
+		wc:        wc,
+		hosts:     make(map[string]*hostQueue),
+		frontier:  newFrontierOrder(wc.Options),
+		throttled: make(map[string]int),
+		wake:      make(chan struct{}, 1),
+	}
+}
+
+// submit queues the url in the crawl order of its host.
+func (s *hostScheduler) submit(u *webscraper.URL) {
+	s.enqueue(u, false)
+}
+
+// enqueue queues the url in crawl order or, if it is crawled again, at the front of its host queue.
+func (s *hostScheduler) enqueue(u *webscraper.URL, front bool) {
+	host := hostOf(u.CurrentURL)
+	s.lock.Lock()
+	q, exist := s.hosts[host]
+	s.lock.Unlock()
+	var delay time.Duration
+	if !exist {
+		// The delay is resolved outside of the lock, it may need to fetch the robots.txt of the host.
+		delay = s.wc.hostDelay(u.CurrentURL)
+	}
+
+	s.lock.Lock()
+	if q, exist = s.hosts[host]; !exist {
+		q = &hostQueue{host: host, urls: &frontierQueue{order: s.frontier}, minDelay: delay, delay: delay}
+		s.hosts[host] = q
+		s.wc.setHostRate(q)
+	}
+	if !q.queued {
+		q.queued = true
+		s.order = append(s.order, q)
+	}
+	q.urls.push(s.frontier.item(u, front))
+	s.lock.Unlock()
+	s.signal()
+}
+
+// release marks the request to the host of the url as finished. The next request to the host may start once the delay
+// of the host has passed since the request finished. A latency of 0 means no response was received. With
+// Options.AdaptiveThrottling a host is slowed down when the latency rises above its average latency and sped back up
+// towards its minimum delay otherwise.
+func (s *hostScheduler) release(u *webscraper.URL, latency time.Duration) {
+	s.lock.Lock()
+	if q, exist := s.hosts[hostOf(u.CurrentURL)]; exist {
+		q.active--
+		if s.wc.Options.AdaptiveThrottling && latency > 0 {
+			switch {
+			case q.latency == 0:
+				q.latency = latency
+			case latency > latencyThreshold*q.latency:
+				q.delay = s.limitDelay(q, maxDuration(2*q.delay, latency))
+			default:
+				q.delay -= (q.delay - q.minDelay) / 4
+			}
+			q.latency = (7*q.latency + latency) / 8
+			s.wc.setHostRate(q)
+		}
+		if ready := time.Now().Add(q.delay); ready.After(q.ready) {
+			q.ready = ready
+		}
+	}
+	s.lock.Unlock()
+	s.signal()
+}
+
+// throttle releases the request to the host of the url after the host responded with 429 or 503. The host is slowed
+// down and waits at least retryAfter before its next request. Unless the url has been throttled
+// Options.MaxThrottledRetries times already, it is queued to be crawled again and throttle returns true.
+func (s *hostScheduler) throttle(u *webscraper.URL, retryAfter time.Duration) bool {
+	s.lock.Lock()
+	q, exist := s.hosts[hostOf(u.CurrentURL)]
+	if !exist {
+		s.lock.Unlock()
+		return false
+	}
+	q.active--
+	q.throttled++
+	if s.wc.Options.AdaptiveThrottling {
+		q.delay = s.limitDelay(q, maxDuration(2*q.delay, throttledDelay, retryAfter))
+	}
+	if ready := time.Now().Add(maxDuration(q.delay, retryAfter)); ready.After(q.ready) {
+		q.ready = ready
+	}
+	s.wc.setHostRate(q)
+
+	s.throttled[u.CurrentURL]++
+	retry := s.throttled[u.CurrentURL] <= s.wc.Options.MaxThrottledRetries
+	s.lock.Unlock()
+
+	if retry {
+		s.enqueue(u, true)
+	} else {
+		s.signal()
+	}
+	return retry
+}
+
+// limitDelay caps the delay of a slowed down host at Options.MaxCrawlDelay, unless the minimum delay of the host is
+// even larger.
+func (s *hostScheduler) limitDelay(q *hostQueue, delay time.Duration) time.Duration {
+	if max := maxDuration(s.wc.Options.MaxCrawlDelay, q.minDelay); delay > max {
+		return max
+	}
+	return delay
+}
+
+// signal wakes up the scheduler without blocking.
+func (s *hostScheduler) signal() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run sends the urls of every host that is ready to the urlsToCrawl channel until the crawl is stopped. When no host is
+// ready, it sleeps until the earliest host becomes ready or a url is submitted or released.
+func (s *hostScheduler) run() {
+	timer := time.NewTimer(time.Hour)
+	defer timer.Stop()
+	for {
+		u, wait := s.dequeue(time.Now())
+		if u != nil {
+			select {
+			case s.wc.urlsToCrawl <- u:
+			case <-s.wc.ctx.Done():
+				return
+			}
+			continue
+		}
+
+		if !timer.Stop() {
+			select {
+			case <-timer.C:
+			default:
+			}
+		}
+		timer.Reset(wait)
+		select {
+		case <-s.wake:
+		case <-timer.C:
+		case <-s.wc.ctx.Done():
+			return
+		}
+	}
+}
+
+// dequeue returns the first url in crawl order among the hosts that are ready, ties go to the first ready host after
+// the host that was served last. If no host is ready, it returns how long to wait until the earliest host becomes
+// ready. Idle hosts are removed from the round robin order.
+func (s *hostScheduler) dequeue(now time.Time) (*webscraper.URL, time.Duration) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	maxActive := s.wc.Options.MaxConcurrentRequestsPerHost
+	wait := time.Hour
+	order := make([]*hostQueue, 0, len(s.order))
+	var (
+		best      *hostQueue
+		bestIndex int
+	)
+	for i := range s.order {
+		q := s.order[(s.next+i)%len(s.order)]
+		if q.urls.Len() == 0 && q.active == 0 && !now.Before(q.ready) {
+			q.queued = false
+			continue
+		}
+		order = append(order, q)
+		if q.urls.Len() == 0 || (maxActive > 0 && q.active >= maxActive) {
+			continue
+		}
+		if now.Before(q.ready) {
+			if d := q.ready.Sub(now); d < wait {
+				wait = d
+			}
+			continue
+		}
+		if best == nil || s.frontier.less(q.urls.peek(), best.urls.peek()) {
+			best, bestIndex = q, len(order)-1
+		}
+	}
+
+	// order starts with the host at s.next, so without a served host the rotation starts over at 0.
+	s.order = order
+	s.next = 0
+	if best == nil {
+		return nil, wait
+	}
+	found := best.urls.pop().url
+	best.active++
+	best.ready = now.Add(best.delay)
+	// The host after this one is served first next time.
+	if bestIndex+1 < len(order) {
+		s.next = bestIndex + 1
+	}
+	return found, wait
+}
+
+// hostDelay returns the minimum delay between requests to the host of the url, the larger of Options.CrawlDelayTime and
+// the robots.txt Crawl-delay of the host for the user agent of the web crawler.
+func (wc *WebCrawler) hostDelay(rawURL string) time.Duration {
+	delay := wc.Options.CrawlDelayTime()
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return delay
+	}
+	if robotsDelay := wc.getRobots(u).Group(wc.userAgent()).CrawlDelay; robotsDelay > delay {
+		delay = robotsDelay
+	}
+	return delay
+}
+
+// setHostRate publishes the current rate of the host in the metrics.
+func (wc *WebCrawler) setHostRate(q *hostQueue) {
+	rate := HostRate{Delay: q.delay, Latency: q.latency, Throttled: q.throttled}
+	if q.delay > 0 {
+		rate.RequestsPerSecond = float64(time.Second) / float64(q.delay)
+	}
+	wc.metricsLock.Lock()
+	if wc.metrics.HostRates == nil {
+		wc.metrics.HostRates = make(map[string]HostRate)
+	}
+	wc.metrics.HostRates[q.host] = rate
+	wc.metricsLock.Unlock()
+}
+
+// hostOf returns the scheme and host of the url, used as the key of its host queue.
+func hostOf(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return ""
+	}
+	return u.Scheme + "://" + u.Host
+}
+
+// maxDuration returns the largest of the durations.
+func maxDuration(durations ...time.Duration) time.Duration {
+	var max time.Duration
+	for _, d := range durations {
+		if d > max {
+			max = d
+		}
+	}
+	return max
+}
@@ -0,0 +1,174 @@
+package webcrawler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	options "github.com/cody6750/web-crawler/pkg/options"
+	webscraper "github.com/cody6750/web-crawler/pkg/webScraper"
+)
+
+func TestHostScheduler(t *testing.T) {
+	newServer := func(robotsTxt string) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/robots.txt" && robotsTxt != "" {
+				rw.Write([]byte(robotsTxt))
+				return
+			}
+			http.NotFound(rw, r)
+		}))
+	}
+	first, second := newServer(""), newServer("User-agent: *\nCrawl-delay: 0.3\n")
+	defer first.Close()
+	defer second.Close()
+
+	tests := []struct {
+		name         string
+		crawlDelay   time.Duration
+		maxPerHost   int
+		urls         []string
+		releaseFirst bool
+		// minGap and maxGap bound the time between the first and the second url reaching the web scraper workers.
+		minGap time.Duration
+		maxGap time.Duration
+	}{
+		{
+			name:         "Delay between requests to the same host",
+			crawlDelay:   200 * time.Millisecond,
+			maxPerHost:   1,
+			urls:         []string{first.URL + "/a", first.URL + "/b"},
+			releaseFirst: true,
+			minGap:       200 * time.Millisecond,
+			maxGap:       time.Second,
+		},
+		{
+			name:       "Different hosts are crawled concurrently",
+			crawlDelay: time.Second,
+			maxPerHost: 1,
+			urls:       []string{first.URL + "/a", second.URL + "/a"},
+			maxGap:     100 * time.Millisecond,
+		},
+		{
+			name:         "Robots.txt crawl delay takes precedence",
+			crawlDelay:   0,
+			maxPerHost:   1,
+			urls:         []string{second.URL + "/a", second.URL + "/b"},
+			releaseFirst: true,
+			minGap:       300 * time.Millisecond,
+			maxGap:       time.Second,
+		},
+		{
+			name:       "Concurrent requests to the same host",
+			crawlDelay: 0,
+			maxPerHost: 2,
+			urls:       []string{first.URL + "/a", first.URL + "/b"},
+			maxGap:     100 * time.Millisecond,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := options.New()
+			o.CrawlDelay = 0
+			o.CrawlDelayDuration = tt.crawlDelay
+			o.MaxConcurrentRequestsPerHost = tt.maxPerHost
+			wc := NewWithOptions(o)
+			wc.init(context.Background())
+			defer wc.cancel()
+			go wc.scheduler.run()
+
+			for _, u := range tt.urls {
+				wc.scheduler.submit(&webscraper.URL{CurrentURL: u})
+			}
+			receive := func() *webscraper.URL {
+				select {
+				case u := <-wc.urlsToCrawl:
+					return u
+				case <-time.After(2 * time.Second):
+					t.Fatalf("hostScheduler did not dispatch a url")
+					return nil
+				}
+			}
+
+			u := receive()
+			start := time.Now()
+			if tt.releaseFirst {
+				wc.scheduler.release(u, 0)
+			}
+			receive()
+			if gap := time.Since(start); gap < tt.minGap || gap > tt.maxGap {
+				t.Errorf("hostScheduler dispatched the second url after %v, want between %v and %v", gap, tt.minGap, tt.maxGap)
+			}
+		})
+	}
+}
+
+func TestHostScheduler_throttle(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			http.NotFound(rw, r)
+			return
+		}
+		// The first request is throttled.
+		if atomic.AddInt32(&requests, 1) == 1 {
+			rw.Header().Set("Retry-After", "1")
+			rw.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		rw.Write([]byte(`<html><body><div class="item"><span>RTX 3080</span></div></body></html>`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name           string
+		maxRetries     int
+		wantVisited    int
+		wantFailed     int
+		wantThrottled  int
+		wantMinElapsed time.Duration
+	}{
+		{
+			name:           "Throttled url is crawled again after Retry-After",
+			maxRetries:     1,
+			wantVisited:    1,
+			wantThrottled:  1,
+			wantMinElapsed: time.Second,
+		},
+		{
+			name:          "Throttled url without retries fails",
+			maxRetries:    0,
+			wantFailed:    1,
+			wantThrottled: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			atomic.StoreInt32(&requests, 0)
+			o := options.New()
+			o.CrawlDelay = 0
+			o.AllowEmptyItem = true
+			o.MaxThrottledRetries = tt.maxRetries
+			wc := NewWithOptions(o)
+
+			start := time.Now()
+			got, err := wc.CrawlContext(context.Background(), server.URL+"/index.html", nil)
+			if err != nil {
+				t.Fatalf("WebCrawler.CrawlContext() error = %v", err)
+			}
+			if elapsed := time.Since(start); elapsed < tt.wantMinElapsed {
+				t.Errorf("WebCrawler.CrawlContext() took %v, want at least %v", elapsed, tt.wantMinElapsed)
+			}
+			if got.Metrics.UrlsVisited != tt.wantVisited || got.Metrics.FailedUrls != tt.wantFailed {
+				t.Errorf("WebCrawler.CrawlContext() urls visited = %v, failed urls = %v, want %v and %v", got.Metrics.UrlsVisited, got.Metrics.FailedUrls, tt.wantVisited, tt.wantFailed)
+			}
+			rate := got.Metrics.HostRates[server.URL]
+			if rate.Throttled != tt.wantThrottled || rate.Delay < time.Second {
+				t.Errorf("WebCrawler.CrawlContext() host rate = %+v, want %v throttled responses and a delay of at least 1s", rate, tt.wantThrottled)
+			}
+		})
+	}
+}
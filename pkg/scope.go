@@ -0,0 +1,205 @@
+package webcrawler
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+
+	options "github.com/cody6750/web-crawler/pkg/options"
+	webscraper "github.com/cody6750/web-crawler/pkg/webScraper"
+	"golang.org/x/net/publicsuffix"
+)
+
+const (
+	// regexPatternPrefix marks an include or exclude pattern as a regular expression, patterns without it are globs.
+	regexPatternPrefix = "regex:"
+)
+
+// scope decides whether a url belongs to the crawl, following the scope rules of the options. It is safe for
+// concurrent use.
+type scope struct {
+	sameHost     bool
+	sameDomain   bool
+	allowedHosts []string
+	deniedHosts  []string
+	include      []*regexp.Regexp
+	exclude      []*regexp.Regexp
+
+	// redirects holds the host every redirected seed redirected to, keyed by seed url.
+	redirects map[string]string
+	lock      sync.RWMutex
+}
+
+// newScope compiles the scope rules of the options. Returns an error if an include or exclude pattern is invalid.
+func newScope(o *options.Options) (*scope, error) {
+	s := &scope{
+		sameHost:     o.SameHost,
+		sameDomain:   o.SameDomain,
+		allowedHosts: lowerAll(o.AllowedHosts),
+		deniedHosts:  lowerAll(o.DeniedHosts),
+		redirects:    make(map[string]string),
+	}
+	var err error
+	if s.include, err = compilePatterns(o.IncludeURLPatterns); err != nil {
+		return nil, err
+	}
+	if s.exclude, err = compilePatterns(o.ExcludeURLPatterns); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// inScope checks the url against the scope rules. Same host and same domain are relative to the normalized root url
+// of the url, or the url the root url redirected to. Denied hosts and exclude patterns take precedence over allowed hosts and include patterns.
+func (s *scope) inScope(u *webscraper.URL) bool {
+	current, err := url.Parse(u.CurrentURL)
+	if err != nil || current.Host == "" {
+		// Urls that cannot be fetched are left for the web scraper to report.
+		return true
+	}
+	host := strings.ToLower(current.Hostname())
+
+	if (s.sameHost || s.sameDomain) && !s.sameSeed(host, u.RootURL) {
+		return false
+	}
+	if matchesHost(host, s.deniedHosts) {
+		return false
+	}
+	if len(s.allowedHosts) != 0 && !matchesHost(host, s.allowedHosts) {
+		return false
+	}
+	if matchesPattern(u.CurrentURL, s.exclude) {
+		return false
+	}
+	if len(s.include) != 0 && !matchesPattern(u.CurrentURL, s.include) {
+		return false
+	}
+	return true
+}
+
+// sameSeed checks the host against the hosts of the seed, following the same host and same domain rules.
+func (s *scope) sameSeed(host, rootURL string) bool {
+	for _, seedHost := range s.seedHosts(rootURL) {
+		if s.sameHost && host != seedHost {
+			continue
+		}
+		if s.sameDomain && registrableDomain(host) != registrableDomain(seedHost) {
+			continue
+		}
+		return true
+	}
+	return false
+}
+
+// seedHosts returns the host of the normalized seed, followed by the host the seed redirected to if any. Returns
+// nil if the seed is not a valid url.
+func (s *scope) seedHosts(rootURL string) []string {
+	normalized, err := webscraper.NormalizeURL(rootURL, nil)
+	if err != nil {
+		return nil
+	}
+	root, err := url.Parse(normalized)
+	if err != nil {
+		return nil
+	}
+	hosts := []string{root.Hostname()}
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if redirect, exist := s.redirects[rootURL]; exist && redirect != hosts[0] {
+		hosts = append(hosts, redirect)
+	}
+	return hosts
+}
+
+// seedRedirected records the url of the page the seed redirected to, so that the urls found on it are in scope of
+// the seed. Redirects are not part of the checkpoint, a resumed crawl only knows the redirects of the seeds it
+// fetched itself.
+func (s *scope) seedRedirected(rootURL, pageURL string) {
+	page, err := url.Parse(pageURL)
+	if err != nil || page.Host == "" {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.redirects[rootURL] = strings.ToLower(page.Hostname())
+}
+
+// registrableDomain returns the registrable domain of the host, for example example.co.uk for www.example.co.uk.
+// Hosts without a registrable domain, such as ip addresses or localhost, are returned as is.
+func registrableDomain(host string) string {
+	domain, err := publicsuffix.EffectiveTLDPlusOne(host)
+	if err != nil {
+		return host
+	}
+	return domain
+}
+
+// matchesHost checks if the host is one of the hosts or a subdomain of one of them.
+func matchesHost(host string, hosts []string) bool {
+	for _, h := range hosts {
+		if host == h || strings.HasSuffix(host, "."+h) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesPattern checks if the url matches one of the patterns.
+func matchesPattern(rawURL string, patterns []*regexp.Regexp) bool {
+	for _, pattern := range patterns {
+		if pattern.MatchString(rawURL) {
+			return true
+		}
+	}
+	return false
+}
+
+// compilePatterns compiles include or exclude patterns. Patterns prefixed with regex: are regular expressions that
+// match anywhere in the url, other patterns are globs that match the whole url, where * matches any sequence of
+// characters and ? matches a single character.
+func compilePatterns(patterns []string) ([]*regexp.Regexp, error) {
+	var compiled []*regexp.Regexp
+	for _, pattern := range patterns {
+		expr := globToRegex(pattern)
+		if strings.HasPrefix(pattern, regexPatternPrefix) {
+			expr = strings.TrimPrefix(pattern, regexPatternPrefix)
+		}
+		re, err := regexp.Compile(expr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid url pattern %q: %w", pattern, err)
+		}
+		compiled = append(compiled, re)
+	}
+	return compiled, nil
+}
+
+// globToRegex converts a glob to an anchored regular expression.
+func globToRegex(glob string) string {
+	var expr strings.Builder
+	expr.WriteString("^")
+	for _, r := range glob {
+		switch r {
+		case '*':
+			expr.WriteString(".*")
+		case '?':
+			expr.WriteString(".")
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	expr.WriteString("$")
+	return expr.String()
+}
+
+// lowerAll returns the lower cased hosts.
+func lowerAll(hosts []string) []string {
+	lowered := make([]string, 0, len(hosts))
+	for _, host := range hosts {
+		if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+			lowered = append(lowered, host)
+		}
+	}
+	return lowered
+}
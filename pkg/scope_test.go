@@ -0,0 +1,142 @@
+package webcrawler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	options "github.com/cody6750/web-crawler/pkg/options"
+	webscraper "github.com/cody6750/web-crawler/pkg/webScraper"
+)
+
+func Test_scope_inScope(t *testing.T) {
+	tests := []struct {
+		name    string
+		options func(o *options.Options)
+		root    string
+		url     string
+		want    bool
+	}{
+		{name: "No rules", options: func(o *options.Options) {}, url: "https://other.com/", want: true},
+		{name: "Same host", options: func(o *options.Options) { o.SameHost = true }, url: "https://www.example.com/a", want: true},
+		{name: "Same host, mixed case seed", options: func(o *options.Options) { o.SameHost = true }, root: "HTTPS://WWW.Example.COM:443/", url: "https://www.example.com/a", want: true},
+		{name: "Same host, subdomain", options: func(o *options.Options) { o.SameHost = true }, url: "https://blog.example.com/a", want: false},
+		{name: "Same domain, subdomain", options: func(o *options.Options) { o.SameDomain = true }, url: "https://blog.example.com/a", want: true},
+		{name: "Same domain, other domain", options: func(o *options.Options) { o.SameDomain = true }, url: "https://example.org/a", want: false},
+		{name: "Same domain, public suffix", options: func(o *options.Options) { o.SameDomain = true }, root: "https://www.example.co.uk/", url: "https://other.co.uk/a", want: false},
+		{name: "Allowed host", options: func(o *options.Options) { o.AllowedHosts = []string{"Other.com"} }, url: "https://shop.other.com/a", want: true},
+		{name: "Not allowed host", options: func(o *options.Options) { o.AllowedHosts = []string{"other.com"} }, url: "https://another.com/a", want: false},
+		{name: "Denied host", options: func(o *options.Options) { o.DeniedHosts = []string{"ads.example.com"} }, url: "https://ads.example.com/a", want: false},
+		{name: "Denied host wins", options: func(o *options.Options) {
+			o.SameDomain = true
+			o.DeniedHosts = []string{"ads.example.com"}
+		}, url: "https://ads.example.com/a", want: false},
+		{name: "Include glob", options: func(o *options.Options) { o.IncludeURLPatterns = []string{"*/products/*"} }, url: "https://www.example.com/products/1", want: true},
+		{name: "Include glob, no match", options: func(o *options.Options) { o.IncludeURLPatterns = []string{"*/products/*"} }, url: "https://www.example.com/blog/1", want: false},
+		{name: "Exclude regex", options: func(o *options.Options) { o.ExcludeURLPatterns = []string{`regex:\.pdf$`} }, url: "https://www.example.com/a.pdf", want: false},
+		{name: "Exclude wins", options: func(o *options.Options) {
+			o.IncludeURLPatterns = []string{"*/products/*"}
+			o.ExcludeURLPatterns = []string{"*?sort=*"}
+		}, url: "https://www.example.com/products/?sort=price", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := options.New()
+			tt.options(o)
+			s, err := newScope(o)
+			if err != nil {
+				t.Fatalf("newScope() error = %v", err)
+			}
+			u := &webscraper.URL{RootURL: "https://www.example.com/", CurrentURL: tt.url}
+			if tt.root != "" {
+				u.RootURL = tt.root
+			}
+			if got := s.inScope(u); got != tt.want {
+				t.Errorf("scope.inScope() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_newScope(t *testing.T) {
+	tests := []struct {
+		name    string
+		pattern string
+		wantErr bool
+	}{
+		{name: "Glob", pattern: "*/[a-z]/*", wantErr: false},
+		{name: "Valid regex", pattern: "regex:/page/[0-9]+", wantErr: false},
+		{name: "Invalid regex", pattern: "regex:/page/[0-9", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := options.New()
+			o.IncludeURLPatterns = []string{tt.pattern}
+			if _, err := newScope(o); (err != nil) != tt.wantErr {
+				t.Errorf("newScope() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWebCrawler_CrawlScope(t *testing.T) {
+	site := newTestSite(t, map[string]string{
+		"/index.html": `<a href="/a">a</a><a href="/private/b">b</a><a href="http://other.invalid/c">c</a>`,
+	})
+	o := options.New()
+	o.CrawlDelay = 0
+	o.AllowEmptyItem = true
+	o.SameHost = true
+	o.ExcludeURLPatterns = []string{"*/private/*"}
+	got, err := NewWithOptions(o).CrawlContext(context.Background(), site.URL+"/index.html", nil)
+	if err != nil {
+		t.Fatalf("WebCrawler.CrawlContext() error = %v", err)
+	}
+	if got.Metrics.UrlsVisited != 2 || got.Metrics.OutOfScopeUrlsFound != 2 || site.hitCount("/private/b") != 0 {
+		t.Errorf("WebCrawler.CrawlContext() urls visited = %v, out of scope urls = %v, /private/b fetched %v times, want 2, 2 and 0", got.Metrics.UrlsVisited, got.Metrics.OutOfScopeUrlsFound, site.hitCount("/private/b"))
+	}
+}
+
+func TestWebCrawler_CrawlScope_seeds(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			http.NotFound(rw, r)
+		case "/redirect":
+			http.Redirect(rw, r, server.URL+"/index.html", http.StatusMovedPermanently)
+		case "/index.html":
+			rw.Write([]byte(`<a href="/a">a</a><a href="http://other.invalid/c">c</a>`))
+		default:
+			rw.Write([]byte(`<html></html>`))
+		}
+	}))
+	defer server.Close()
+	// The seeds are on localhost, the server also answers on 127.0.0.1.
+	localhost := strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+
+	tests := []struct {
+		name string
+		seed string
+	}{
+		{name: "Mixed case seed", seed: strings.Replace(localhost, "http://localhost", "HTTP://LocalHost", 1) + "/index.html"},
+		{name: "Redirected seed", seed: localhost + "/redirect"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := options.New()
+			o.CrawlDelay = 0
+			o.AllowEmptyItem = true
+			o.SameHost = true
+			got, err := NewWithOptions(o).CrawlContext(context.Background(), tt.seed, nil)
+			if err != nil {
+				t.Fatalf("WebCrawler.CrawlContext() error = %v", err)
+			}
+			if got.Metrics.UrlsVisited != 2 || got.Metrics.OutOfScopeUrlsFound != 1 {
+				t.Errorf("WebCrawler.CrawlContext() urls visited = %v, out of scope urls = %v, want 2 and 1", got.Metrics.UrlsVisited, got.Metrics.OutOfScopeUrlsFound)
+			}
+		})
+	}
+}
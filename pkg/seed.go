@@ -0,0 +1,98 @@
+package webcrawler
+
+import (
+	"errors"
+	"fmt"
+
+	webscraper "github.com/cody6750/web-crawler/pkg/webScraper"
+)
+
+// Seed is a url the crawl starts from. The urls found from a seed are crawled up to its max depth, carry its tag and
+// are counted in its SeedMetrics. Scope rules relative to the root url, such as Options.SameHost, are relative to the
+// seed the url was found from.
+type Seed struct {
+	URL string `json:"URL"`
+
+	// MaxDepth overrides Options.MaxDepth for the urls found from the seed when set.
+	MaxDepth *int `json:"MaxDepth,omitempty"`
+
+	// Tag labels the urls found from the seed, it is copied to webscraper.URL.Tag.
+	Tag string `json:"Tag,omitempty"`
+
+	// Feed marks the url as an RSS or Atom feed. The entries of the feed are crawled instead of the feed itself, they
+	// are found from the seed.
+	Feed bool `json:"Feed,omitempty"`
+}
+
+// SeedMetrics represents the metrics of the urls found from a single seed. A url found from several seeds is only
+// crawled once, it counts towards the seed it was first found from.
+type SeedMetrics struct {
+	Tag             string
+	UrlsFound       int
+	UrlsVisited     int
+	ItemsFound      int
+	FailedUrls      int
+	BytesDownloaded int64
+}
+
+// validateSeeds checks that there is at least one seed and that every seed has a url and a valid max depth.
+func validateSeeds(seeds []Seed) error {
+	if len(seeds) == 0 {
+		return errors.New("at least one seed url is required")
+	}
+	for _, seed := range seeds {
+		if seed.URL == "" {
+			return errors.New("seed url cannot be empty")
+		}
+		if seed.MaxDepth != nil && *seed.MaxDepth < 0 {
+			return fmt.Errorf("max depth of seed %v cannot be lower then 0. Current max depth: %v", seed.URL, *seed.MaxDepth)
+		}
+	}
+	return nil
+}
+
+// seedRoot returns the root url of the seed.
+func (wc *WebCrawler) seedRoot(seed Seed) *webscraper.URL {
+	maxDepth := wc.Options.MaxDepth
+	if seed.MaxDepth != nil {
+		maxDepth = *seed.MaxDepth
+	}
+	return &webscraper.URL{RootURL: seed.URL, CurrentURL: seed.URL, CurrentDepth: 0, MaxDepth: maxDepth, Tag: seed.Tag}
+}
+
+// isSeed returns whether the url is the root url of a seed, rather than a url found from a seed such as a feed entry.
+// Seeds are crawled whatever the scope rules.
+func isSeed(u *webscraper.URL) bool {
+	return u.CurrentDepth == 0 && u.ParentURL == ""
+}
+
+// feedSeeds returns the seeds of the crawl that are feeds, including the feeds of Options.FeedURLs.
+func (wc *WebCrawler) feedSeeds(seeds []Seed) []Seed {
+	var feeds []Seed
+	for _, seed := range seeds {
+		if seed.Feed {
+			feeds = append(feeds, seed)
+		}
+	}
+	for _, feedURL := range wc.Options.FeedURLs {
+		feeds = append(feeds, Seed{URL: feedURL, Feed: true})
+	}
+	return feeds
+}
+
+// incrementSeedMetrics adds the metrics of the url to the metrics of the seed it was found from.
+func (wc *WebCrawler) incrementSeedMetrics(u *webscraper.URL, m SeedMetrics) {
+	wc.metricsLock.Lock()
+	defer wc.metricsLock.Unlock()
+	if wc.metrics.Seeds == nil {
+		wc.metrics.Seeds = make(map[string]SeedMetrics)
+	}
+	seed := wc.metrics.Seeds[u.RootURL]
+	seed.Tag = u.Tag
+	seed.UrlsFound += m.UrlsFound
+	seed.UrlsVisited += m.UrlsVisited
+	seed.ItemsFound += m.ItemsFound
+	seed.FailedUrls += m.FailedUrls
+	seed.BytesDownloaded += m.BytesDownloaded
+	wc.metrics.Seeds[u.RootURL] = seed
+}
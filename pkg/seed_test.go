@@ -0,0 +1,96 @@
+package webcrawler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	options "github.com/cody6750/web-crawler/pkg/options"
+)
+
+func Test_validateSeeds(t *testing.T) {
+	depth := 1
+	negative := -1
+	tests := []struct {
+		name    string
+		seeds   []Seed
+		wantErr bool
+	}{
+		{name: "Seeds", seeds: []Seed{{URL: "https://www.example.com"}, {URL: "https://shop.example.com", MaxDepth: &depth, Tag: "shop"}}},
+		{name: "No seeds", wantErr: true},
+		{name: "Empty url", seeds: []Seed{{URL: ""}}, wantErr: true},
+		{name: "Negative max depth", seeds: []Seed{{URL: "https://www.example.com", MaxDepth: &negative}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateSeeds(tt.seeds); (err != nil) != tt.wantErr {
+				t.Errorf("validateSeeds() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWebCrawler_CrawlSeeds(t *testing.T) {
+	var (
+		lock    sync.Mutex
+		crawled []string
+	)
+	handler := func(name string) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/robots.txt" {
+				rw.Write([]byte("User-agent: *\nDisallow: /private\n"))
+				return
+			}
+			lock.Lock()
+			crawled = append(crawled, name+r.URL.Path)
+			lock.Unlock()
+			switch r.URL.Path {
+			case "/":
+				rw.Write([]byte(`<a href="/a">a</a><a href="/private">private</a>`))
+			case "/a":
+				rw.Write([]byte(`<a href="/b">b</a>`))
+			default:
+				rw.Write([]byte(`<html></html>`))
+			}
+		})
+	}
+	first := httptest.NewServer(handler("first"))
+	defer first.Close()
+	second := httptest.NewServer(handler("second"))
+	defer second.Close()
+
+	o := options.New()
+	o.CrawlDelay = 0
+	o.AllowEmptyItem = true
+	o.SameHost = true
+	o.MaxDepth = 2
+	depth := 1
+	seeds := []Seed{{URL: first.URL + "/"}, {URL: second.URL + "/", MaxDepth: &depth, Tag: "second"}}
+	got, err := NewWithOptions(o).CrawlSeeds(seeds, nil)
+	if err != nil {
+		t.Fatalf("WebCrawler.CrawlSeeds() error = %v", err)
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	sort.Strings(crawled)
+	if want := []string{"first/", "first/a", "first/b", "second/", "second/a"}; !reflect.DeepEqual(crawled, want) {
+		t.Errorf("WebCrawler.CrawlSeeds() crawled = %v, want %v", crawled, want)
+	}
+	if got.Metrics.URL != first.URL+"/" {
+		t.Errorf("WebCrawler.CrawlSeeds() metrics url = %v, want %v", got.Metrics.URL, first.URL+"/")
+	}
+	wantSeeds := map[string]SeedMetrics{
+		first.URL + "/":  {UrlsFound: 3, UrlsVisited: 3},
+		second.URL + "/": {Tag: "second", UrlsFound: 3, UrlsVisited: 2},
+	}
+	for seed, want := range wantSeeds {
+		metrics := got.Metrics.Seeds[seed]
+		metrics.BytesDownloaded = 0
+		if metrics != want {
+			t.Errorf("WebCrawler.CrawlSeeds() metrics of seed %v = %+v, want %+v", seed, metrics, want)
+		}
+	}
+}
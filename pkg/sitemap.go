@@ -0,0 +1,196 @@
+package webcrawler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	webscraper "github.com/cody6750/web-crawler/pkg/webScraper"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// maxSitemapSize is the maximum uncompressed size of a sitemap, set by the sitemap protocol.
+	maxSitemapSize = 50 * 1024 * 1024
+
+	// maxSitemaps is the maximum number of sitemaps read per crawl, it bounds sitemap indexes that reference each
+	// other.
+	maxSitemaps = 1000
+
+	// defaultSitemapPriority is the priority of a url without <priority>, set by the sitemap protocol.
+	defaultSitemapPriority = 0.5
+)
+
+// lastModLayouts are the W3C datetime layouts allowed in <lastmod>.
+var lastModLayouts = []string{time.RFC3339Nano, "2006-01-02T15:04Z07:00", "2006-01-02", "2006-01", "2006"}
+
+// sitemap is a sitemap or a sitemap index, see https://www.sitemaps.org/protocol.html.
+type sitemap struct {
+	URLs     []sitemapEntry `xml:"url"`
+	Sitemaps []sitemapEntry `xml:"sitemap"`
+}
+
+// sitemapEntry is a <url> of a sitemap or a <sitemap> of a sitemap index.
+type sitemapEntry struct {
+	Loc      string `xml:"loc"`
+	LastMod  string `xml:"lastmod"`
+	Priority string `xml:"priority"`
+}
+
+// parseSitemap parses a sitemap or a sitemap index, gzip compressed or not.
+func parseSitemap(body []byte) (*sitemap, error) {
+	var reader io.Reader = bytes.NewReader(body)
+	if len(body) >= 2 && body[0] == 0x1f && body[1] == 0x8b {
+		gz, err := gzip.NewReader(reader)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+		reader = gz
+	}
+	sm := &sitemap{}
+	if err := xml.NewDecoder(io.LimitReader(reader, maxSitemapSize)).Decode(sm); err != nil {
+		return nil, fmt.Errorf("invalid sitemap: %w", err)
+	}
+	return sm, nil
+}
+
+// parseLastMod parses a <lastmod>, zero if it is missing or invalid.
+func parseLastMod(value string) time.Time {
+	value = strings.TrimSpace(value)
+	for _, layout := range lastModLayouts {
+		if lastMod, err := time.Parse(layout, value); err == nil {
+			return lastMod
+		}
+	}
+	return time.Time{}
+}
+
+// parsePriority parses a <priority>, defaultSitemapPriority if it is missing or invalid.
+func parsePriority(value string) float64 {
+	priority, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || priority < 0 || priority > 1 {
+		return defaultSitemapPriority
+	}
+	return priority
+}
+
+// processSitemaps seeds the frontier with the urls of the sitemaps of the host of the root url, the Sitemap: lines of
+// its robots.txt or /sitemap.xml if there are none. Sitemap indexes are followed. Sitemap urls are found at depth 1,
+// like the links of the root url, and go through the same checks. With Options.SitemapModifiedSince, urls last
+// modified before it are skipped.
+func (wc *WebCrawler) processSitemaps(root *webscraper.URL) {
+	rootURL, err := url.Parse(root.CurrentURL)
+	if err != nil || rootURL.Host == "" {
+		return
+	}
+	queue := append([]string(nil), wc.getRobots(rootURL).Sitemaps...)
+	if len(queue) == 0 {
+		queue = []string{rootURL.Scheme + "://" + rootURL.Host + "/sitemap.xml"}
+	}
+	read := make(map[string]bool)
+	for len(queue) > 0 && len(read) < maxSitemaps && wc.ctx.Err() == nil {
+		sitemapURL := queue[0]
+		queue = queue[1:]
+		if read[sitemapURL] {
+			continue
+		}
+		read[sitemapURL] = true
+		if !wc.isAllowedByRobots(sitemapURL) {
+			wc.Logger.WithField("url", sitemapURL).Debug("Sitemap is disallowed by robots.txt")
+			continue
+		}
+		sm, err := wc.fetchSitemap(sitemapURL)
+		if err != nil {
+			wc.Logger.WithError(err).WithField("url", sitemapURL).Warn("Unable to read sitemap")
+			continue
+		}
+		base, _ := url.Parse(sitemapURL)
+		for _, entry := range sm.Sitemaps {
+			if loc := resolveLoc(base, entry.Loc); loc != "" {
+				queue = append(queue, loc)
+			}
+		}
+
+		found := make([]*webscraper.URL, 0, len(sm.URLs))
+		for _, entry := range sm.URLs {
+			loc := resolveLoc(base, entry.Loc)
+			if loc == "" {
+				continue
+			}
+			lastMod := parseLastMod(entry.LastMod)
+			if since := wc.Options.SitemapModifiedSince; !since.IsZero() && !lastMod.IsZero() && lastMod.Before(since) {
+				continue
+			}
+			found = append(found, &webscraper.URL{
+				RootURL:      root.RootURL,
+				ParentURL:    sitemapURL,
+				CurrentURL:   loc,
+				CurrentDepth: root.CurrentDepth + 1,
+				MaxDepth:     root.MaxDepth,
+				Tag:          root.Tag,
+				LastModified: lastMod,
+				Priority:     parsePriority(entry.Priority),
+			})
+		}
+		wc.Logger.WithFields(logrus.Fields{"url": sitemapURL, "urls": len(found), "sitemaps": len(sm.Sitemaps)}).Debug("Successfully read sitemap")
+		wc.incrementMetrics(&Metrics{SitemapUrlsFound: len(found)})
+		wc.processScrapedUrls(found)
+	}
+}
+
+// fetchSitemap fetches and parses a sitemap.
+func (wc *WebCrawler) fetchSitemap(sitemapURL string) (*sitemap, error) {
+	body, err := wc.download(sitemapURL, maxSitemapSize)
+	if err != nil {
+		return nil, err
+	}
+	return parseSitemap(body)
+}
+
+// download fetches the body of a document that is read by the web crawler itself rather than scraped, up to maxSize
+// bytes. The downloaded bytes count towards the crawl budgets.
+func (wc *WebCrawler) download(rawURL string, maxSize int64) ([]byte, error) {
+	resp, err := webscraper.FetchContext(wc.ctx, wc.fetcher(), rawURL, wc.Options.HeaderKey, wc.Options.HeaderValue)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code %v", resp.StatusCode)
+	}
+	body, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxSize))
+	wc.incrementMetrics(&Metrics{BytesDownloaded: int64(len(body))})
+	wc.addHostBytes(&webscraper.URL{CurrentURL: rawURL}, int64(len(body)))
+	if err != nil {
+		return nil, err
+	}
+	return body, nil
+}
+
+// resolveLoc resolves a <loc> against the url of its sitemap. Returns an empty string if it is invalid.
+func resolveLoc(base *url.URL, loc string) string {
+	loc = strings.TrimSpace(loc)
+	if loc == "" || base == nil {
+		return ""
+	}
+	resolved, err := base.Parse(loc)
+	if err != nil || resolved.Host == "" {
+		return ""
+	}
+	return resolved.String()
+}
+
+// SitemapPriorityScorer is a url scorer for best first crawls that crawls the urls of a sitemap in the order of their
+// <priority>, the shallow urls first. Urls that are not found in a sitemap have a priority of 0.
+func SitemapPriorityScorer(u *webscraper.URL) float64 {
+	return u.Priority - float64(u.CurrentDepth)
+}
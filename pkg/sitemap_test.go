@@ -0,0 +1,201 @@
+package webcrawler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	options "github.com/cody6750/web-crawler/pkg/options"
+)
+
+func gzipBytes(t *testing.T, data string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	if _, err := gz.Write([]byte(data)); err != nil {
+		t.Fatalf("gzip.Writer.Write() error = %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		t.Fatalf("gzip.Writer.Close() error = %v", err)
+	}
+	return buf.Bytes()
+}
+
+func Test_parseSitemap(t *testing.T) {
+	urlset := `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+	<url><loc>https://www.example.com/a</loc><lastmod>2021-01-02</lastmod><priority>0.8</priority></url>
+	<url><loc> https://www.example.com/b </loc></url>
+</urlset>`
+	index := `<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+	<sitemap><loc>https://www.example.com/sitemap1.xml.gz</loc></sitemap>
+</sitemapindex>`
+	tests := []struct {
+		name    string
+		body    []byte
+		want    *sitemap
+		wantErr bool
+	}{
+		{
+			name: "Sitemap",
+			body: []byte(urlset),
+			want: &sitemap{URLs: []sitemapEntry{
+				{Loc: "https://www.example.com/a", LastMod: "2021-01-02", Priority: "0.8"},
+				{Loc: " https://www.example.com/b "},
+			}},
+		},
+		{
+			name: "Gzip sitemap",
+			body: gzipBytes(t, urlset),
+			want: &sitemap{URLs: []sitemapEntry{
+				{Loc: "https://www.example.com/a", LastMod: "2021-01-02", Priority: "0.8"},
+				{Loc: " https://www.example.com/b "},
+			}},
+		},
+		{
+			name: "Sitemap index",
+			body: []byte(index),
+			want: &sitemap{Sitemaps: []sitemapEntry{{Loc: "https://www.example.com/sitemap1.xml.gz"}}},
+		},
+		{name: "Invalid sitemap", body: []byte(`<urlset><url>`), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSitemap(tt.body)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSitemap() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseSitemap() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_parseLastMod(t *testing.T) {
+	tests := []struct {
+		value string
+		want  time.Time
+	}{
+		{value: "2021-01-02T03:04:05.5+01:00", want: time.Date(2021, 1, 2, 2, 4, 5, 500000000, time.UTC)},
+		{value: "2021-01-02T03:04+01:00", want: time.Date(2021, 1, 2, 2, 4, 0, 0, time.UTC)},
+		{value: "2021-01-02", want: time.Date(2021, 1, 2, 0, 0, 0, 0, time.UTC)},
+		{value: "2021", want: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{value: "yesterday", want: time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			if got := parseLastMod(tt.value); !got.Equal(tt.want) {
+				t.Errorf("parseLastMod() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebCrawler_CrawlSitemaps(t *testing.T) {
+	var (
+		lock    sync.Mutex
+		crawled []string
+	)
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			rw.Write([]byte("User-agent: *\nDisallow: /private\nSitemap: " + server.URL + "/sitemap_index.xml\n"))
+		case "/sitemap_index.xml":
+			rw.Write([]byte(`<sitemapindex><sitemap><loc>/products.xml.gz</loc></sitemap><sitemap><loc>/sitemap_index.xml</loc></sitemap></sitemapindex>`))
+		case "/products.xml.gz":
+			rw.Write(gzipBytes(t, `<urlset>
+				<url><loc>`+server.URL+`/product/1</loc><lastmod>2021-06-01</lastmod></url>
+				<url><loc>`+server.URL+`/product/2</loc><lastmod>2020-01-01</lastmod></url>
+				<url><loc>`+server.URL+`/private/3</loc></url>
+				<url><loc>https://other.example.com/product/4</loc></url>
+			</urlset>`))
+		default:
+			lock.Lock()
+			crawled = append(crawled, r.URL.Path)
+			lock.Unlock()
+			rw.Write([]byte(`<html></html>`))
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name          string
+		maxDepth      int
+		modifiedSince time.Time
+		want          []string
+		wantFound     int
+	}{
+		{name: "Sitemap urls are crawled", maxDepth: 1, want: []string{"/", "/product/1", "/product/2"}, wantFound: 4},
+		{name: "Old sitemap urls are skipped", maxDepth: 1, modifiedSince: time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC), want: []string{"/", "/product/1"}, wantFound: 3},
+		{name: "Sitemap urls respect max depth", maxDepth: 0, want: []string{"/"}, wantFound: 4},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lock.Lock()
+			crawled = nil
+			lock.Unlock()
+			o := options.New()
+			o.CrawlDelay = 0
+			o.AllowEmptyItem = true
+			o.SameHost = true
+			o.MaxDepth = tt.maxDepth
+			o.Sitemaps = true
+			o.SitemapModifiedSince = tt.modifiedSince
+			got, err := NewWithOptions(o).CrawlContext(context.Background(), server.URL+"/", nil)
+			if err != nil {
+				t.Fatalf("WebCrawler.CrawlContext() error = %v", err)
+			}
+			lock.Lock()
+			defer lock.Unlock()
+			sort.Strings(crawled)
+			if !reflect.DeepEqual(crawled, tt.want) || got.Metrics.SitemapUrlsFound != tt.wantFound {
+				t.Errorf("WebCrawler.CrawlContext() crawled = %v, sitemap urls found = %v, want %v and %v", crawled, got.Metrics.SitemapUrlsFound, tt.want, tt.wantFound)
+			}
+		})
+	}
+}
+
+func TestWebCrawler_CrawlSitemapsFallback(t *testing.T) {
+	var (
+		lock    sync.Mutex
+		crawled []string
+	)
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			http.NotFound(rw, r)
+		case "/sitemap.xml":
+			rw.Write([]byte(`<urlset><url><loc>` + server.URL + `/a</loc><priority>0.9</priority></url></urlset>`))
+		default:
+			lock.Lock()
+			crawled = append(crawled, r.URL.Path)
+			lock.Unlock()
+			rw.Write([]byte(`<html></html>`))
+		}
+	}))
+	defer server.Close()
+
+	o := options.New()
+	o.CrawlDelay = 0
+	o.AllowEmptyItem = true
+	o.Sitemaps = true
+	if _, err := NewWithOptions(o).CrawlContext(context.Background(), server.URL+"/", nil); err != nil {
+		t.Fatalf("WebCrawler.CrawlContext() error = %v", err)
+	}
+	lock.Lock()
+	defer lock.Unlock()
+	sort.Strings(crawled)
+	if want := []string{"/", "/a"}; !reflect.DeepEqual(crawled, want) {
+		t.Errorf("WebCrawler.CrawlContext() crawled = %v, want %v", crawled, want)
+	}
+}
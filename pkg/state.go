@@ -0,0 +1,221 @@
+package webcrawler
+
+import (
+	"sort"
+	"sync"
+
+	webscraper "github.com/cody6750/web-crawler/pkg/webScraper"
+)
+
+// crawlState tracks the visited urls of the crawl along with the frontier, the urls that have been found but not yet
+// crawled, so that the crawl can be checkpointed and resumed. A url joins the frontier before it is sent to the
+// pendingUrlsToCrawl channel and leaves it once it has been deduplicated as a duplicate, or crawled, skipped or has
+// failed, so a snapshot never misses a url that is on its way between the go routines of the web crawler.
+//
+// Once changes are tracked, the urls visited and the urls of the frontier that changed since the last snapshot or
+// delta are recorded, so that a checkpoint can be saved incrementally.
+type crawlState struct {
+	lock       sync.Mutex
+	visitedSet string
+	visited    VisitedSet
+	frontier   map[string]*frontierEntry
+
+	track      bool
+	visitedLog []string
+	changed    map[string]struct{}
+}
+
+// frontierEntry is a url of the frontier. The same url may be on its way more than once until it is deduplicated,
+// pending counts how many times. submitted reports whether the url has passed deduplication and is waiting to be
+// crawled or being crawled.
+type frontierEntry struct {
+	url       *webscraper.URL
+	pending   int
+	submitted bool
+}
+
+// newCrawlState creates an empty crawl state that keeps its visited urls in the visited set of the type.
+func newCrawlState(visitedSet string, visited VisitedSet) *crawlState {
+	return &crawlState{
+		visitedSet: visitedSet,
+		visited:    visited,
+		frontier:   make(map[string]*frontierEntry),
+	}
+}
+
+// trackChanges starts recording the changes of the crawl state for delta.
+func (s *crawlState) trackChanges() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.track = true
+	s.changed = make(map[string]struct{})
+}
+
+// entry returns the frontier entry of the url, creating it if needed, and records the url as changed. Must be called
+// under the lock.
+func (s *crawlState) entry(u *webscraper.URL) *frontierEntry {
+	if s.track {
+		s.changed[u.CurrentURL] = struct{}{}
+	}
+	entry, ok := s.frontier[u.CurrentURL]
+	if !ok {
+		entry = &frontierEntry{url: u}
+		s.frontier[u.CurrentURL] = entry
+	}
+	return entry
+}
+
+// release removes the entry of the url from the frontier once it is neither pending nor submitted. Must be called
+// under the lock.
+func (s *crawlState) release(u *webscraper.URL, entry *frontierEntry) {
+	if entry.pending <= 0 && !entry.submitted {
+		delete(s.frontier, u.CurrentURL)
+	}
+}
+
+// push adds the url to the frontier before it is deduplicated.
+func (s *crawlState) push(u *webscraper.URL) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.entry(u).pending++
+}
+
+// visit marks the url as visited. Returns false if it has already been visited, or the visited set failed, in which
+// case it leaves the frontier.
+func (s *crawlState) visit(u *webscraper.URL) (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	entry := s.entry(u)
+	entry.pending--
+	added, err := s.visited.Add(u.CurrentURL)
+	if added {
+		entry.url, entry.submitted = u, true
+		if s.track {
+			s.visitedLog = append(s.visitedLog, u.CurrentURL)
+		}
+	}
+	s.release(u, entry)
+	return added, err
+}
+
+// submit marks a url of a checkpoint that has already passed deduplication as submitted.
+func (s *crawlState) submit(u *webscraper.URL) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	entry := s.entry(u)
+	entry.url, entry.submitted = u, true
+}
+
+// done removes the url from the frontier once it has been crawled, skipped or has failed.
+func (s *crawlState) done(u *webscraper.URL) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	entry, ok := s.frontier[u.CurrentURL]
+	if !ok {
+		return
+	}
+	if s.track {
+		s.changed[u.CurrentURL] = struct{}{}
+	}
+	entry.submitted = false
+	s.release(u, entry)
+}
+
+// snapshot returns the submitted and the pending urls of the frontier, ordered by depth, and the visited set of the
+// crawl. The tracked changes are reset, the next delta is relative to the snapshot.
+func (s *crawlState) snapshot() ([]*webscraper.URL, []*webscraper.URL, []byte, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.resetChanges()
+	var submitted, pending []*webscraper.URL
+	for _, entry := range s.frontier {
+		u := *entry.url
+		if entry.submitted {
+			submitted = append(submitted, &u)
+		} else {
+			pending = append(pending, &u)
+		}
+	}
+	sortByDepth(submitted)
+	sortByDepth(pending)
+	visited, err := s.visited.MarshalBinary()
+	return submitted, pending, visited, err
+}
+
+// delta returns the changes of the crawl state since the last snapshot or delta: the urls visited, the submitted and
+// pending urls of the frontier that changed and the urls that left the frontier. The tracked changes are reset.
+func (s *crawlState) delta() (visited []string, submitted, pending []*webscraper.URL, removed []string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for rawURL := range s.changed {
+		entry, ok := s.frontier[rawURL]
+		if !ok {
+			removed = append(removed, rawURL)
+			continue
+		}
+		u := *entry.url
+		if entry.submitted {
+			submitted = append(submitted, &u)
+		} else {
+			pending = append(pending, &u)
+		}
+	}
+	visited = s.visitedLog
+	s.resetChanges()
+	sortByDepth(submitted)
+	sortByDepth(pending)
+	sort.Strings(removed)
+	return visited, submitted, pending, removed
+}
+
+// resetChanges forgets the tracked changes. Must be called under the lock.
+func (s *crawlState) resetChanges() {
+	if s.track {
+		s.visitedLog = nil
+		s.changed = make(map[string]struct{})
+	}
+}
+
+// restore loads the visited urls of a checkpoint, including the urls visited after its visited set was saved.
+func (s *crawlState) restore(checkpoint *CrawlState) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if err := s.visited.UnmarshalBinary(checkpoint.Visited); err != nil {
+		return err
+	}
+	for _, rawURL := range checkpoint.VisitedLog {
+		if _, err := s.visited.Add(rawURL); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// stats describes the visited set of the crawl.
+func (s *crawlState) stats() VisitedSetStats {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return VisitedSetStats{
+		Type:              s.visitedSet,
+		Len:               s.visited.Len(),
+		SizeBytes:         s.visited.SizeBytes(),
+		FalsePositiveRate: s.visited.FalsePositiveRate(),
+	}
+}
+
+// close releases the visited set of the crawl.
+func (s *crawlState) close() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.visited.Close()
+}
+
+// sortByDepth sorts the urls by depth, then by url.
+func sortByDepth(urls []*webscraper.URL) {
+	sort.Slice(urls, func(i, j int) bool {
+		if urls[i].CurrentDepth != urls[j].CurrentDepth {
+			return urls[i].CurrentDepth < urls[j].CurrentDepth
+		}
+		return urls[i].CurrentURL < urls[j].CurrentURL
+	})
+}
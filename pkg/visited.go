@@ -0,0 +1,399 @@
+package webcrawler
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math"
+	"os"
+	"sort"
+
+	options "github.com/cody6750/web-crawler/pkg/options"
+)
+
+const (
+	// exactURLOverhead is the approximate memory used by every url of an exact visited set on top of the url itself.
+	exactURLOverhead = 64
+
+	// diskSetMinSlots is the initial number of slots of a disk visited set.
+	diskSetMinSlots = 1 << 16
+
+	// diskSetProbeSlots is the number of slots a disk visited set reads at once while probing. Linear probing rarely
+	// leaves the block of its first slot, so most urls are added with a single read.
+	diskSetProbeSlots = 512
+
+	// diskSetChunkSlots is the number of slots a disk visited set reads at once while growing or saving the table, so
+	// that the table is never held in memory as a whole.
+	diskSetChunkSlots = 1 << 14
+)
+
+// VisitedSet keeps track of the urls the crawl has visited. Implementations trade exactness for memory, see
+// Options.VisitedSet. They are used from a single go routine at a time and do not need to be safe for concurrent use.
+type VisitedSet interface {
+	// Add adds the url to the set. Returns false if the url is already in the set, or for approximate sets, probably
+	// is. Returns an error if the set cannot be read or written, the crawl fails rather than crawling urls twice.
+	Add(url string) (bool, error)
+
+	// Len returns the number of urls added to the set.
+	Len() int
+
+	// SizeBytes returns the approximate memory or disk space used by the set.
+	SizeBytes() int64
+
+	// FalsePositiveRate returns the probability that Add reports a url that has not been visited as visited.
+	FalsePositiveRate() float64
+
+	// MarshalBinary and UnmarshalBinary save and restore the set in checkpoints.
+	MarshalBinary() ([]byte, error)
+	UnmarshalBinary(data []byte) error
+
+	// Close releases the resources of the set.
+	Close() error
+}
+
+// VisitedSetStats describes the visited set of a crawl.
+type VisitedSetStats struct {
+	Type              string
+	Len               int
+	SizeBytes         int64
+	FalsePositiveRate float64
+}
+
+// newVisitedSet creates the visited set selected by Options.VisitedSet.
+func newVisitedSet(o *options.Options) (VisitedSet, error) {
+	switch o.VisitedSet {
+	case options.VisitedSetExact:
+		return NewExactVisitedSet(), nil
+	case options.VisitedSetBloom:
+		return NewBloomVisitedSet(o.VisitedSetCapacity, o.VisitedSetFalsePositiveRate)
+	case options.VisitedSetDisk:
+		return NewDiskVisitedSet(o.VisitedSetDir)
+	}
+	return nil, fmt.Errorf("unknown visited set %q, expected one of %v, %v or %v", o.VisitedSet, options.VisitedSetExact, options.VisitedSetBloom, options.VisitedSetDisk)
+}
+
+// ExactVisitedSet is a VisitedSet that keeps every url in memory. It never reports false positives, its memory grows
+// with every url.
+type ExactVisitedSet struct {
+	urls map[string]struct{}
+	size int64
+}
+
+// NewExactVisitedSet creates an empty exact visited set.
+func NewExactVisitedSet() *ExactVisitedSet {
+	return &ExactVisitedSet{urls: make(map[string]struct{})}
+}
+
+// Add implements VisitedSet.
+func (s *ExactVisitedSet) Add(url string) (bool, error) {
+	if _, visited := s.urls[url]; visited {
+		return false, nil
+	}
+	s.urls[url] = struct{}{}
+	s.size += int64(len(url)) + exactURLOverhead
+	return true, nil
+}
+
+// Len implements VisitedSet.
+func (s *ExactVisitedSet) Len() int { return len(s.urls) }
+
+// SizeBytes implements VisitedSet.
+func (s *ExactVisitedSet) SizeBytes() int64 { return s.size }
+
+// FalsePositiveRate implements VisitedSet, an exact set has none.
+func (s *ExactVisitedSet) FalsePositiveRate() float64 { return 0 }
+
+// MarshalBinary implements VisitedSet. The urls are stored sorted, one per line.
+func (s *ExactVisitedSet) MarshalBinary() ([]byte, error) {
+	urls := make([]string, 0, len(s.urls))
+	for url := range s.urls {
+		urls = append(urls, url)
+	}
+	sort.Strings(urls)
+	var data bytes.Buffer
+	for _, url := range urls {
+		data.WriteString(url)
+		data.WriteByte('\n')
+	}
+	return data.Bytes(), nil
+}
+
+// UnmarshalBinary implements VisitedSet, the urls are added to the set.
+func (s *ExactVisitedSet) UnmarshalBinary(data []byte) error {
+	for _, url := range bytes.Split(data, []byte("\n")) {
+		if len(url) != 0 {
+			s.Add(string(url))
+		}
+	}
+	return nil
+}
+
+// Close implements VisitedSet.
+func (s *ExactVisitedSet) Close() error { return nil }
+
+// BloomVisitedSet is a VisitedSet backed by a Bloom filter. Its memory is fixed when it is created, in exchange it
+// may report a url that has not been visited as visited, which skips the url. The false positive rate rises once more
+// urls than its capacity are added.
+type BloomVisitedSet struct {
+	bits   []uint64
+	m      uint64
+	k      uint64
+	length int
+}
+
+// NewBloomVisitedSet creates a Bloom filter sized for the capacity at the false positive rate.
+func NewBloomVisitedSet(capacity int, falsePositiveRate float64) (*BloomVisitedSet, error) {
+	if capacity <= 0 {
+		return nil, fmt.Errorf("bloom visited set capacity must be greater than 0, got %v", capacity)
+	}
+	if falsePositiveRate <= 0 || falsePositiveRate >= 1 {
+		return nil, fmt.Errorf("bloom visited set false positive rate must be between 0 and 1, got %v", falsePositiveRate)
+	}
+	m := uint64(math.Ceil(-float64(capacity) * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2)))
+	k := uint64(math.Max(1, math.Round(float64(m)/float64(capacity)*math.Ln2)))
+	return &BloomVisitedSet{bits: make([]uint64, (m+63)/64), m: m, k: k}, nil
+}
+
+// Add implements VisitedSet.
+func (s *BloomVisitedSet) Add(url string) (bool, error) {
+	h1, h2 := hashURL(url)
+	added := false
+	for i := uint64(0); i < s.k; i++ {
+		bit := (h1 + i*h2) % s.m
+		if s.bits[bit/64]&(1<<(bit%64)) == 0 {
+			s.bits[bit/64] |= 1 << (bit % 64)
+			added = true
+		}
+	}
+	if added {
+		s.length++
+	}
+	return added, nil
+}
+
+// Len implements VisitedSet.
+func (s *BloomVisitedSet) Len() int { return s.length }
+
+// SizeBytes implements VisitedSet.
+func (s *BloomVisitedSet) SizeBytes() int64 { return int64(len(s.bits)) * 8 }
+
+// FalsePositiveRate implements VisitedSet, estimated from the number of urls added.
+func (s *BloomVisitedSet) FalsePositiveRate() float64 {
+	return math.Pow(1-math.Exp(-float64(s.k)*float64(s.length)/float64(s.m)), float64(s.k))
+}
+
+// MarshalBinary implements VisitedSet.
+func (s *BloomVisitedSet) MarshalBinary() ([]byte, error) {
+	var data bytes.Buffer
+	binary.Write(&data, binary.BigEndian, []uint64{s.m, s.k, uint64(s.length)})
+	binary.Write(&data, binary.BigEndian, s.bits)
+	return data.Bytes(), nil
+}
+
+// UnmarshalBinary implements VisitedSet, the set is replaced by the saved filter.
+func (s *BloomVisitedSet) UnmarshalBinary(data []byte) error {
+	header := make([]uint64, 3)
+	reader := bytes.NewReader(data)
+	if err := binary.Read(reader, binary.BigEndian, header); err != nil {
+		return fmt.Errorf("invalid bloom visited set: %w", err)
+	}
+	m, k, length := header[0], header[1], header[2]
+	if m == 0 || uint64(reader.Len()) != (m+63)/64*8 {
+		return errors.New("invalid bloom visited set: size does not match")
+	}
+	bits := make([]uint64, (m+63)/64)
+	if err := binary.Read(reader, binary.BigEndian, bits); err != nil {
+		return fmt.Errorf("invalid bloom visited set: %w", err)
+	}
+	s.bits, s.m, s.k, s.length = bits, m, k, int(length)
+	return nil
+}
+
+// Close implements VisitedSet.
+func (s *BloomVisitedSet) Close() error { return nil }
+
+// DiskVisitedSet is a VisitedSet that keeps a 64 bit fingerprint of every url in a hash table in a temporary file, so
+// its memory stays constant however many urls are visited. Two urls with the same fingerprint are reported as the
+// same url, which is practically impossible below billions of urls.
+type DiskVisitedSet struct {
+	dir    string
+	file   *os.File
+	slots  uint64
+	length int
+
+	// probe holds the block of slots read by the last probe.
+	probe []byte
+}
+
+// NewDiskVisitedSet creates a disk visited set in a temporary file in the directory, the default temporary directory
+// if empty. The file is removed by Close.
+func NewDiskVisitedSet(dir string) (*DiskVisitedSet, error) {
+	s := &DiskVisitedSet{dir: dir, probe: make([]byte, diskSetProbeSlots*8)}
+	if err := s.create(diskSetMinSlots); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// create replaces the file of the set with an empty table of the number of slots.
+func (s *DiskVisitedSet) create(slots uint64) error {
+	file, err := os.CreateTemp(s.dir, "visited-*.db")
+	if err != nil {
+		return err
+	}
+	if err := file.Truncate(int64(slots) * 8); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return err
+	}
+	s.file, s.slots, s.length = file, slots, 0
+	return nil
+}
+
+// Add implements VisitedSet.
+func (s *DiskVisitedSet) Add(url string) (bool, error) {
+	fingerprint, _ := hashURL(url)
+	added, err := s.add(fingerprint)
+	if err != nil {
+		return false, fmt.Errorf("unable to add url to disk visited set: %w", err)
+	}
+	return added, nil
+}
+
+// add adds the fingerprint to the table using linear probing, growing the table once it is half full. Slots are read
+// a block at a time.
+func (s *DiskVisitedSet) add(fingerprint uint64) (bool, error) {
+	// 0 marks an empty slot.
+	fingerprint |= 1
+	// start and end are the first and last slot read into the probe block, plus one.
+	var start, end uint64
+	for i := fingerprint % s.slots; ; i = (i + 1) % s.slots {
+		if i < start || i >= end {
+			start, end = i-i%diskSetProbeSlots, i-i%diskSetProbeSlots+diskSetProbeSlots
+			if end > s.slots {
+				end = s.slots
+			}
+			if _, err := s.file.ReadAt(s.probe[:(end-start)*8], int64(start)*8); err != nil {
+				return false, err
+			}
+		}
+		slot := s.probe[(i-start)*8 : (i-start+1)*8]
+		switch binary.BigEndian.Uint64(slot) {
+		case fingerprint:
+			return false, nil
+		case 0:
+			binary.BigEndian.PutUint64(slot, fingerprint)
+			if _, err := s.file.WriteAt(slot, int64(i)*8); err != nil {
+				return false, err
+			}
+			s.length++
+			if uint64(s.length)*2 > s.slots {
+				return true, s.grow()
+			}
+			return true, nil
+		}
+	}
+}
+
+// eachFingerprint calls fn with every fingerprint of the table of the number of slots in the file. The table is read
+// a chunk at a time.
+func eachFingerprint(file *os.File, slots uint64, fn func(fingerprint uint64) error) error {
+	chunk := make([]byte, diskSetChunkSlots*8)
+	for start := uint64(0); start < slots; start += diskSetChunkSlots {
+		n := slots - start
+		if n > diskSetChunkSlots {
+			n = diskSetChunkSlots
+		}
+		if _, err := file.ReadAt(chunk[:n*8], int64(start)*8); err != nil {
+			return err
+		}
+		for i := uint64(0); i < n; i++ {
+			if fingerprint := binary.BigEndian.Uint64(chunk[i*8:]); fingerprint != 0 {
+				if err := fn(fingerprint); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// grow doubles the table, rehashing every fingerprint into a new file.
+func (s *DiskVisitedSet) grow() error {
+	old, oldSlots := s.file, s.slots
+	if err := s.create(s.slots * 2); err != nil {
+		return err
+	}
+	defer func() {
+		old.Close()
+		os.Remove(old.Name())
+	}()
+	return eachFingerprint(old, oldSlots, func(fingerprint uint64) error {
+		_, err := s.add(fingerprint)
+		return err
+	})
+}
+
+// Len implements VisitedSet.
+func (s *DiskVisitedSet) Len() int { return s.length }
+
+// SizeBytes implements VisitedSet, the size of the file.
+func (s *DiskVisitedSet) SizeBytes() int64 { return int64(s.slots) * 8 }
+
+// FalsePositiveRate implements VisitedSet, the probability that the fingerprint of a new url is already taken.
+func (s *DiskVisitedSet) FalsePositiveRate() float64 { return float64(s.length) / math.Pow(2, 63) }
+
+// MarshalBinary implements VisitedSet, the fingerprints are stored.
+func (s *DiskVisitedSet) MarshalBinary() ([]byte, error) {
+	data := make([]byte, 0, s.length*8)
+	err := eachFingerprint(s.file, s.slots, func(fingerprint uint64) error {
+		data = binary.BigEndian.AppendUint64(data, fingerprint)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// UnmarshalBinary implements VisitedSet, the saved fingerprints are added to the set.
+func (s *DiskVisitedSet) UnmarshalBinary(data []byte) error {
+	if len(data)%8 != 0 {
+		return errors.New("invalid disk visited set: size does not match")
+	}
+	for i := 0; i < len(data); i += 8 {
+		if _, err := s.add(binary.BigEndian.Uint64(data[i:])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Close implements VisitedSet, the file of the set is removed.
+func (s *DiskVisitedSet) Close() error {
+	err := s.file.Close()
+	os.Remove(s.file.Name())
+	return err
+}
+
+// hashURL returns two independent 64 bit hashes of the url. The second hash is the step of the double hashing of the
+// Bloom filter, it is odd so that it is never 0, which would set the same bit k times.
+func hashURL(url string) (uint64, uint64) {
+	h := fnv.New128a()
+	h.Write([]byte(url))
+	sum := h.Sum(nil)
+	return mix(binary.BigEndian.Uint64(sum[:8])), mix(binary.BigEndian.Uint64(sum[8:])) | 1
+}
+
+// mix spreads every bit of the hash over all of its bits. The low bits of an FNV hash barely depend on the end of the
+// input, urls that only differ in their last characters would otherwise land in neighbouring slots and bits.
+func mix(h uint64) uint64 {
+	h ^= h >> 33
+	h *= 0xff51afd7ed558ccd
+	h ^= h >> 33
+	h *= 0xc4ceb9fe1a85ec53
+	h ^= h >> 33
+	return h
+}
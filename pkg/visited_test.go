@@ -0,0 +1,157 @@
+package webcrawler
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+
+	options "github.com/cody6750/web-crawler/pkg/options"
+)
+
+func Test_VisitedSet(t *testing.T) {
+	tests := []struct {
+		name       string
+		visitedSet string
+		urls       int
+		maxFPR     float64
+	}{
+		{name: "Exact", visitedSet: options.VisitedSetExact, urls: 1000},
+		{name: "Bloom", visitedSet: options.VisitedSetBloom, urls: 1000, maxFPR: 0.03},
+		// More urls than the initial slots of the table, so that it grows.
+		{name: "Disk", visitedSet: options.VisitedSetDisk, urls: diskSetMinSlots, maxFPR: 1e-9},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := options.New()
+			o.VisitedSet = tt.visitedSet
+			o.VisitedSetCapacity = tt.urls
+			o.VisitedSetFalsePositiveRate = 0.01
+			o.VisitedSetDir = t.TempDir()
+			set, err := newVisitedSet(o)
+			if err != nil {
+				t.Fatalf("newVisitedSet() error = %v", err)
+			}
+			defer set.Close()
+
+			var falsePositives int
+			for i := 0; i < tt.urls; i++ {
+				added, err := set.Add(fmt.Sprintf("https://www.example.com/%v", i))
+				if err != nil {
+					t.Fatalf("VisitedSet.Add() error = %v", err)
+				}
+				if !added {
+					falsePositives++
+				}
+			}
+			for i := 0; i < tt.urls; i++ {
+				if added, _ := set.Add(fmt.Sprintf("https://www.example.com/%v", i)); added {
+					t.Fatalf("VisitedSet.Add() visited url %v added again", i)
+				}
+			}
+			if got := float64(falsePositives) / float64(tt.urls); got > tt.maxFPR {
+				t.Errorf("VisitedSet.Add() false positive rate = %v, want at most %v", got, tt.maxFPR)
+			}
+			if got := set.FalsePositiveRate(); got > tt.maxFPR {
+				t.Errorf("VisitedSet.FalsePositiveRate() = %v, want at most %v", got, tt.maxFPR)
+			}
+			if set.Len() != tt.urls-falsePositives || set.SizeBytes() <= 0 {
+				t.Errorf("VisitedSet.Len() = %v, SizeBytes() = %v, want %v and a size", set.Len(), set.SizeBytes(), tt.urls-falsePositives)
+			}
+
+			data, err := set.MarshalBinary()
+			if err != nil {
+				t.Fatalf("VisitedSet.MarshalBinary() error = %v", err)
+			}
+			restored, err := newVisitedSet(o)
+			if err != nil {
+				t.Fatalf("newVisitedSet() error = %v", err)
+			}
+			defer restored.Close()
+			if err := restored.UnmarshalBinary(data); err != nil {
+				t.Fatalf("VisitedSet.UnmarshalBinary() error = %v", err)
+			}
+			if added, _ := restored.Add("https://www.example.com/0"); restored.Len() != set.Len() || added {
+				t.Errorf("VisitedSet.UnmarshalBinary() len = %v, want %v and visited urls", restored.Len(), set.Len())
+			}
+		})
+	}
+}
+
+func TestDiskVisitedSet_AddError(t *testing.T) {
+	set, err := NewDiskVisitedSet(t.TempDir())
+	if err != nil {
+		t.Fatalf("NewDiskVisitedSet() error = %v", err)
+	}
+	set.file.Close()
+	defer os.Remove(set.file.Name())
+	if added, err := set.Add("https://www.example.com/"); err == nil || added {
+		t.Errorf("DiskVisitedSet.Add() = %v, %v, want an error once the file cannot be read", added, err)
+	}
+}
+
+func Test_hashURL(t *testing.T) {
+	for i := 0; i < 1000; i++ {
+		if _, h2 := hashURL(fmt.Sprintf("https://www.example.com/%v", i)); h2%2 == 0 {
+			t.Fatalf("hashURL() second hash = %v, want an odd step for double hashing", h2)
+		}
+	}
+}
+
+func Test_newVisitedSet(t *testing.T) {
+	tests := []struct {
+		name              string
+		visitedSet        string
+		capacity          int
+		falsePositiveRate float64
+		wantErr           bool
+	}{
+		{name: "Exact", visitedSet: options.VisitedSetExact, wantErr: false},
+		{name: "Bloom", visitedSet: options.VisitedSetBloom, capacity: 100, falsePositiveRate: 0.01, wantErr: false},
+		{name: "Bloom without capacity", visitedSet: options.VisitedSetBloom, falsePositiveRate: 0.01, wantErr: true},
+		{name: "Bloom with invalid false positive rate", visitedSet: options.VisitedSetBloom, capacity: 100, falsePositiveRate: 1, wantErr: true},
+		{name: "Unknown visited set", visitedSet: "cuckoo", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := options.New()
+			o.VisitedSet = tt.visitedSet
+			o.VisitedSetCapacity = tt.capacity
+			o.VisitedSetFalsePositiveRate = tt.falsePositiveRate
+			set, err := newVisitedSet(o)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newVisitedSet() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if set != nil {
+				set.Close()
+			}
+		})
+	}
+}
+
+func TestWebCrawler_CrawlVisitedSet(t *testing.T) {
+	site := newTestSite(t, map[string]string{
+		"/":  `<a href="/a">a</a><a href="/b">b</a><a href="/a">a</a><a href="/">home</a>`,
+		"/a": `<a href="/">home</a>`,
+		"/b": `<a href="/">home</a>`,
+	})
+
+	for _, visitedSet := range []string{options.VisitedSetExact, options.VisitedSetBloom, options.VisitedSetDisk} {
+		t.Run(visitedSet, func(t *testing.T) {
+			o := options.New()
+			o.CrawlDelay = 0
+			o.AllowEmptyItem = true
+			o.MaxDepth = 2
+			o.VisitedSet = visitedSet
+			o.VisitedSetDir = t.TempDir()
+			got, err := NewWithOptions(o).CrawlContext(context.Background(), site.URL+"/", nil)
+			if err != nil {
+				t.Fatalf("WebCrawler.CrawlContext() error = %v", err)
+			}
+			want := VisitedSetStats{Type: visitedSet, Len: 3}
+			if got.Metrics.UrlsVisited != 3 || got.Metrics.VisitedSet.Type != want.Type || got.Metrics.VisitedSet.Len != want.Len || got.Metrics.VisitedSet.SizeBytes <= 0 {
+				t.Errorf("WebCrawler.CrawlContext() urls visited = %v, visited set = %+v, want 3 and %+v", got.Metrics.UrlsVisited, got.Metrics.VisitedSet, want)
+			}
+		})
+	}
+}
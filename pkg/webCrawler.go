@@ -1,9 +1,10 @@
 package webcrawler
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io/ioutil"
 	"runtime"
 	"strings"
 	"sync"
@@ -22,14 +23,54 @@ import (
 type Metrics struct {
 	URL                 string
 	DuplicatedUrlsFound int
+	InvalidUrlsFound    int
+	DisallowedUrlsFound int
+	OutOfScopeUrlsFound int
+	OverBudgetUrlsFound int
+	SitemapUrlsFound    int
+	FeedUrlsFound       int
 	UrlsFound           int
 	UrlsVisited         int
 	ItemsFound          int
+	Retries             int
+	FailedUrls          int
+	BytesDownloaded     int64
+
+	// HostRates holds the current request rate of every crawled host, keyed by scheme and host.
+	HostRates map[string]HostRate
+
+	// VisitedSet describes the visited set of the crawl, see Options.VisitedSet.
+	VisitedSet VisitedSetStats
+
+	// Seeds holds the metrics of every seed of the crawl, keyed by seed url.
+	Seeds map[string]SeedMetrics
+}
+
+// HostRate represents the rate at which the web crawler currently requests a host.
+type HostRate struct {
+	// Delay is the current delay between requests to the host.
+	Delay time.Duration
+
+	// RequestsPerSecond is the request rate that corresponds to Delay, 0 if the host is not delayed.
+	RequestsPerSecond float64
+
+	// Latency is the moving average of the response latency of the host.
+	Latency time.Duration
+
+	// Throttled is the number of 429 and 503 responses of the host.
+	Throttled int
 }
 
 //Web Crawler represents all dependencies required to initialize the web crawler.
 type WebCrawler struct {
 
+	// ctx is derived from the context passed to CrawlContext. It is cancelled once the crawl has finished, has
+	// failed or the parent context is done, which signals every go routine of the web crawler to exit.
+	ctx context.Context
+
+	// cancel cancels ctx.
+	cancel context.CancelFunc
+
 	// errs is used to return errors that occur during the concurrent execution of the web scrapers
 	// between the go routines.
 	errs chan error
@@ -56,8 +97,27 @@ type WebCrawler struct {
 	// specific webscraper workers.
 	webScrapers map[int]*webscraper.WebScraper
 
-	// visited used to keep track of all of the visited urls between the web scraper workers.
-	visited map[string]struct{}
+	// state keeps track of all of the visited urls between the web scraper workers, along with the frontier of urls
+	// still to be crawled.
+	state *crawlState
+
+	// robots caches the robots.txt restrictions of every host that is crawled.
+	robots *robotsCache
+
+	// scope decides which urls belong to the crawl.
+	scope *scope
+
+	// scheduler enforces the politeness of the web crawler per host, it feeds the urlsToCrawl channel.
+	scheduler *hostScheduler
+
+	// hostUsages tracks the per host budgets of the crawl, keyed by scheme and host.
+	hostUsages map[string]*hostUsage
+
+	// stopReason is the reason of the first exceeded crawl budget.
+	stopReason StopReason
+
+	// events receives the events of the current crawl, see CrawlStream.
+	events chan<- *Event
 
 	// metrics represents all exposed metrics by the web crawler.
 	metrics Metrics
@@ -71,12 +131,61 @@ type WebCrawler struct {
 	//mapLock used to block actions on the metrics object.
 	metricsLock sync.Mutex
 
+	// budgetLock used to block actions on the host usages and the stop reason.
+	budgetLock sync.Mutex
+
+	// fetcherLock used to create the Fetcher once.
+	fetcherLock sync.Mutex
+
+	// stateStoreLock used to create the StateStore once.
+	stateStoreLock sync.Mutex
+
+	// feedStates holds the publish date of the newest entry crawled of every feed, keyed by feed url. They are saved to
+	// the FeedStateStore once the crawl has stopped, so that the entries that were not crawled are not skipped.
+	feedStates map[string]time.Time
+
+	// feedLock used to block actions on the feed states.
+	feedLock sync.Mutex
+
+	// checkpointSaved is set once a full checkpoint of the crawl was saved, appendedCheckpoints counts the deltas
+	// appended since.
+	checkpointSaved     bool
+	appendedCheckpoints int
+
+	// checkpointLock used to save one checkpoint at a time.
+	checkpointLock sync.Mutex
+
 	//wg used to wait for channels in the web crawler.
 	wg sync.WaitGroup
 
+	// scrapeWg used to wait for all in flight scrapes to finish before the channels are drained.
+	scrapeWg sync.WaitGroup
+
+	// tasksWg used to wait for the go routines that send urls to the crawl or checkpoint it, before the final
+	// checkpoint is saved and the visited set is closed.
+	tasksWg sync.WaitGroup
+
 	//Logger used to log.
 	Logger *logrus.Logger
 
+	// Fetcher executes the http requests of the web crawler. When nil, an HTTPFetcher is created from
+	// Options.Transport on the first crawl and reused by every following crawl. Share a Fetcher between web crawlers
+	// to share their connections.
+	Fetcher webscraper.Fetcher
+
+	// StateStore persists checkpoints of the crawl every Options.CheckpointInterval and once the crawl has stopped, so
+	// that it can be resumed by Resume. When nil, a FileStateStore is created in Options.CheckpointDir, if set,
+	// otherwise checkpointing is disabled.
+	StateStore StateStore
+
+	// CrawlID identifies the checkpoints of the crawl in the StateStore. When empty and checkpointing is enabled, a
+	// random ID is generated once the crawl starts. It is returned in the crawl response.
+	CrawlID string
+
+	// OnEvent, when set, is called by Crawl and CrawlContext for every event of the crawl as it happens. It is called
+	// from a single go routine and blocks the crawl until it returns.
+	OnEvent func(*Event)
+
 	// session established a session with AWS. Requires AWS to be configured on the
 	// machine. The session is created through initAWS which is set using options.AWSMaxRetries
 	// and options.AWSRegion or AWS_MAX_RETRIES and AWS_REGION environent variables.
@@ -85,16 +194,19 @@ type WebCrawler struct {
 	// s3Svc establishes a session with AWS S3 manager using the AWS session.
 	// Allows us to upload files to S3.
 	s3Svc *s3manager.Uploader
-
-	//webScraperResponses represents the aggregated responses from all web scrapers. Returned to the end user in
-	// the web crawler response
-	webScraperResponses []*webscraper.Response
 }
 
 //Response represents the response the web crawler returns to the end user.
 type Response struct {
 	WebScraperResponses []*webscraper.Response
+	Errors              []*CrawlError
 	Metrics             *Metrics
+
+	// StopReason explains why the crawl ended, for example which crawl budget was exceeded.
+	StopReason StopReason
+
+	// CrawlID identifies the checkpoints of the crawl, empty if checkpointing is disabled.
+	CrawlID string
 }
 
 //NewCrawler initializes a web crawler using the default options.
@@ -115,24 +227,50 @@ func NewWithOptions(options *options.Options) *WebCrawler {
 	return wc
 }
 
-// init intializes all required channels and objects for the web crawler. It sets all of the
-// robots.txt restrictions as well.
-func (wc *WebCrawler) init(url string) error {
+// init intializes all required channels and objects for the web crawler.
+func (wc *WebCrawler) init(ctx context.Context) error {
+	visited, err := newVisitedSet(wc.Options)
+	if err != nil {
+		return err
+	}
+	wc.ctx, wc.cancel = context.WithCancel(ctx)
 	wc.pendingUrlsToCrawlCount = make(chan int)
 	wc.pendingUrlsToCrawl = make(chan *webscraper.URL)
 	wc.collectWebScraperResponse = make(chan *webscraper.Response)
 	wc.errs = make(chan error)
 	wc.urlsToCrawl = make(chan *webscraper.URL)
 	wc.stop = make(chan struct{}, 30)
-	wc.visited = make(map[string]struct{})
+	wc.robots = newRobotsCache()
+	wc.scheduler = newHostScheduler(wc)
 	wc.webScrapers = make(map[int]*webscraper.WebScraper)
-	wc.wg = *new(sync.WaitGroup)
-	err := wc.initRobotsTxtRestrictions(url)
-	if err != nil {
-		wc.Logger.WithField("URL: ", url).Info("robots.txt does not exist for website")
-	}
+	wc.budgetLock.Lock()
+	wc.hostUsages = make(map[string]*hostUsage)
+	wc.stopReason = ""
+	wc.budgetLock.Unlock()
+	wc.feedLock.Lock()
+	wc.feedStates = make(map[string]time.Time)
+	wc.feedLock.Unlock()
+	wc.checkpointLock.Lock()
+	wc.checkpointSaved, wc.appendedCheckpoints = false, 0
+	wc.checkpointLock.Unlock()
+	wc.wg = sync.WaitGroup{}
+	wc.scrapeWg = sync.WaitGroup{}
+	wc.tasksWg = sync.WaitGroup{}
+	wc.metricsLock.Lock()
+	wc.metrics = Metrics{}
+	wc.state = newCrawlState(wc.Options.VisitedSet, visited)
+	wc.metricsLock.Unlock()
 	return nil
+}
 
+// fetcher returns the fetcher of the web crawler, creating it from Options.Transport if none is set.
+func (wc *WebCrawler) fetcher() webscraper.Fetcher {
+	wc.fetcherLock.Lock()
+	defer wc.fetcherLock.Unlock()
+	if wc.Fetcher == nil {
+		wc.Fetcher = webscraper.NewHTTPFetcher(wc.Options.Transport)
+	}
+	return wc.Fetcher
 }
 
 // initAWS creates the required AWS session and services.
@@ -151,29 +289,282 @@ func (wc *WebCrawler) initAWS(maxRetries int, region string) {
 // results are aggregated from all of the web scraper workers into a single web crawler response which is returned to the
 // end user.
 func (wc *WebCrawler) Crawl(url string, itemsToget []webscraper.ScrapeItemConfig, urlsToGet ...webscraper.ScrapeURLConfig) (*Response, error) {
-	wc.Logger.WithField("url", url).Info("Starting to crawl url")
-	wgDone := make(chan bool)
-	err := wc.init(url)
+	return wc.CrawlContext(context.Background(), url, itemsToget, urlsToGet...)
+}
+
+// CrawlContext is the context aware version of Crawl. Once the context is cancelled or its deadline is exceeded, every
+// web scraper worker is stopped, in flight http requests are aborted and the channels are drained. The partially
+// aggregated response is returned along with the context error. CrawlContext collects the events of CrawlStream.
+func (wc *WebCrawler) CrawlContext(ctx context.Context, url string, itemsToget []webscraper.ScrapeItemConfig, urlsToGet ...webscraper.ScrapeURLConfig) (*Response, error) {
+	return wc.CrawlSeedsContext(ctx, []Seed{{URL: url}}, itemsToget, urlsToGet...)
+}
+
+// CrawlSeeds crawls every seed like Crawl within a single crawl, sharing its workers, visited urls and budgets. The
+// metrics of every seed are returned in Metrics.Seeds.
+func (wc *WebCrawler) CrawlSeeds(seeds []Seed, itemsToget []webscraper.ScrapeItemConfig, urlsToGet ...webscraper.ScrapeURLConfig) (*Response, error) {
+	return wc.CrawlSeedsContext(context.Background(), seeds, itemsToget, urlsToGet...)
+}
 
+// CrawlSeedsContext is the context aware version of CrawlSeeds, see CrawlContext.
+func (wc *WebCrawler) CrawlSeedsContext(ctx context.Context, seeds []Seed, itemsToget []webscraper.ScrapeItemConfig, urlsToGet ...webscraper.ScrapeURLConfig) (*Response, error) {
+	events, err := wc.CrawlSeedsStream(ctx, seeds, itemsToget, urlsToGet...)
+	if err != nil {
+		return nil, err
+	}
+	return wc.collect(events)
+}
+
+// Resume resumes the crawl with the crawl ID from its last checkpoint in the StateStore. Urls that have already been
+// visited are not crawled again, the metrics carry on from the checkpoint. The responses of the urls crawled before
+// the checkpoint are not part of the response.
+func (wc *WebCrawler) Resume(crawlID string) (*Response, error) {
+	return wc.ResumeContext(context.Background(), crawlID)
+}
+
+// ResumeContext is the context aware version of Resume, see CrawlContext.
+func (wc *WebCrawler) ResumeContext(ctx context.Context, crawlID string) (*Response, error) {
+	events, err := wc.ResumeStream(ctx, crawlID)
+	if err != nil {
+		return nil, err
+	}
+	return wc.collect(events)
+}
+
+// ResumeStream is the streaming version of Resume, see CrawlStream. Returns ErrCrawlNotFound if there is no checkpoint
+// for the crawl ID.
+func (wc *WebCrawler) ResumeStream(ctx context.Context, crawlID string) (<-chan *Event, error) {
+	store, err := wc.stateStore()
+	if err != nil {
+		return nil, err
+	}
+	if store == nil {
+		return nil, fmt.Errorf("unable to resume crawl %v, checkpointing is disabled", crawlID)
+	}
+	checkpoint, err := store.Load(crawlID)
 	if err != nil {
-		wc.Logger.WithError(err).Error("cannot initialize crawler")
 		return nil, err
 	}
+	if checkpoint.VisitedSet != wc.Options.VisitedSet {
+		return nil, fmt.Errorf("unable to resume crawl %v, it was checkpointed with visited set %v, not %v", crawlID, checkpoint.VisitedSet, wc.Options.VisitedSet)
+	}
+	wc.Logger.WithFields(logrus.Fields{"crawl": crawlID, "frontier": len(checkpoint.Frontier) + len(checkpoint.Pending), "visited": checkpoint.Metrics.VisitedSet.Len}).Info("Resuming crawl from checkpoint")
+	return wc.stream(ctx, checkpoint, true)
+}
 
+// collect aggregates the events of a crawl into a single response and writes it to S3 if enabled.
+func (wc *WebCrawler) collect(events <-chan *Event) (*Response, error) {
+	var err error
+	response := &Response{CrawlID: wc.CrawlID}
+	for event := range events {
+		if wc.OnEvent != nil {
+			wc.OnEvent(event)
+		}
+		switch event.Type {
+		case EventResponse:
+			response.WebScraperResponses = append(response.WebScraperResponses, event.Response)
+		case EventFailed:
+			response.Errors = append(response.Errors, event.Error)
+		case EventDone:
+			response.Metrics = event.Metrics
+			response.StopReason = event.StopReason
+			err = event.Err
+		}
+	}
+	if err != nil {
+		return response, err
+	}
+
+	if wc.Options.AWSWriteOutputToS3 {
+		out, err := json.Marshal(response)
+		if err != nil {
+			wc.Logger.WithError(err).Error("Unable to marshal json")
+			return response, err
+		}
+		outputFile := services.GenerateFileName("crawl_results", ".json")
+		err = services.WriteToS3(wc.s3Svc, strings.NewReader(string(out)), wc.Options.AWSS3Bucket, outputFile, "")
+		if err != nil {
+			wc.Logger.WithError(err).Error("Unable to upload file to S3")
+			return response, err
+		}
+		wc.Logger.WithField("output File", outputFile).Info("Successfully uploaded file to S3")
+
+	}
+	return response, nil
+}
+
+// CrawlStream crawls the url like CrawlContext, but instead of aggregating the results it returns a channel that
+// delivers every web scraper response and every extracted item as soon as they are scraped, along with a progress
+// event for every crawled url. The last event on the
+// channel is an EventDone event that holds the final metrics, the reason the crawl ended and the error that ended the
+// crawl, if any, after which the channel is closed. The channel must be read until it is closed, otherwise the crawl blocks.
+func (wc *WebCrawler) CrawlStream(ctx context.Context, url string, itemsToget []webscraper.ScrapeItemConfig, urlsToGet ...webscraper.ScrapeURLConfig) (<-chan *Event, error) {
+	return wc.CrawlSeedsStream(ctx, []Seed{{URL: url}}, itemsToget, urlsToGet...)
+}
+
+// CrawlSeedsStream is the streaming version of CrawlSeeds, see CrawlStream.
+func (wc *WebCrawler) CrawlSeedsStream(ctx context.Context, seeds []Seed, itemsToget []webscraper.ScrapeItemConfig, urlsToGet ...webscraper.ScrapeURLConfig) (<-chan *Event, error) {
+	if err := validateSeeds(seeds); err != nil {
+		return nil, err
+	}
+	checkpoint := &CrawlState{
+		CrawlID:    wc.CrawlID,
+		URL:        seeds[0].URL,
+		Seeds:      append([]Seed(nil), seeds...),
+		ItemsToGet: itemsToget,
+		URLsToGet:  urlsToGet,
+		VisitedSet: wc.Options.VisitedSet,
+	}
+	return wc.stream(ctx, checkpoint, false)
+}
+
+// stream validates the options and starts the crawl described by the checkpoint, resuming it if resume is set.
+func (wc *WebCrawler) stream(ctx context.Context, checkpoint *CrawlState, resume bool) (<-chan *Event, error) {
 	if wc.Options.MaxDepth < 0 {
 		return nil, fmt.Errorf("max depth is cannot be lower then 0. Current max depth: %v", wc.Options.MaxDepth)
 	}
 
-	//send initial URL
+	if err := validateCrawlOrder(wc.Options); err != nil {
+		return nil, err
+	}
+
+	if err := webscraper.ValidateScrapeConfigs(checkpoint.ItemsToGet, checkpoint.URLsToGet); err != nil {
+		return nil, err
+	}
+
+	scope, err := newScope(wc.Options)
+	if err != nil {
+		return nil, err
+	}
+	wc.scope = scope
+
+	store, err := wc.stateStore()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := store.(FeedStateStore); wc.Options.OnlyNewFeedEntries && len(wc.feedSeeds(checkpoint.Seeds)) > 0 && !ok {
+		return nil, errors.New("only new feed entries requires a state store that implements FeedStateStore, set a checkpoint dir")
+	}
+	if store != nil && checkpoint.CrawlID == "" {
+		if checkpoint.CrawlID, err = newCrawlID(); err != nil {
+			return nil, err
+		}
+	}
+	wc.CrawlID = checkpoint.CrawlID
+
+	events := make(chan *Event, wc.Options.WebScraperWorkerCount)
 	go func() {
-		wc.processScrapedUrls([]*webscraper.URL{{RootURL: url, CurrentURL: url, CurrentDepth: 0, MaxDepth: wc.Options.MaxDepth}})
+		defer close(events)
+		err := wc.crawl(ctx, events, store, *checkpoint, resume)
+		reason := wc.crawlStopReason(ctx, err)
+		if reason != StopReasonError {
+			wc.saveFeedStates(store)
+		}
+		metrics := wc.Metrics()
+		events <- &Event{Type: EventDone, Metrics: &metrics, StopReason: reason, Err: err}
+	}()
+	return events, nil
+}
+
+// crawl executes the crawl. It sets up all necessary channels needed to crawl, initializes all of the web scraper
+// workers and sends the initial url, or the frontier of the checkpoint if the crawl is resumed. Scraped responses are
+// forwarded to the events channel. If a state store is given, the crawl is checkpointed periodically and once it has
+// stopped. Returns once the crawl has finished, failed or the context is done, after every go routine of the crawl has
+// exited.
+func (wc *WebCrawler) crawl(ctx context.Context, events chan<- *Event, store StateStore, checkpoint CrawlState, resume bool) error {
+	url, itemsToget, urlsToGet := checkpoint.URL, checkpoint.ItemsToGet, checkpoint.URLsToGet
+	seeds := checkpoint.Seeds
+	if len(seeds) == 0 {
+		// Checkpoints saved before seeds were supported only have a url.
+		seeds = []Seed{{URL: url}}
+	}
+	wc.Logger.WithField("url", url).Info("Starting to crawl url")
+	wgDone := make(chan bool)
+	collectorDone := make(chan bool)
+
+	err := wc.init(ctx)
+	if err != nil {
+		wc.Logger.WithError(err).Error("cannot initialize crawler")
+		return err
+	}
+	wc.events = events
+	defer wc.cancel()
+	// Deferred first so that the visited set is closed after the final checkpoint.
+	defer func() {
+		if err := wc.state.close(); err != nil {
+			wc.Logger.WithError(err).Warn("Unable to close visited set")
+		}
+	}()
+
+	if wc.Options.MaxDuration > 0 {
+		timer := time.AfterFunc(wc.Options.MaxDuration, func() { wc.stopCrawl(StopReasonMaxDuration) })
+		defer timer.Stop()
+	}
+
+	if _, ok := store.(IncrementalStateStore); ok {
+		wc.state.trackChanges()
+	}
+
+	if resume {
+		if err := wc.state.restore(&checkpoint); err != nil {
+			wc.Logger.WithError(err).Error("cannot restore visited urls of checkpoint")
+			return err
+		}
+		wc.metricsLock.Lock()
+		wc.metrics = checkpoint.Metrics
+		wc.metricsLock.Unlock()
+		wc.restoreFeedStates(checkpoint.FeedStates)
+		wc.runTask(func() { wc.resumeFrontier(checkpoint.Frontier, checkpoint.Pending) })
+	} else {
+		wc.metricsLock.Lock()
+		wc.metrics.URL = url
+		wc.metricsLock.Unlock()
+		//send initial URLs
+		wc.runTask(func() {
+			// The seeds, sitemaps and feeds are pending until they have all been sent, so that the crawl does not
+			// finish early.
+			wc.updatePendingUrlsToCrawlCount(1)
+			defer wc.updatePendingUrlsToCrawlCount(-1)
+			var roots []*webscraper.URL
+			for _, seed := range seeds {
+				if seed.Feed {
+					continue
+				}
+				root := wc.seedRoot(seed)
+				roots = append(roots, root)
+				wc.processScrapedUrls([]*webscraper.URL{root})
+			}
+			if wc.Options.Sitemaps {
+				for _, root := range roots {
+					wc.processSitemaps(root)
+				}
+			}
+			if feeds := wc.feedSeeds(seeds); len(feeds) > 0 {
+				wc.processFeeds(store, feeds)
+			}
+		})
+	}
+
+	if store != nil {
+		wc.runTask(func() { wc.runCheckpoints(store, checkpoint) })
+		// The final checkpoint is saved once every go routine has exited, so that it is consistent.
+		defer wc.saveCheckpoint(store, checkpoint, true)
+	}
+	// Deferred last so that it runs first, the web scrapers have already been drained by then.
+	defer func() {
+		wc.cancel()
+		wc.tasksWg.Wait()
 	}()
 
-	go wc.processCrawledUrls()
+	wc.runTask(wc.processCrawledUrls)
+
+	go wc.scheduler.run()
 
 	go wc.monitorCrawling()
 
-	go wc.processSrapedResponse()
+	go func() {
+		wc.processSrapedResponse(events)
+		close(collectorDone)
+	}()
 
 	wc.Logger.WithField("Webscraper Count: ", wc.Options.WebScraperWorkerCount).Debug("Deploying webscrapers")
 	for i := 0; i < wc.Options.WebScraperWorkerCount; i++ {
@@ -185,62 +576,77 @@ func (wc *WebCrawler) Crawl(url string, itemsToget []webscraper.ScrapeItemConfig
 	}
 	wc.Logger.WithField("Webscraper Count: ", wc.Options.WebScraperWorkerCount).Debug("Successfully deployed webscrapers")
 
-	err = wc.readinessCheck()
-	if err != nil {
-		wc.Logger.WithError(err).Error("Readiness check failed")
-		return nil, err
-	}
-
-	go wc.livenessCheck()
-
 	go func() {
 		wc.Logger.Debug("Waiting for all scraper goroutines to finish scraping")
 		wc.wg.Wait()
 		close(wgDone)
 	}()
 
+	go func() {
+		if err := wc.readinessCheck(); err != nil {
+			wc.Logger.WithError(err).Error("Readiness check failed")
+			select {
+			case wc.errs <- err:
+			case <-wc.ctx.Done():
+			}
+			return
+		}
+		wc.livenessCheck()
+	}()
+
 	select {
 	case <-wgDone:
 		// carry on
 		break
 	case err := <-wc.errs:
-		return &Response{WebScraperResponses: wc.webScraperResponses, Metrics: &wc.metrics}, err
+		wc.drain(wgDone, collectorDone)
+		return err
 	}
+	wc.drain(wgDone, collectorDone)
 
-	response := &Response{WebScraperResponses: wc.webScraperResponses, Metrics: &wc.metrics}
-	if wc.Options.AWSWriteOutputToS3 {
-		out, err := json.Marshal(response)
-		if err != nil {
-			wc.Logger.WithError(err).Error("Unable to marshal json")
-			return response, err
-		}
-		outputFile := services.GenerateFileName("crawl_results", ".json")
-		err = services.WriteToS3(wc.s3Svc, strings.NewReader(string(out)), wc.Options.AWSS3Bucket, outputFile, "")
-		if err != nil {
-			wc.Logger.WithError(err).Error("Unable to upload file to S3")
-			return response, err
-		}
-		wc.Logger.WithField("output File", outputFile).Info("Successfully uploaded file to S3")
-
+	if ctx.Err() != nil {
+		wc.Logger.WithError(ctx.Err()).WithField("url", url).Warn("Crawl was cancelled, returning partial response")
+		return ctx.Err()
 	}
 	wc.Logger.WithField("url", url).Info("Finished crawling url")
-	return response, nil
+	return nil
+}
+
+// runTask runs the task in a go routine that is waited for before the crawl saves its final checkpoint.
+func (wc *WebCrawler) runTask(task func()) {
+	wc.tasksWg.Add(1)
+	go func() {
+		defer wc.tasksWg.Done()
+		task()
+	}()
+}
+
+// drain stops the web crawler and waits for every web scraper worker and in flight scrape to exit. Once no one can
+// send to the collectWebScraperResponse channel anymore, it is closed and the remaining responses are flushed.
+func (wc *WebCrawler) drain(wgDone, collectorDone chan bool) {
+	wc.cancel()
+	<-wgDone
+	wc.scrapeWg.Wait()
+	close(wc.collectWebScraperResponse)
+	<-collectorDone
 }
 
 // runWebScraper creates an instance of the web scraper, this represents a single web scraper worker. The web scraper
 // worker actively listens to the urlsToCrawl channels for urls and begins to scrape them for urls and items. This function
-// implements a variety of features which include delaying the crawl between urls, can restrict number of go routines runnning, ability
-// to stop scraping onces if the max number of urls are visited, and collects metrics.
+// implements a variety of features which include restricting the number of go routines runnning, ability to stop scraping
+// onces if the max number of urls are visited, and collects metrics. The delay between urls is enforced per host by the
+// scheduler, which is notified once the request to the url has finished.
 func (wc *WebCrawler) runWebScraper(scraperNumber int, itemsToget []webscraper.ScrapeItemConfig, urlsToGet ...webscraper.ScrapeURLConfig) (*webscraper.WebScraper, error) {
-	wg := new(sync.WaitGroup)
 	ws := &webscraper.WebScraper{
-		Logger:              wc.Logger,
-		ScraperNumber:       scraperNumber,
-		Stop:                wc.stop,
-		WaitGroup:           *wg,
-		BlackListedURLPaths: wc.Options.BlacklistedURLPaths,
-		HeaderKey:           wc.Options.HeaderKey,
-		HeaderValue:         wc.Options.HeaderValue,
+		Logger:                wc.Logger,
+		ScraperNumber:         scraperNumber,
+		Stop:                  wc.stop,
+		BlackListedURLPaths:   wc.Options.BlacklistedURLPaths,
+		HeaderKey:             wc.Options.HeaderKey,
+		HeaderValue:           wc.Options.HeaderValue,
+		RetryPolicy:           wc.Options.RetryPolicy,
+		Fetcher:               wc.fetcher(),
+		ExtractStructuredData: wc.Options.ExtractStructuredData,
 	}
 
 	wc.mapLock.Lock()
@@ -250,43 +656,148 @@ func (wc *WebCrawler) runWebScraper(scraperNumber int, itemsToget []webscraper.S
 		select {
 		// If there is a url to crawl, begin scraping concurrently
 		case url := <-wc.urlsToCrawl:
-			// Options: Delay duration between each crawl
-			if wc.Options.CrawlDelay != 0 {
-				time.Sleep(time.Second * time.Duration(wc.Options.CrawlDelay))
-			}
 			// Options: Set maximum amount of GoRoutines. Each webscraper deploys a gorotuine per each url in the channel.
 			if numGoRoutine := runtime.NumGoroutine(); numGoRoutine > wc.Options.MaxGoRoutines {
-				wc.urlsToCrawl <- url
+				wc.scheduler.release(url, 0)
+				wc.scheduler.submit(url)
 				return ws, fmt.Errorf("webscraper gorutines has supressed the max go routines. Current: %v Max: %v", numGoRoutine, wc.Options.MaxGoRoutines)
 			}
-			// Options: Ability to cap the number of urls scraped. Shared value between each webscraper.
-			if wc.Options.MaxVisitedUrls <= wc.metrics.UrlsVisited {
-				return ws, fmt.Errorf("url visited has supressed the max url visited. Current: %v Max: %v", wc.metrics.UrlsVisited, wc.Options.MaxVisitedUrls)
+			// Options: Ability to cap the number of urls scraped, items found and bytes downloaded. Shared values
+			// between each webscraper.
+			if reason := wc.checkBudgets(); reason != "" {
+				wc.scheduler.release(url, 0)
+				wc.updatePendingUrlsToCrawlCount(-1)
+				wc.stopCrawl(reason)
+				continue
 			}
 
 			// Begin scraping concurrently
-			ws.WaitGroup.Add(1)
+			wc.scrapeWg.Add(1)
 			go func() {
-				defer ws.WaitGroup.Done()
-				scrapeResponse, err := ws.Scrape(url, itemsToget, urlsToGet...)
+				defer wc.scrapeWg.Done()
+				defer wc.updatePendingUrlsToCrawlCount(-1)
+				start := time.Now()
+				scrapeResponse, err := ws.ScrapeContext(wc.ctx, url, itemsToget, urlsToGet...)
+				wc.incrementMetrics(&Metrics{Retries: scrapeResponse.Retries, BytesDownloaded: scrapeResponse.BytesDownloaded})
+				wc.incrementSeedMetrics(url, SeedMetrics{BytesDownloaded: scrapeResponse.BytesDownloaded})
+				wc.addHostBytes(url, scrapeResponse.BytesDownloaded)
+				var throttled *webscraper.ThrottledError
+				if errors.As(err, &throttled) {
+					// The url stays pending while it waits to be crawled again.
+					wc.updatePendingUrlsToCrawlCount(1)
+					if wc.scheduler.throttle(url, throttled.RetryAfter) {
+						wc.Logger.WithError(err).WithField("url", url.CurrentURL).Warn("Website is throttling, slowing down host")
+						return
+					}
+					wc.updatePendingUrlsToCrawlCount(-1)
+				} else if err != nil {
+					wc.scheduler.release(url, 0)
+				} else {
+					wc.scheduler.release(url, time.Since(start))
+				}
 				if err != nil {
-					wc.errs <- err
+					// Failed urls are recorded and the crawl carries on until the error budget is exceeded. Errors
+					// caused by the crawl being stopped are not reported.
+					if wc.ctx.Err() == nil {
+						wc.Logger.WithError(err).WithField("url", url.CurrentURL).Warn("Failed to crawl url")
+						wc.state.done(url)
+						wc.incrementMetrics(&Metrics{FailedUrls: 1})
+						wc.incrementSeedMetrics(url, SeedMetrics{FailedUrls: 1})
+						select {
+						case wc.events <- &Event{Type: EventFailed, Error: newCrawlError(url, scrapeResponse.StatusCode, err)}:
+						case <-wc.ctx.Done():
+							return
+						}
+						if err := wc.checkErrorBudget(); err != nil {
+							select {
+							case wc.errs <- err:
+							case <-wc.ctx.Done():
+							}
+						}
+					}
+					return
+				}
+				wc.incrementMetrics(&Metrics{UrlsFound: len(scrapeResponse.ExtractedURLs), UrlsVisited: 1, ItemsFound: len(scrapeResponse.ExtractedItem)})
+				wc.incrementSeedMetrics(url, SeedMetrics{UrlsFound: len(scrapeResponse.ExtractedURLs), UrlsVisited: 1, ItemsFound: len(scrapeResponse.ExtractedItem)})
+				wc.feedEntryCrawled(url)
+				if isSeed(url) {
+					wc.scope.seedRedirected(url.RootURL, scrapeResponse.PageURL)
+				}
+				metrics := wc.Metrics()
+				wc.Logger.Infof("Go routine:%v | Crawling url: %v | Current depth: %v | Url Visited: %v | Url Found : %v | Duplicate Url found: %v | Items Found: %v", scraperNumber, url.CurrentURL, url.CurrentDepth, metrics.UrlsVisited, metrics.UrlsFound, metrics.DuplicatedUrlsFound, metrics.ItemsFound)
+				event := &Event{Type: EventProgress, Progress: &Progress{
+					ScraperNumber: scraperNumber,
+					URL:           url.CurrentURL,
+					ParentURL:     url.ParentURL,
+					Depth:         url.CurrentDepth,
+					StatusCode:    scrapeResponse.StatusCode,
+					UrlsFound:     len(scrapeResponse.ExtractedURLs),
+					ItemsFound:    len(scrapeResponse.ExtractedItem),
+					Metrics:       metrics,
+				}}
+				select {
+				case wc.events <- event:
+				case <-wc.ctx.Done():
+					return
+				}
+				// Pages with structured data are kept even without items, when the structured data is extracted.
+				if !wc.Options.AllowEmptyItem && len(scrapeResponse.ExtractedItem) == 0 && scrapeResponse.StructuredData.IsEmpty() {
+					wc.state.done(url)
+					wc.stopIfBudgetExceeded()
+					return
 				}
-				wc.incrementMetrics(&Metrics{URL: url.RootURL, UrlsFound: len(scrapeResponse.ExtractedURLs), UrlsVisited: 1, ItemsFound: len(scrapeResponse.ExtractedItem)})
-				wc.Logger.Infof("Go routine:%v | Crawling url: %v | Current depth: %v | Url Visited: %v | Url Found : %v | Duplicate Url found: %v | Items Found: %v", scraperNumber, url.CurrentURL, url.CurrentDepth, wc.metrics.UrlsVisited, wc.metrics.UrlsFound, wc.metrics.DuplicatedUrlsFound, wc.metrics.ItemsFound)
-				if !wc.Options.AllowEmptyItem && len(scrapeResponse.ExtractedItem) == 0 {
+				select {
+				case wc.collectWebScraperResponse <- scrapeResponse:
+				case <-wc.ctx.Done():
 					return
 				}
-				wc.collectWebScraperResponse <- scrapeResponse
 				wc.processScrapedUrls(scrapeResponse.ExtractedURLs)
-				wc.pendingUrlsToCrawlCount <- -1
+				// The url stays in the frontier if the crawl was stopped before all of its urls were processed.
+				if wc.ctx.Err() == nil {
+					wc.state.done(url)
+				}
+				wc.stopIfBudgetExceeded()
 			}()
 
 		// Stop scraping, wait for all scrapes to finish before exiting function.
 		case <-ws.Stop:
 			return ws, nil
+		case <-wc.ctx.Done():
+			return ws, wc.ctx.Err()
+		}
+	}
+}
+
+// updatePendingUrlsToCrawlCount sends the change in the number of pending urls to the monitorCrawling go routine.
+// The update is dropped once the crawl has been stopped.
+func (wc *WebCrawler) updatePendingUrlsToCrawlCount(count int) {
+	select {
+	case wc.pendingUrlsToCrawlCount <- count:
+	case <-wc.ctx.Done():
+	}
+}
+
+// Metrics returns a snapshot of the metrics of the current crawl. It is safe to call while the crawl is running.
+func (wc *WebCrawler) Metrics() Metrics {
+	wc.metricsLock.Lock()
+	defer wc.metricsLock.Unlock()
+	metrics := wc.metrics
+	if wc.metrics.HostRates != nil {
+		metrics.HostRates = make(map[string]HostRate, len(wc.metrics.HostRates))
+		for host, rate := range wc.metrics.HostRates {
+			metrics.HostRates[host] = rate
 		}
 	}
+	if wc.metrics.Seeds != nil {
+		metrics.Seeds = make(map[string]SeedMetrics, len(wc.metrics.Seeds))
+		for seed, seedMetrics := range wc.metrics.Seeds {
+			metrics.Seeds[seed] = seedMetrics
+		}
+	}
+	if wc.state != nil {
+		metrics.VisitedSet = wc.state.stats()
+	}
+	return metrics
 }
 
 // incrementMetrics used to aggregate results from each web scraper worker and appends them to the existing metrics.
@@ -311,74 +822,186 @@ func (wc *WebCrawler) incrementMetrics(m *Metrics) *Metrics {
 	if m.DuplicatedUrlsFound != 0 {
 		wc.metrics.DuplicatedUrlsFound += m.DuplicatedUrlsFound
 	}
+
+	if m.InvalidUrlsFound != 0 {
+		wc.metrics.InvalidUrlsFound += m.InvalidUrlsFound
+	}
+
+	if m.DisallowedUrlsFound != 0 {
+		wc.metrics.DisallowedUrlsFound += m.DisallowedUrlsFound
+	}
+
+	if m.OutOfScopeUrlsFound != 0 {
+		wc.metrics.OutOfScopeUrlsFound += m.OutOfScopeUrlsFound
+	}
+
+	if m.OverBudgetUrlsFound != 0 {
+		wc.metrics.OverBudgetUrlsFound += m.OverBudgetUrlsFound
+	}
+
+	if m.SitemapUrlsFound != 0 {
+		wc.metrics.SitemapUrlsFound += m.SitemapUrlsFound
+	}
+
+	if m.FeedUrlsFound != 0 {
+		wc.metrics.FeedUrlsFound += m.FeedUrlsFound
+	}
+
+	if m.Retries != 0 {
+		wc.metrics.Retries += m.Retries
+	}
+
+	if m.FailedUrls != 0 {
+		wc.metrics.FailedUrls += m.FailedUrls
+	}
+
+	if m.BytesDownloaded != 0 {
+		wc.metrics.BytesDownloaded += m.BytesDownloaded
+	}
 	wc.metricsLock.Unlock()
 	return m
 }
 
-// processScrapedUrls checks the current depth of the url and decides whether or not
-// to send the urls to the pendingUrlsToCrawl channel.
+// processScrapedUrls normalizes the urls, checks the current depth of the url, the scope rules of the options and the
+// robots.txt restrictions of its host and decides whether or not to send the urls to the pendingUrlsToCrawl channel.
+// The root url is always in scope. Urls that are sent join the frontier.
 func (wc *WebCrawler) processScrapedUrls(scrapedUrls []*webscraper.URL) {
 	if len(scrapedUrls) == 0 {
 		return
 	}
 
-	if scrapedUrls[0].CurrentDepth <= wc.Options.MaxDepth {
-		for _, url := range scrapedUrls {
-			wc.pendingUrlsToCrawl <- url
-			wc.pendingUrlsToCrawlCount <- 1
+	for _, url := range scrapedUrls {
+		// The max depth of the url is the max depth of the seed it was found from.
+		if url.CurrentDepth > url.MaxDepth {
+			continue
+		}
+		normalizedURL, err := webscraper.NormalizeURL(url.CurrentURL, wc.Options.IgnoredQueryParams)
+		if err != nil {
+			wc.Logger.WithError(err).WithField("url", url.CurrentURL).Debug("Unable to normalize url")
+			wc.incrementMetrics(&Metrics{InvalidUrlsFound: 1})
+			continue
+		}
+		url.CurrentURL = normalizedURL
+		if !isSeed(url) && !wc.scope.inScope(url) {
+			wc.Logger.WithField("url", url.CurrentURL).Debug("Url is out of scope")
+			wc.incrementMetrics(&Metrics{OutOfScopeUrlsFound: 1})
+			continue
+		}
+		if !wc.isAllowedByRobots(url.CurrentURL) {
+			wc.Logger.WithField("url", url.CurrentURL).Debug("Url is disallowed by robots.txt")
+			wc.incrementMetrics(&Metrics{DisallowedUrlsFound: 1})
+			continue
+		}
+		// The count is incremented before the url is sent so that the monitor never sees zero pending urls
+		// while this url is still on its way.
+		wc.state.push(url)
+		wc.updatePendingUrlsToCrawlCount(1)
+		select {
+		case wc.pendingUrlsToCrawl <- url:
+		case <-wc.ctx.Done():
+			return
+		}
+	}
+}
+
+// resumeFrontier queues the frontier of a checkpoint. The urls have already passed the checks of processScrapedUrls.
+// The submitted urls are already in the visited set and are scheduled right away, the pending urls are sent to the
+// pendingUrlsToCrawl channel to be deduplicated. If the frontier is empty, there is nothing left to crawl and the crawl
+// is stopped.
+func (wc *WebCrawler) resumeFrontier(submitted, pending []*webscraper.URL) {
+	if len(submitted) == 0 && len(pending) == 0 {
+		wc.Logger.Debug("Frontier of the checkpoint is empty, nothing left to crawl")
+		wc.cancel()
+		return
+	}
+	for _, url := range submitted {
+		wc.state.submit(url)
+		wc.updatePendingUrlsToCrawlCount(1)
+		wc.schedule(url)
+	}
+	for _, url := range pending {
+		wc.state.push(url)
+		wc.updatePendingUrlsToCrawlCount(1)
+		select {
+		case wc.pendingUrlsToCrawl <- url:
+		case <-wc.ctx.Done():
+			return
 		}
 	}
 }
 
-// processSrapedResponse aggregates web scraper responses from all web scraper workers
-func (wc *WebCrawler) processSrapedResponse() {
+// processSrapedResponse forwards the web scraper responses from all web scraper workers to the events channel, followed
+// by an event for every item extracted in the response.
+func (wc *WebCrawler) processSrapedResponse(events chan<- *Event) {
 	for response := range wc.collectWebScraperResponse {
-		wc.webScraperResponses = append(wc.webScraperResponses, response)
+		events <- &Event{Type: EventResponse, Response: response}
+		for _, item := range response.ExtractedItem {
+			events <- &Event{Type: EventItem, Item: item}
+		}
 	}
 }
 
-// processCrawledUrls checks the url to see if its empty, if it's been visited, and generates metrics.
-// If the url is ready to be crawled, it is then sent to the pendingUrlsToCrawl channel where the web scraper
-// workers are actively listening to.
+// processCrawledUrls checks the url to see if it's been visited, if its host is within its crawl budget, and generates
+// metrics.
+// If the url is ready to be crawled, it is then submitted to the scheduler which sends it to the urlsToCrawl channel
+// where the web scraper workers are actively listening to.
 func (wc *WebCrawler) processCrawledUrls() {
 	for {
-		url := <-wc.pendingUrlsToCrawl
-		if url == nil {
-			wc.Logger.Debug("Channel is closed, no longer processing crawled links")
+		var url *webscraper.URL
+		select {
+		case url = <-wc.pendingUrlsToCrawl:
+		case <-wc.ctx.Done():
+			wc.Logger.Debug("Crawl has stopped, no longer processing crawled links")
 			return
 		}
 
-		if url.CurrentURL == "" {
-			wc.pendingUrlsToCrawlCount <- -1
-			wc.incrementMetrics(&Metrics{DuplicatedUrlsFound: 1})
-			continue
+		added, err := wc.state.visit(url)
+		if err != nil {
+			// Without a working visited set every url could be crawled again, the crawl is failed instead.
+			wc.Logger.WithError(err).WithField("url", url.CurrentURL).Error("Unable to mark url as visited")
+			select {
+			case wc.errs <- err:
+			case <-wc.ctx.Done():
+			}
+			return
 		}
-
-		_, visited := wc.visited[url.CurrentURL]
-		if !visited {
-			wc.visited[url.CurrentURL] = struct{}{}
-			wc.urlsToCrawl <- url
+		if added {
+			wc.schedule(url)
 		} else {
 			wc.incrementMetrics(&Metrics{DuplicatedUrlsFound: 1})
-			wc.pendingUrlsToCrawlCount <- -1
+			wc.updatePendingUrlsToCrawlCount(-1)
 		}
 	}
 }
 
+// schedule submits a visited url to the scheduler, unless its host has exceeded its crawl budget.
+func (wc *WebCrawler) schedule(url *webscraper.URL) {
+	if !wc.withinHostBudget(url) {
+		wc.Logger.WithField("url", url.CurrentURL).Debug("Host has exceeded its crawl budget")
+		wc.incrementMetrics(&Metrics{OverBudgetUrlsFound: 1})
+		wc.state.done(url)
+		wc.updatePendingUrlsToCrawlCount(-1)
+		return
+	}
+	wc.scheduler.submit(url)
+}
+
 // monitorCrawling used as a groutine that actively checks the pendingUrlsToCrawlCount channel to determine the state
 // of the web scrapers. If the web scrapers have finished or halted or are stuck, then this function will gracefully stop
-// all web scrapers and close all of the channels.
+// all web scrapers by cancelling the crawl.
 func (wc *WebCrawler) monitorCrawling() {
 	var c int
-	for count := range wc.pendingUrlsToCrawlCount {
-		c += count
-		if c == 0 {
-			wc.Logger.Debug("No more pending urls to crawl, close all channels")
-			wc.stopAllWebScrapers()
-			close(wc.urlsToCrawl)
-			close(wc.pendingUrlsToCrawl)
-			close(wc.pendingUrlsToCrawlCount)
-			wc.Logger.Debug("Sucessfully closed all channels")
+	for {
+		select {
+		case count := <-wc.pendingUrlsToCrawlCount:
+			c += count
+			if c == 0 {
+				wc.Logger.Debug("No more pending urls to crawl, stopping all webscrapers")
+				wc.cancel()
+				return
+			}
+		case <-wc.ctx.Done():
+			return
 		}
 	}
 }
@@ -386,7 +1009,10 @@ func (wc *WebCrawler) monitorCrawling() {
 // shutDownWebScraper stops web scraper by sending a signal over the stop channel
 func (wc *WebCrawler) shutDownWebScraper(s *webscraper.WebScraper) {
 	defer wc.wg.Done()
-	s.Stop <- struct{}{}
+	select {
+	case s.Stop <- struct{}{}:
+	case <-wc.ctx.Done():
+	}
 }
 
 // StopWebScraper stops web scraper using scraper number
@@ -415,9 +1041,17 @@ func (wc *WebCrawler) stopAllWebScrapers() {
 // readinessCheck ensures that the specified number of webscraper workers have start up correctly which indicates that
 // the crawler has started up correctly and are ready to scrape.
 func (wc *WebCrawler) readinessCheck() error {
-	time.Sleep(time.Second * 10)
-	if len(wc.webScrapers) != wc.Options.WebScraperWorkerCount {
-		wc.Logger.WithFields(logrus.Fields{"Current # of webscrapers": len(wc.webScrapers), "Required # of webscrapers": wc.Options.WebScraperWorkerCount}).Error("Failed health check, required # of webscrapers not reached")
+	select {
+	case <-time.After(time.Second * 10):
+	case <-wc.ctx.Done():
+		// The crawl finished or was cancelled before the check was due.
+		return nil
+	}
+	wc.mapLock.Lock()
+	webScraperCount := len(wc.webScrapers)
+	wc.mapLock.Unlock()
+	if webScraperCount != wc.Options.WebScraperWorkerCount {
+		wc.Logger.WithFields(logrus.Fields{"Current # of webscrapers": webScraperCount, "Required # of webscrapers": wc.Options.WebScraperWorkerCount}).Error("Failed health check, required # of webscrapers not reached")
 		return fmt.Errorf("health check has failed")
 	}
 
@@ -426,30 +1060,28 @@ func (wc *WebCrawler) readinessCheck() error {
 }
 
 // livenessCheck determines if the web crawler is able to crawl urls, checks if web crawler is stuck crawling a url. It also
-// checks the cpu and memory usages.
+// checks the cpu and memory usages. A crawl that fails the check is stopped with StopReasonStalled.
 func (wc *WebCrawler) livenessCheck() error {
-	checkCounter := true
+	window := wc.livenessWindow()
+	visited, progressed := wc.Metrics().UrlsVisited, time.Now()
 	for {
-		time.Sleep(time.Second * 10)
+		select {
+		case <-time.After(time.Second * 10):
+		case <-wc.ctx.Done():
+			return nil
+		}
 		numGoRoutine := runtime.NumGoroutine()
 		if numGoRoutine < wc.Options.WebScraperWorkerCount*2 {
-			wc.stopAllWebScrapers()
+			wc.stopCrawl(StopReasonStalled)
 			return fmt.Errorf("failed liveness check, number of go routines: %v is below threshold: %v", numGoRoutine, wc.Options.WebScraperWorkerCount*2)
 		}
 
-		if checkCounter {
-			go func() {
-				checkCounter = false
-				pastCounter := wc.metrics.UrlsVisited
-				time.Sleep(time.Second * 60)
-				presentCounter := wc.metrics.UrlsVisited
-				if pastCounter == presentCounter {
-					wc.stopAllWebScrapers()
-				}
-
-				wc.Logger.Info("Liveness check passed")
-				checkCounter = true
-			}()
+		if present := wc.Metrics().UrlsVisited; present != visited {
+			visited, progressed = present, time.Now()
+			wc.Logger.Debug("Liveness check passed")
+		} else if time.Since(progressed) >= window {
+			wc.stopCrawl(StopReasonStalled)
+			return fmt.Errorf("failed liveness check, no url visited for %v", window)
 		}
 		var stats runtime.MemStats
 		runtime.ReadMemStats(&stats)
@@ -457,50 +1089,12 @@ func (wc *WebCrawler) livenessCheck() error {
 	}
 }
 
-// initRobotsTxtRestrictions parses the given website robots.txt web page and intializes the user agent restrictions
-func (wc *WebCrawler) initRobotsTxtRestrictions(url string) error {
-	// Given URL, generate robots.txt url, and get the response.
-	wc.Logger.WithField("url", url).Debugf("Initializing robots.txt restrictions")
-	url = generateRobotsTxtURLPath(url)
-	resp, err := webscraper.ConnectToWebsite(url, wc.Options.HeaderKey, wc.Options.HeaderValue)
-	if err != nil {
-		return err
-	}
-
-	defer resp.Body.Close()
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return err
-	}
-
-	// Parses /robots.txt for blacklisted url path. Generates map used for checking.
-	wc.Logger.WithField("url", url).Debugf("Parsing url for robots.txt restrictions")
-	robotTxtRules := strings.Split(string(body), "\n")
-	addRule := false
-	for _, rules := range robotTxtRules {
-		if strings.Contains(rules, "User-agent: *") {
-			addRule = true
-			continue
-		} else if strings.Contains(rules, "User-agent: ") && addRule {
-			break
-		}
-
-		if addRule {
-			if strings.Contains(rules, "Disallow: ") {
-				wc.Options.BlacklistedURLPaths[strings.ReplaceAll(strings.Split(rules, " ")[1], "*", "")] = struct{}{}
-			}
-		}
-	}
-	wc.Logger.WithField("url", url).Debugf("Successfully parsed url for robots.txt restrictions")
-	return nil
-}
-
-// generateRobotsTxtURLPath given any url, generate the robots txt url path
-func generateRobotsTxtURLPath(url string) string {
-	if strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "https://") {
-		url = strings.Replace(url, strings.SplitAfterN(url, "/", 4)[3], "robots.txt", 1)
-	} else {
-		url = strings.Replace(url, strings.SplitAfterN(url, "/", 2)[1], "robots.txt", 1)
+// livenessWindow returns how long the crawl may visit no url before it is stalled. It is a minute longer than the
+// longest a url can wait for its host, its request and its retry, so that a slowed down host does not stall the crawl.
+func (wc *WebCrawler) livenessWindow() time.Duration {
+	window := time.Minute + maxDuration(wc.Options.CrawlDelayTime(), wc.Options.MaxCrawlDelay) + wc.Options.Transport.Timeout
+	if wc.Options.RetryPolicy != nil {
+		window += wc.Options.RetryPolicy.MaxBackoff
 	}
-	return url
+	return window
 }
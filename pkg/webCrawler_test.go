@@ -1,11 +1,56 @@
 package webcrawler
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
 	"testing"
+	"time"
 
+	options "github.com/cody6750/web-crawler/pkg/options"
 	webscraper "github.com/cody6750/web-crawler/pkg/webScraper"
 )
 
+// testSite is a website serving pages by path to the crawl tests. robots.txt is not found, so that every page may be
+// crawled, and every other path is an empty page.
+type testSite struct {
+	*httptest.Server
+
+	lock sync.Mutex
+	hits map[string]int
+}
+
+// newTestSite starts a test site serving the pages, it is closed once the test has finished.
+func newTestSite(t *testing.T, pages map[string]string) *testSite {
+	t.Helper()
+	site := &testSite{hits: make(map[string]int)}
+	site.Server = httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		site.lock.Lock()
+		site.hits[r.URL.Path]++
+		site.lock.Unlock()
+		if r.URL.Path == "/robots.txt" {
+			http.NotFound(rw, r)
+			return
+		}
+		page, exist := pages[r.URL.Path]
+		if !exist {
+			page = `<html></html>`
+		}
+		rw.Write([]byte(page))
+	}))
+	t.Cleanup(site.Close)
+	return site
+}
+
+// hitCount returns the number of requests of the path.
+func (s *testSite) hitCount(path string) int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.hits[path]
+}
+
 func TestWebCrawler_Crawl(t *testing.T) {
 	crawl := NewCrawler()
 	type args struct {
@@ -144,3 +189,169 @@ func TestWebCrawler_Crawl(t *testing.T) {
 		})
 	}
 }
+
+func TestWebCrawler_CrawlContext(t *testing.T) {
+	// The server never answers until the client goes away, simulating a slow website.
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			http.NotFound(rw, r)
+			return
+		}
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		timeout time.Duration
+		wantErr error
+	}{
+		{
+			name:    "Deadline exceeded stops the crawl",
+			timeout: 500 * time.Millisecond,
+			wantErr: context.DeadlineExceeded,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := options.New()
+			o.CrawlDelay = 0
+			o.WebScraperWorkerCount = 1
+			wc := NewWithOptions(o)
+			ctx, cancel := context.WithTimeout(context.Background(), tt.timeout)
+			defer cancel()
+
+			start := time.Now()
+			got, err := wc.CrawlContext(ctx, server.URL+"/index.html", nil)
+			if err != tt.wantErr {
+				t.Errorf("WebCrawler.CrawlContext() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got == nil {
+				t.Errorf("WebCrawler.CrawlContext() returned no partial response")
+			}
+			if elapsed := time.Since(start); elapsed > 5*time.Second {
+				t.Errorf("WebCrawler.CrawlContext() took %v to return after cancellation", elapsed)
+			}
+		})
+	}
+}
+
+func TestWebCrawler_CrawlStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			http.NotFound(rw, r)
+			return
+		}
+		rw.Write([]byte(`<html><body><div class="item"><span>RTX 3080</span></div></body></html>`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name        string
+		itemsToGet  []webscraper.ScrapeItemConfig
+		wantEvents  []EventType
+		wantVisited int
+	}{
+		{
+			name: "Progress, response and item are streamed before done",
+			itemsToGet: []webscraper.ScrapeItemConfig{
+				{
+					ItemName:  "Graphics Cards",
+					ItemToGet: webscraper.ExtractFromTokenConfig{Tag: "div", Attribute: "class", AttributeValue: "item"},
+					ItemDetails: map[string]webscraper.ExtractFromTokenConfig{
+						"title": {Tag: "span"},
+					},
+				},
+			},
+			wantEvents:  []EventType{EventProgress, EventResponse, EventItem, EventDone},
+			wantVisited: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := options.New()
+			o.CrawlDelay = 0
+			wc := NewWithOptions(o)
+			events, err := wc.CrawlStream(context.Background(), server.URL+"/index.html", tt.itemsToGet)
+			if err != nil {
+				t.Fatalf("WebCrawler.CrawlStream() error = %v", err)
+			}
+
+			var got []EventType
+			var done *Event
+			for event := range events {
+				got = append(got, event.Type)
+				if event.Type == EventDone {
+					done = event
+				}
+			}
+			if !reflect.DeepEqual(got, tt.wantEvents) {
+				t.Errorf("WebCrawler.CrawlStream() events = %v, want %v", got, tt.wantEvents)
+			}
+			if done == nil || done.Err != nil || done.Metrics.UrlsVisited != tt.wantVisited {
+				t.Errorf("WebCrawler.CrawlStream() done event = %+v, want %v urls visited", done, tt.wantVisited)
+			}
+		})
+	}
+}
+
+func TestWebCrawler_isAllowedByRobots(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/robots.txt":
+			rw.Write([]byte("User-agent: *\nDisallow: /cart\nAllow: /cart/public\n\nUser-agent: testbot\nDisallow: /\n"))
+		default:
+			http.NotFound(rw, r)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name      string
+		userAgent string
+		url       string
+		want      bool
+	}{
+		{name: "Allowed", userAgent: "Mozilla/5.0", url: server.URL + "/products?page=2", want: true},
+		{name: "Disallowed", userAgent: "Mozilla/5.0", url: server.URL + "/cart/view.html", want: false},
+		{name: "Longest match allowed", userAgent: "Mozilla/5.0", url: server.URL + "/cart/public/view.html", want: true},
+		{name: "User agent group", userAgent: "TestBot/1.0", url: server.URL + "/products", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := options.New()
+			o.HeaderValue = tt.userAgent
+			wc := NewWithOptions(o)
+			wc.init(context.Background())
+			if got := wc.isAllowedByRobots(tt.url); got != tt.want {
+				t.Errorf("WebCrawler.isAllowedByRobots() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebCrawler_CrawlNormalizesUrls(t *testing.T) {
+	site := newTestSite(t, map[string]string{
+		"/index.html": `<a href="/a">a</a><a href="/a#reviews">a</a><a href="./a?utm_source=mail">a</a><a href="b">b</a>`,
+	})
+	o := options.New()
+	o.CrawlDelay = 0
+	o.AllowEmptyItem = true
+	got, err := NewWithOptions(o).CrawlContext(context.Background(), site.URL+"/index.html", nil)
+	if err != nil {
+		t.Fatalf("WebCrawler.CrawlContext() error = %v", err)
+	}
+	// /a, /a#reviews and ./a?utm_source=mail are the same url.
+	if got.Metrics.UrlsVisited != 3 || got.Metrics.DuplicatedUrlsFound != 2 || site.hitCount("/a") != 1 {
+		t.Errorf("WebCrawler.CrawlContext() urls visited = %v, duplicated urls = %v, /a fetched %v times, want 3, 2 and 1", got.Metrics.UrlsVisited, got.Metrics.DuplicatedUrlsFound, site.hitCount("/a"))
+	}
+}
+
+func TestWebCrawler_processScrapedUrls_invalidUrls(t *testing.T) {
+	wc := NewWithOptions(options.New())
+	wc.init(context.Background())
+	wc.processScrapedUrls([]*webscraper.URL{{CurrentURL: "/relative", MaxDepth: 1}, {CurrentURL: "http://[::1", MaxDepth: 1}})
+	if got := wc.Metrics(); got.InvalidUrlsFound != 2 || got.DuplicatedUrlsFound != 0 {
+		t.Errorf("WebCrawler.processScrapedUrls() invalid urls = %v, duplicated urls = %v, want 2 and 0", got.InvalidUrlsFound, got.DuplicatedUrlsFound)
+	}
+}
@@ -0,0 +1,339 @@
+package webcrawler
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// ValidateScrapeConfigs checks the selectors, XPath expressions, regular expressions and schemas of the scrape configs,
+// so that an invalid config is reported before crawling rather than on every page.
+func ValidateScrapeConfigs(itemsToGet []ScrapeItemConfig, urlsToGet []ScrapeURLConfig) error {
+	for _, item := range itemsToGet {
+		if err := validateScrapeItemConfig(item); err != nil {
+			return err
+		}
+	}
+	for _, scrapeURLConfig := range urlsToGet {
+		if err := validateExtractFromTokenConfig(scrapeURLConfig.ExtractFromTokenConfig); err != nil {
+			return fmt.Errorf("url config %v: %w", scrapeURLConfig.Name, err)
+		}
+		if scrapeURLConfig.ExtractFromTokenConfig.Schema != "" {
+			return fmt.Errorf("url config %v: schema is only supported for items", scrapeURLConfig.Name)
+		}
+	}
+	return nil
+}
+
+// validateScrapeItemConfig checks the item config and its children. The item details and children of an item mapped
+// from structured data must be mapped from structured data as well, and the other way around.
+func validateScrapeItemConfig(item ScrapeItemConfig) error {
+	if err := validateExtractFromTokenConfig(item.ItemToGet); err != nil {
+		return fmt.Errorf("item %v: %w", item.ItemName, err)
+	}
+	for itemDetailName, itemDetail := range item.ItemDetails {
+		if err := validateExtractFromTokenConfig(itemDetail); err != nil {
+			return fmt.Errorf("item detail %v of item %v: %w", itemDetailName, item.ItemName, err)
+		}
+		if (item.ItemToGet.Schema == "") != (itemDetail.Schema == "") {
+			return fmt.Errorf("item detail %v of item %v: a schema path requires a schema type in ItemToGet and the other way around", itemDetailName, item.ItemName)
+		}
+	}
+	for _, child := range item.Children {
+		if (item.ItemToGet.Schema == "") != (child.ItemToGet.Schema == "") {
+			return fmt.Errorf("child %v of item %v: children of an item with a schema type require a schema type and the other way around", child.ItemName, item.ItemName)
+		}
+		if err := validateScrapeItemConfig(child); err != nil {
+			return fmt.Errorf("child of item %v: %w", item.ItemName, err)
+		}
+	}
+	return nil
+}
+
+// validateExtractFromTokenConfig checks the selector, XPath expression and regular expression of the config.
+func validateExtractFromTokenConfig(config ExtractFromTokenConfig) error {
+	if config.Selector != "" && config.XPath != "" {
+		return fmt.Errorf("only one of selector and xpath can be set")
+	}
+	if config.Schema != "" && (config.Selector != "" || config.XPath != "") {
+		return fmt.Errorf("schema can not be combined with selector or xpath")
+	}
+	if config.Selector != "" {
+		if _, err := CompileSelector(config.Selector); err != nil {
+			return err
+		}
+	}
+	if config.XPath != "" {
+		if _, err := CompileXPath(config.XPath); err != nil {
+			return err
+		}
+	}
+	if config.Regex != "" {
+		if _, err := compileRegex(config.Regex); err != nil {
+			return fmt.Errorf("invalid regex %q: %w", config.Regex, err)
+		}
+	}
+	return nil
+}
+
+// usesDOM reports whether the config is evaluated against the parsed DOM of the page rather than the stream of tokens.
+// Schemas are evaluated against the structured data of the parsed DOM.
+func usesDOM(config ExtractFromTokenConfig) bool {
+	return config.Selector != "" || config.XPath != "" || config.Schema != ""
+}
+
+// splitScrapeConfigs splits the configs evaluated against the stream of tokens from the configs evaluated against the
+// parsed DOM of the page. An item is evaluated against the DOM if the item or one of its details is, or if it has
+// children.
+func splitScrapeConfigs(itemsToGet []ScrapeItemConfig, urlsToGet []ScrapeURLConfig) (tokenItems, domItems []ScrapeItemConfig, tokenURLs, domURLs []ScrapeURLConfig) {
+	for _, item := range itemsToGet {
+		dom := usesDOM(item.ItemToGet) || len(item.Children) > 0
+		for _, itemDetail := range item.ItemDetails {
+			dom = dom || usesDOM(itemDetail)
+		}
+		if dom {
+			domItems = append(domItems, item)
+		} else {
+			tokenItems = append(tokenItems, item)
+		}
+	}
+	for _, scrapeURLConfig := range urlsToGet {
+		if usesDOM(scrapeURLConfig.ExtractFromTokenConfig) {
+			domURLs = append(domURLs, scrapeURLConfig)
+		} else {
+			tokenURLs = append(tokenURLs, scrapeURLConfig)
+		}
+	}
+	return tokenItems, domItems, tokenURLs, domURLs
+}
+
+// scrapeDocument parses the page and extracts the urls and items of the configs evaluated against its DOM. Links are
+// resolved against the base url of the page. The structured data of the page is extracted if the web scraper returns
+// it or an item config maps it, otherwise it is nil.
+func (ws *WebScraper) scrapeDocument(page []byte, u *URL, base *url.URL, urlsToCheck map[string]bool, itemsToGet []ScrapeItemConfig, urlsToGet []ScrapeURLConfig) ([]*URL, []*Item, *StructuredData) {
+	var (
+		urls  []*URL
+		items []*Item
+		data  *StructuredData
+	)
+	doc, err := html.Parse(bytes.NewReader(page))
+	if err != nil {
+		ws.logger().WithError(err).WithField("url", u.CurrentURL).Warn("Unable to parse html")
+		return nil, nil, nil
+	}
+	if ws.ExtractStructuredData || usesSchema(itemsToGet) {
+		data = extractStructuredData(doc, base)
+	}
+	for _, href := range extractURLsFromDocument(doc, urlsToCheck, urlsToGet) {
+		if child := ws.childURL(u, base, href); child != nil {
+			urls = append(urls, child)
+		}
+	}
+	for _, item := range extractItemsFromDocument(doc, data, itemsToGet) {
+		item := item
+		item.URL = u
+		items = append(items, &item)
+	}
+	return urls, items, data
+}
+
+// usesSchema reports whether one of the item configs maps the structured data of the page.
+func usesSchema(itemsToGet []ScrapeItemConfig) bool {
+	for _, item := range itemsToGet {
+		if item.ItemToGet.Schema != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// extractURLsFromDocument returns the links of the elements matched by the url configs, the attribute AttributeToGet of
+// the elements or their href. Checks for duplicates.
+func extractURLsFromDocument(doc *html.Node, urlsToCheck map[string]bool, urlsToGet []ScrapeURLConfig) []string {
+	var urls []string
+	for _, scrapeURLConfig := range urlsToGet {
+		attribute := hrefAttribute
+		if scrapeURLConfig.ExtractFromTokenConfig.AttributeToGet != "" {
+			attribute = scrapeURLConfig.ExtractFromTokenConfig.AttributeToGet
+		}
+		for _, url := range linkValues(doc, scrapeURLConfig.ExtractFromTokenConfig, attribute) {
+			url, _, matched := matchRegex("", url, scrapeURLConfig.ExtractFromTokenConfig)
+			if !matched {
+				continue
+			}
+			if !IsEmpty(scrapeURLConfig.FormatURLConfig) {
+				url = formatURL(url, scrapeURLConfig.FormatURLConfig)
+			}
+			if url == "" || isDuplicateURL(url, urlsToCheck) {
+				continue
+			}
+			urls = append(urls, url)
+		}
+	}
+	return urls
+}
+
+// linkValues returns the attribute of the elements matched by the config. The XPath expression of the config may also
+// select attributes or texts, or evaluate to a string, which are links themselves.
+func linkValues(doc *html.Node, config ExtractFromTokenConfig, attribute string) []string {
+	if config.XPath != "" {
+		x, err := CompileXPath(config.XPath)
+		if err != nil {
+			return nil
+		}
+		return x.values(doc, attribute)
+	}
+	var values []string
+	for _, n := range matchNodes(doc, config) {
+		values = append(values, attributeValue(n, attribute))
+	}
+	return values
+}
+
+// extractItemsFromDocument extracts an item from every element matched by the item configs, or from every object of
+// the structured data of the page of their schema type.
+func extractItemsFromDocument(doc *html.Node, data *StructuredData, itemsToGet []ScrapeItemConfig) []Item {
+	var items []Item
+	for _, scrapeItemConfig := range itemsToGet {
+		if scrapeItemConfig.ItemToGet.Schema != "" {
+			items = append(items, extractItemsFromStructuredData(data, scrapeItemConfig)...)
+			continue
+		}
+		for _, n := range matchNodes(doc, scrapeItemConfig.ItemToGet) {
+			if item, ok := extractItemFromNode(n, scrapeItemConfig); ok {
+				items = append(items, item)
+			}
+		}
+	}
+	return items
+}
+
+// extractItemFromNode extracts the item details of the item from the first element within the item matched by each
+// item detail config, or from every element for item details with several values. The children of the item are
+// extracted from the elements within the item matched by the child item configs. Returns false if an item detail does
+// not pass its filter.
+func extractItemFromNode(n *html.Node, scrapeItemConfig ScrapeItemConfig) (Item, bool) {
+	item, ok := extractItem(scrapeItemConfig, func(config ExtractFromTokenConfig) []string {
+		return itemDetailValues(n, config)
+	})
+	if !ok {
+		return Item{}, false
+	}
+	for _, child := range scrapeItemConfig.Children {
+		for _, childNode := range matchNodes(n, child.ItemToGet) {
+			if childItem, ok := extractItemFromNode(childNode, child); ok {
+				addChild(&item, childItem)
+			}
+		}
+	}
+	return item, true
+}
+
+// extractItem extracts the item details of the item with the values function, which returns the values of an item
+// detail config in the order they were found. Only the first value is used unless the item detail is configured with
+// Multiple. Returns false if an item detail with a single value does not pass its filter.
+func extractItem(scrapeItemConfig ScrapeItemConfig, itemDetailValues func(config ExtractFromTokenConfig) []string) (Item, bool) {
+	item := newItem(scrapeItemConfig.ItemName)
+	for itemDetailName, itemDetails := range scrapeItemConfig.ItemDetails {
+		values := itemDetailValues(itemDetails)
+		if !itemDetails.Multiple && len(values) > 1 {
+			values = values[:1]
+		}
+		for _, value := range values {
+			if !addItemDetailValue(&item, scrapeItemConfig, itemDetailName, value) {
+				return Item{}, false
+			}
+		}
+	}
+	return item, true
+}
+
+// itemDetailValues returns the values of the item detail within the item, in document order.
+func itemDetailValues(item *html.Node, config ExtractFromTokenConfig) []string {
+	var values []string
+	if config.XPath != "" {
+		x, err := CompileXPath(config.XPath)
+		if err != nil {
+			return nil
+		}
+		for _, value := range x.values(item, config.AttributeToGet) {
+			values = append(values, strings.TrimSpace(value))
+		}
+		return values
+	}
+	for _, n := range matchNodes(item, config) {
+		values = append(values, nodeValue(n, config))
+	}
+	return values
+}
+
+// matchNodes returns the elements matched by the config within the node, in document order. Without a selector or an
+// XPath expression, the elements are matched by Tag, Attribute and AttributeValue like the tokens of the page.
+func matchNodes(root *html.Node, config ExtractFromTokenConfig) []*html.Node {
+	if config.Selector != "" {
+		selector, err := CompileSelector(config.Selector)
+		if err != nil {
+			return nil
+		}
+		return selector.MatchAll(root)
+	}
+	var matches []*html.Node
+	if config.XPath != "" {
+		x, err := CompileXPath(config.XPath)
+		if err != nil {
+			return nil
+		}
+		for _, n := range x.Select(root) {
+			if n.Type == html.ElementNode {
+				matches = append(matches, n)
+			}
+		}
+		return matches
+	}
+	for n := nextNode(root, root); n != nil; n = nextNode(n, root) {
+		if n.Type != html.ElementNode || n.Data != config.Tag {
+			continue
+		}
+		if (config.Attribute == "" && config.AttributeValue == "") || attributeValue(n, config.Attribute) == config.AttributeValue {
+			matches = append(matches, n)
+		}
+	}
+	return matches
+}
+
+// nodeValue returns the value of the element, its attribute AttributeToGet or else the text that follows its start
+// tag, skipping SkipToken texts. Unlike tokens, white space only texts are skipped and the text is trimmed.
+func nodeValue(n *html.Node, config ExtractFromTokenConfig) string {
+	var value string
+	if config.AttributeToGet != "" {
+		value = attributeValue(n, config.AttributeToGet)
+	} else {
+		skip := config.SkipToken
+		for text := nextNode(n, nil); text != nil; text = nextNode(text, nil) {
+			if text.Type != html.TextNode || strings.TrimSpace(text.Data) == "" {
+				continue
+			}
+			if skip == 0 {
+				value = strings.TrimSpace(text.Data)
+				break
+			}
+			skip--
+		}
+	}
+	return value
+}
+
+// formatValue formats the value of an item detail with the FormatAttributeConfiguration. Returns false if the value
+// does not pass the FilterConfiguration.
+func formatValue(value string, config ExtractFromTokenConfig) (string, bool) {
+	if !IsEmpty(config.ItemFilterConfiguration) && !Validate(value, &config.ItemFilterConfiguration) {
+		return "", false
+	}
+	if !IsEmpty(config.FormatAttributeConfiguration) {
+		value = formatURL(value, config.FormatAttributeConfiguration)
+	}
+	return value, true
+}
@@ -0,0 +1,163 @@
+package webcrawler
+
+import (
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Fetcher executes the http requests of the web scraper. Implementations may add proxies, caches or replace the
+// network entirely in tests. Fetch must be safe for concurrent use.
+type Fetcher interface {
+	Fetch(request *http.Request) (*http.Response, error)
+}
+
+// FetcherFunc adapts a function to the Fetcher interface.
+type FetcherFunc func(request *http.Request) (*http.Response, error)
+
+// Fetch calls f(request).
+func (f FetcherFunc) Fetch(request *http.Request) (*http.Response, error) {
+	return f(request)
+}
+
+// TransportConfig configures the http client and transport of an HTTPFetcher.
+type TransportConfig struct {
+	// Timeout limits the whole request, including reading the body. 0 means no timeout.
+	Timeout time.Duration
+
+	// DialTimeout, TLSHandshakeTimeout and ResponseHeaderTimeout limit the phases of a request. 0 means no timeout.
+	DialTimeout           time.Duration
+	TLSHandshakeTimeout   time.Duration
+	ResponseHeaderTimeout time.Duration
+
+	// IdleConnTimeout is how long an idle connection is kept in the pool. 0 means no limit.
+	IdleConnTimeout time.Duration
+
+	// MaxIdleConns and MaxIdleConnsPerHost size the connection pool, MaxConnsPerHost limits the connections per host.
+	// 0 means no limit, except for MaxIdleConnsPerHost where it means http.DefaultMaxIdleConnsPerHost.
+	MaxIdleConns        int
+	MaxIdleConnsPerHost int
+	MaxConnsPerHost     int
+
+	// TLSConfig configures TLS, nil uses the default configuration. InsecureSkipVerify disables certificate
+	// verification.
+	TLSConfig          *tls.Config
+	InsecureSkipVerify bool
+
+	// DisableHTTP2 restricts the transport to HTTP/1.1.
+	DisableHTTP2 bool
+
+	// MaxBodySize is the maximum number of bytes read from a response body, longer bodies are truncated. 0 means no
+	// limit.
+	MaxBodySize int64
+
+	// Proxy returns the proxy for a request, nil uses the proxy of the environment.
+	Proxy func(*http.Request) (*url.URL, error)
+}
+
+// DefaultTransportConfig returns the transport configuration used unless configured otherwise.
+func DefaultTransportConfig() TransportConfig {
+	return TransportConfig{
+		Timeout:               60 * time.Second,
+		DialTimeout:           30 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: 30 * time.Second,
+		IdleConnTimeout:       90 * time.Second,
+		MaxIdleConns:          100,
+		MaxIdleConnsPerHost:   10,
+		MaxBodySize:           10 * 1024 * 1024,
+	}
+}
+
+// Clone returns a deep copy of the transport configuration.
+func (c TransportConfig) Clone() TransportConfig {
+	if c.TLSConfig != nil {
+		c.TLSConfig = c.TLSConfig.Clone()
+	}
+	return c
+}
+
+// HTTPFetcher is the default Fetcher. It reuses the connections of a single http.Transport for every request, so a
+// single HTTPFetcher should be shared between web scrapers and web crawlers.
+type HTTPFetcher struct {
+	client      *http.Client
+	maxBodySize int64
+}
+
+// DefaultFetcher is the shared fetcher used by web scrapers without a Fetcher.
+var DefaultFetcher = NewHTTPFetcher(DefaultTransportConfig())
+
+// NewHTTPFetcher creates a fetcher with its own http.Transport configured by the transport configuration.
+func NewHTTPFetcher(config TransportConfig) *HTTPFetcher {
+	dialer := &net.Dialer{Timeout: config.DialTimeout, KeepAlive: 30 * time.Second}
+	transport := &http.Transport{
+		Proxy:                 config.Proxy,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   config.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: config.ResponseHeaderTimeout,
+		IdleConnTimeout:       config.IdleConnTimeout,
+		MaxIdleConns:          config.MaxIdleConns,
+		MaxIdleConnsPerHost:   config.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       config.MaxConnsPerHost,
+		ForceAttemptHTTP2:     !config.DisableHTTP2,
+	}
+	if transport.Proxy == nil {
+		transport.Proxy = http.ProxyFromEnvironment
+	}
+	if config.TLSConfig != nil {
+		transport.TLSClientConfig = config.TLSConfig.Clone()
+	}
+	if config.InsecureSkipVerify {
+		if transport.TLSClientConfig == nil {
+			transport.TLSClientConfig = &tls.Config{}
+		}
+		transport.TLSClientConfig.InsecureSkipVerify = true
+	}
+	if config.DisableHTTP2 {
+		// A non nil empty map disables the automatic HTTP/2 upgrade.
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+	return &HTTPFetcher{
+		client:      &http.Client{Transport: transport, Timeout: config.Timeout},
+		maxBodySize: config.MaxBodySize,
+	}
+}
+
+// Fetch executes the request, the body of the response is truncated at the max body size.
+func (f *HTTPFetcher) Fetch(request *http.Request) (*http.Response, error) {
+	response, err := f.client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	if f.maxBodySize > 0 {
+		response.Body = &limitedBody{Reader: io.LimitReader(response.Body, f.maxBodySize), Closer: response.Body}
+	}
+	return response, nil
+}
+
+// CloseIdleConnections closes the idle connections of the fetcher.
+func (f *HTTPFetcher) CloseIdleConnections() {
+	f.client.CloseIdleConnections()
+}
+
+// limitedBody reads a response body up to a limit while closing the original body.
+type limitedBody struct {
+	io.Reader
+	io.Closer
+}
+
+// countingBody counts the bytes read from a response body.
+type countingBody struct {
+	io.ReadCloser
+	n int64
+}
+
+// Read implements the io.Reader interface.
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.n += int64(n)
+	return n, err
+}
@@ -0,0 +1,75 @@
+package webcrawler
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestHTTPFetcher_Fetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.Write([]byte(r.Header.Get("User-Agent") + " 0123456789"))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name        string
+		maxBodySize int64
+		want        string
+	}{
+		{name: "Full body", maxBodySize: 0, want: "testbot 0123456789"},
+		{name: "Truncated body", maxBodySize: 7, want: "testbot"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := DefaultTransportConfig()
+			config.MaxBodySize = tt.maxBodySize
+			response, err := FetchContext(context.Background(), NewHTTPFetcher(config), server.URL, "User-Agent", "testbot")
+			if err != nil {
+				t.Fatalf("HTTPFetcher.Fetch() error = %v", err)
+			}
+			defer response.Body.Close()
+			body, _ := ioutil.ReadAll(response.Body)
+			if string(body) != tt.want {
+				t.Errorf("HTTPFetcher.Fetch() body = %q, want %q", body, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebScraper_Fetcher(t *testing.T) {
+	var requested string
+	fetcher := FetcherFunc(func(request *http.Request) (*http.Response, error) {
+		requested = request.URL.String()
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{},
+			Body:       ioutil.NopCloser(strings.NewReader(`<div class="item"><span>RTX 3080</span></div>`)),
+			Request:    request,
+		}, nil
+	})
+	ws := &WebScraper{Logger: logrus.New(), Fetcher: fetcher}
+	itemsToGet := []ScrapeItemConfig{
+		{
+			ItemName:    "Graphics Cards",
+			ItemToGet:   ExtractFromTokenConfig{Tag: "div", Attribute: "class", AttributeValue: "item"},
+			ItemDetails: map[string]ExtractFromTokenConfig{"title": {Tag: "span"}},
+		},
+	}
+
+	response, err := ws.Scrape(&URL{RootURL: "https://www.example.com", CurrentURL: "https://www.example.com/gpus"}, itemsToGet)
+	if err != nil {
+		t.Fatalf("WebScraper.Scrape() error = %v", err)
+	}
+	if requested != "https://www.example.com/gpus" {
+		t.Errorf("WebScraper.Scrape() requested %v with the fetcher, want %v", requested, "https://www.example.com/gpus")
+	}
+	if len(response.ExtractedItem) != 1 {
+		t.Errorf("WebScraper.Scrape() extracted %v items, want 1", len(response.ExtractedItem))
+	}
+}
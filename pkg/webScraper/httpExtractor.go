@@ -15,6 +15,31 @@ type ExtractFromTokenConfig struct {
 	Attribute                    string              `json:"Attribute"`
 	AttributeValue               string              `json:"AttributeValue"`
 	AttributeToGet               string              `json:"AttributeToGet"`
+
+	// Selector is a CSS selector, see Selector, used instead of Tag, Attribute and AttributeValue. It is evaluated
+	// against the parsed DOM of the page, the item details of an item are matched within the item.
+	Selector string `json:"Selector"`
+
+	// XPath is an XPath 1.0 expression, see XPath, used instead of Tag, Attribute and AttributeValue. It is evaluated
+	// against the parsed DOM of the page with the item as the context node for item details, so .//span searches
+	// within the item. The value of an item detail is the string value of the first selected node, such as text() or
+	// @href, or the value of the expression, such as normalize-space(.//h3).
+	XPath string `json:"XPath"`
+
+	// Regex is a regular expression applied to the extracted text or attribute, named groups are written (?P<name>re).
+	// The value becomes the capture group named like the item detail, or else the first capture group, or else the
+	// whole match. Every other named group becomes an item detail of its own. Item details that do not match are
+	// skipped, as are urls.
+	Regex string `json:"Regex"`
+
+	// Schema maps the structured data of the page, see StructuredData, to items. In ItemToGet it is a schema.org type,
+	// such as Product, every JSON-LD, Microdata and RDFa object of the type becomes an item. In ItemDetails it is the
+	// dot separated path of the item detail within the object, such as offers.price.
+	Schema string `json:"Schema"`
+
+	// Multiple collects every value of the item detail into Item.ItemDetailLists, such as all image urls of an item,
+	// instead of the first value only. Values that do not pass the filter are skipped rather than the item.
+	Multiple bool `json:"Multiple"`
 }
 
 // extractAttributeValue given an token, extract the given attribute.
@@ -15,6 +15,13 @@ type Item struct {
 	TimeQueried string
 	DateQueried string
 	ItemDetails map[string]string
+
+	// ItemDetailLists holds every value of the item details configured with Multiple, in the order they were found.
+	// ItemDetails holds their first value.
+	ItemDetailLists map[string][]string `json:"ItemDetailLists,omitempty"`
+
+	// Children holds the items of the child item configs found within the item, by item name.
+	Children map[string][]Item `json:"Children,omitempty"`
 }
 
 //ScrapeItemConfig configuration used to extract item from html token
@@ -22,6 +29,62 @@ type ScrapeItemConfig struct {
 	ItemName    string                            `json:"ItemName"`
 	ItemToGet   ExtractFromTokenConfig            `json:"ItemToGet"`
 	ItemDetails map[string]ExtractFromTokenConfig `json:"ItemDetails"`
+
+	// Children are item configs matched within the item, such as the variants of a product with their own price and
+	// size. Their ItemToGet is matched within the element of the item, or within the object of the item for schemas.
+	// Items with children are evaluated against the parsed DOM of the page.
+	Children []ScrapeItemConfig `json:"Children"`
+}
+
+// newItem returns an item without item details, queried now.
+func newItem(itemName string) Item {
+	return Item{
+		ItemName:    itemName,
+		ItemDetails: make(map[string]string),
+		DateQueried: strings.Split(time.Now().String(), " ")[0],
+		TimeQueried: strings.Split(time.Now().String(), " ")[1],
+	}
+}
+
+// addItemDetail sets the item detail, unless it is already set. Every value of an item detail configured with Multiple
+// is also added to the list of values of the item detail.
+func addItemDetail(item *Item, itemDetailName, value string, config ExtractFromTokenConfig) {
+	if _, exist := item.ItemDetails[itemDetailName]; !exist {
+		item.ItemDetails[itemDetailName] = value
+	}
+	if config.Multiple {
+		if item.ItemDetailLists == nil {
+			item.ItemDetailLists = make(map[string][]string)
+		}
+		item.ItemDetailLists[itemDetailName] = append(item.ItemDetailLists[itemDetailName], value)
+	}
+}
+
+// addItemDetailValue adds a value of the item detail found in the DOM of the page to the item. The value goes through
+// the regular expression, the filter and the format of the item detail config. Values that do not match the regular expression are skipped, as are values of item details configured with Multiple
+// that do not pass the filter. Returns false if the value of an item detail with a single value does not pass the
+// filter, which rejects the whole item.
+func addItemDetailValue(item *Item, scrapeItemConfig ScrapeItemConfig, itemDetailName, value string) bool {
+	config := scrapeItemConfig.ItemDetails[itemDetailName]
+	value, groups, matched := matchRegex(itemDetailName, value, config)
+	if !matched {
+		return true
+	}
+	value, ok := formatValue(value, config)
+	if !ok {
+		return config.Multiple
+	}
+	addItemDetail(item, itemDetailName, value, config)
+	addRegexGroups(item, groups, scrapeItemConfig)
+	return true
+}
+
+// addChild adds the item of a child item config to the children of the item.
+func addChild(item *Item, child Item) {
+	if item.Children == nil {
+		item.Children = make(map[string][]Item)
+	}
+	item.Children[child.ItemName] = append(item.Children[child.ItemName], child)
 }
 
 // ExtractItemWithScrapItemConfig extracts item from html token using a list of scrape item config which allows
@@ -55,12 +118,7 @@ func parseTokenForItemDetails(token html.Token, z *html.Tokenizer, scrapeItemCon
 		currentToken          html.Token
 		tagStack              stack
 		itemDetailTagsToCheck map[string]bool
-		item                  Item = Item{
-			ItemName:    scrapeItemConfig.ItemName,
-			ItemDetails: make(map[string]string),
-			DateQueried: strings.Split(time.Now().String(), " ")[0],
-			TimeQueried: strings.Split(time.Now().String(), " ")[1],
-		}
+		item                  Item = newItem(scrapeItemConfig.ItemName)
 	)
 
 	if token.Type != html.StartTagToken {
@@ -81,43 +139,43 @@ func parseTokenForItemDetails(token html.Token, z *html.Tokenizer, scrapeItemCon
 				for itemDetailName, itemDetails := range scrapeItemConfig.ItemDetails {
 					HTTPAttributeValueFromToken, _ := extractAttributeValue(currentToken, itemDetails.Attribute)
 					if (itemDetails.Tag == currentToken.Data && itemDetails.AttributeValue == HTTPAttributeValueFromToken) || (itemDetails.Tag == currentToken.Data && itemDetails.Attribute == "" && itemDetails.AttributeValue == "") {
-						if _, exist := item.ItemDetails[itemDetailName]; exist {
+						if _, exist := item.ItemDetails[itemDetailName]; exist && !itemDetails.Multiple {
 							return item, nil
 						}
 						if itemDetails.AttributeToGet != "" {
 							HTTPAttributeValueFromToken, _ = extractAttributeValue(currentToken, itemDetails.AttributeToGet)
+							value, groups, matched := matchRegex(itemDetailName, HTTPAttributeValueFromToken, itemDetails)
+							if !matched {
+								continue
+							}
+							HTTPAttributeValueFromToken = value
 							if !IsEmpty(itemDetails.FormatAttributeConfiguration) {
 								HTTPAttributeValueFromToken = formatURL(HTTPAttributeValueFromToken, itemDetails.FormatAttributeConfiguration)
 							}
-							item.ItemDetails[itemDetailName] = HTTPAttributeValueFromToken
+							addItemDetail(&item, itemDetailName, HTTPAttributeValueFromToken, itemDetails)
+							addRegexGroups(&item, groups, scrapeItemConfig)
 						} else {
-							if itemDetails.SkipToken != 0 {
-								for itemDetails.SkipToken >= 0 {
-									tokenType = z.Next()
-									if tokenType == html.TextToken {
-										itemDetails.SkipToken--
-										continue
-									}
-								}
-							} else {
-								for tokenType != html.TextToken {
-									tokenType = z.Next()
-								}
+							// The page ends before the text of the item detail, the item is complete.
+							if !nextTextToken(z, itemDetails.SkipToken) {
+								return item, nil
 							}
 							currentToken = z.Token()
-							str := currentToken.String()
-							if !IsEmpty(itemDetails.FormatAttributeConfiguration) {
-								HTTPAttributeValueFromToken = formatURL(str, itemDetails.FormatAttributeConfiguration)
+							str, groups, matched := matchRegex(itemDetailName, currentToken.String(), itemDetails)
+							if !matched {
+								continue
 							}
-
 							if !IsEmpty(itemDetails.ItemFilterConfiguration) {
 								if !Validate(str, &itemDetails.ItemFilterConfiguration) {
+									// Values of item details with several values that do not pass the filter are skipped.
+									if itemDetails.Multiple {
+										continue
+									}
 									return Item{}, nil
 								}
 							}
-							item.ItemDetails[itemDetailName] = str
+							addItemDetail(&item, itemDetailName, str, itemDetails)
+							addRegexGroups(&item, groups, scrapeItemConfig)
 						}
-
 					}
 				}
 			}
@@ -132,6 +190,22 @@ func parseTokenForItemDetails(token html.Token, z *html.Tokenizer, scrapeItemCon
 	return item, nil
 }
 
+// nextTextToken moves the tokenizer to the next text token, skipping the given number of text tokens first. Returns
+// false if the page ends before.
+func nextTextToken(z *html.Tokenizer, skip int) bool {
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			return false
+		case html.TextToken:
+			if skip <= 0 {
+				return true
+			}
+			skip--
+		}
+	}
+}
+
 // generateItemDetailsTagsToCheckMap generates a map of tags to check given the scrape item configuration. The map is used to check
 // whether or not the html element should be used to extract from
 func generateItemDetailsTagsToCheckMap(itemDetailTagsToCheck map[string]bool, scrapeItemConfig ScrapeItemConfig) (map[string]bool, error) {
@@ -0,0 +1,246 @@
+package webcrawler
+
+import (
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const itemTestPage = `<html><head><script type="application/ld+json">
+{"@context": "https://schema.org", "@type": "ProductGroup", "name": "Shirt", "image": ["/a.png", "/b.png"],
+ "hasVariant": [
+	{"@type": "Product", "size": "S", "offers": {"@type": "Offer", "price": 10}},
+	{"@type": "Product", "size": "M", "offers": {"@type": "Offer", "price": 12}}
+]}
+</script></head><body>
+<div class="product"><h3>Shirt</h3>
+<img class="image" src="/a.png"><img class="image" src="/b.png">
+<ul class="specs"><li>Cotton</li><li>Blue</li></ul>
+<div class="variant"><span class="size">S</span><span class="price">10</span></div>
+<div class="variant"><span class="size">M</span><span class="price">12</span></div>
+<div class="variant"><span class="size">XL</span><span class="price">30</span></div>
+</div>
+</body></html>`
+
+func TestWebScraper_ScrapeMultipleAndChildren(t *testing.T) {
+	fetcher := FetcherFunc(func(request *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{},
+			Body:       ioutil.NopCloser(strings.NewReader(itemTestPage)),
+			Request:    request,
+		}, nil
+	})
+	ws := &WebScraper{Logger: logrus.New(), Fetcher: fetcher}
+	cheap := FilterConfiguration{IsLessThan: 20.0, ConvertStringToNumber: "true"}
+	variants := []ScrapeItemConfig{{
+		ItemName:  "Variant",
+		ItemToGet: ExtractFromTokenConfig{Selector: "div.variant"},
+		ItemDetails: map[string]ExtractFromTokenConfig{
+			"size":  {Selector: ".size"},
+			"price": {Selector: ".price", ItemFilterConfiguration: cheap},
+		},
+	}}
+	wantVariants := map[string][]Item{"Variant": {
+		{ItemName: "Variant", ItemDetails: map[string]string{"size": "S", "price": "10"}},
+		{ItemName: "Variant", ItemDetails: map[string]string{"size": "M", "price": "12"}},
+	}}
+	tests := []struct {
+		name          string
+		itemToGet     ExtractFromTokenConfig
+		itemDetails   map[string]ExtractFromTokenConfig
+		children      []ScrapeItemConfig
+		wantDetails   map[string]string
+		wantLists     map[string][]string
+		wantChildren  map[string][]Item
+		wantItemCount int
+	}{
+		{
+			name:      "Tokens",
+			itemToGet: ExtractFromTokenConfig{Tag: "div", Attribute: "class", AttributeValue: "product"},
+			itemDetails: map[string]ExtractFromTokenConfig{
+				"title":  {Tag: "h3"},
+				"images": {Tag: "img", Attribute: "class", AttributeValue: "image", AttributeToGet: "src", Multiple: true},
+				"specs":  {Tag: "li", Multiple: true},
+			},
+			wantDetails: map[string]string{"title": "Shirt", "images": "/a.png", "specs": "Cotton"},
+			wantLists:   map[string][]string{"images": {"/a.png", "/b.png"}, "specs": {"Cotton", "Blue"}},
+		},
+		{
+			name:      "DOM",
+			itemToGet: ExtractFromTokenConfig{Selector: "div.product"},
+			itemDetails: map[string]ExtractFromTokenConfig{
+				"title":  {Selector: "h3"},
+				"images": {Selector: "img.image", AttributeToGet: "src", Multiple: true},
+				"prices": {XPath: ".//span[@class='price']", Multiple: true, ItemFilterConfiguration: cheap},
+			},
+			children:     variants,
+			wantDetails:  map[string]string{"title": "Shirt", "images": "/a.png", "prices": "10"},
+			wantLists:    map[string][]string{"images": {"/a.png", "/b.png"}, "prices": {"10", "12"}},
+			wantChildren: wantVariants,
+		},
+		{
+			name:         "Tokens with children",
+			itemToGet:    ExtractFromTokenConfig{Tag: "div", Attribute: "class", AttributeValue: "product"},
+			itemDetails:  map[string]ExtractFromTokenConfig{"title": {Tag: "h3"}},
+			children:     variants,
+			wantDetails:  map[string]string{"title": "Shirt"},
+			wantChildren: wantVariants,
+		},
+		{
+			name:      "Schema",
+			itemToGet: ExtractFromTokenConfig{Schema: "ProductGroup"},
+			itemDetails: map[string]ExtractFromTokenConfig{
+				"title":  {Schema: "name"},
+				"images": {Schema: "image", Multiple: true},
+			},
+			children: []ScrapeItemConfig{{
+				ItemName:  "Variant",
+				ItemToGet: ExtractFromTokenConfig{Schema: "Product"},
+				ItemDetails: map[string]ExtractFromTokenConfig{
+					"size":  {Schema: "size"},
+					"price": {Schema: "offers.price"},
+				},
+			}},
+			wantDetails:  map[string]string{"title": "Shirt", "images": "/a.png"},
+			wantLists:    map[string][]string{"images": {"/a.png", "/b.png"}},
+			wantChildren: wantVariants,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			itemsToGet := []ScrapeItemConfig{{ItemName: "Product", ItemToGet: tt.itemToGet, ItemDetails: tt.itemDetails, Children: tt.children}}
+			response, err := ws.Scrape(&URL{CurrentURL: "https://www.example.io/"}, itemsToGet)
+			if err != nil {
+				t.Fatalf("WebScraper.Scrape() error = %v", err)
+			}
+			if len(response.ExtractedItem) != 1 {
+				t.Fatalf("WebScraper.Scrape() = %v items, want 1", len(response.ExtractedItem))
+			}
+			item := response.ExtractedItem[0]
+			if !reflect.DeepEqual(item.ItemDetails, tt.wantDetails) {
+				t.Errorf("WebScraper.Scrape() item details = %v, want %v", item.ItemDetails, tt.wantDetails)
+			}
+			if !reflect.DeepEqual(item.ItemDetailLists, tt.wantLists) {
+				t.Errorf("WebScraper.Scrape() item detail lists = %v, want %v", item.ItemDetailLists, tt.wantLists)
+			}
+			children := make(map[string][]Item)
+			for name, items := range item.Children {
+				for _, child := range items {
+					children[name] = append(children[name], Item{ItemName: child.ItemName, ItemDetails: child.ItemDetails})
+				}
+			}
+			if len(children) == 0 {
+				children = nil
+			}
+			if !reflect.DeepEqual(children, tt.wantChildren) {
+				t.Errorf("WebScraper.Scrape() children = %v, want %v", children, tt.wantChildren)
+			}
+		})
+	}
+
+	invalid := []ScrapeItemConfig{{ItemToGet: ExtractFromTokenConfig{Schema: "ProductGroup"}, Children: variants}}
+	if err := ValidateScrapeConfigs(invalid, nil); err == nil {
+		t.Errorf("ValidateScrapeConfigs() error = nil, want error for child without schema of an item with schema")
+	}
+}
+
+func TestWebScraper_ScrapeItemDetailsFromTokensAndDOM(t *testing.T) {
+	body := `<div class="product"><h3> Fish &amp; Chips </h3><a class="link" href="/p/1?a=1&amp;b=2">link</a><span class="price">$12</span></div>`
+	fetcher := FetcherFunc(func(request *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{},
+			Body:       ioutil.NopCloser(strings.NewReader(body)),
+			Request:    request,
+		}, nil
+	})
+	ws := &WebScraper{Logger: logrus.New(), Fetcher: fetcher}
+	details := map[string]ExtractFromTokenConfig{
+		"title": {Tag: "h3", Regex: `^Fish & (\w+)$`, FormatAttributeConfiguration: FormatURLConfig{PrefixToAdd: "Fried "}},
+		"link":  {Tag: "a", Attribute: "class", AttributeValue: "link", AttributeToGet: "href", Regex: `&b=(\d+)`, FormatAttributeConfiguration: FormatURLConfig{PrefixToAdd: "#"}},
+		"price": {Tag: "span", Attribute: "class", AttributeValue: "price", FormatAttributeConfiguration: FormatURLConfig{PrefixToRemove: "$"}},
+	}
+	tests := []struct {
+		name      string
+		itemToGet ExtractFromTokenConfig
+		want      map[string]string
+	}{
+		{
+			// Texts of tokens are raw html, without the format of the item detail.
+			name:      "Tokens",
+			itemToGet: ExtractFromTokenConfig{Tag: "div", Attribute: "class", AttributeValue: "product"},
+			want:      map[string]string{"link": "#2", "price": "$12"},
+		},
+		{
+			name:      "DOM",
+			itemToGet: ExtractFromTokenConfig{Selector: "div.product"},
+			want:      map[string]string{"title": "Fried Chips", "link": "#2", "price": "12"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			itemsToGet := []ScrapeItemConfig{{ItemName: "Product", ItemToGet: tt.itemToGet, ItemDetails: details}}
+			response, err := ws.Scrape(&URL{CurrentURL: "https://www.example.io/"}, itemsToGet)
+			if err != nil {
+				t.Fatalf("WebScraper.Scrape() error = %v", err)
+			}
+			if len(response.ExtractedItem) != 1 || !reflect.DeepEqual(response.ExtractedItem[0].ItemDetails, tt.want) {
+				t.Errorf("WebScraper.Scrape() items = %v, want one item with item details %v", response.ExtractedItem, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebScraper_ScrapeItemDetailsAtEndOfPage(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		details map[string]ExtractFromTokenConfig
+		want    map[string]string
+	}{
+		{
+			name:    "Item detail tag is the last token",
+			body:    `<div class="product"><span class="name">RTX 3080</span><span class="price">`,
+			details: map[string]ExtractFromTokenConfig{"name": {Tag: "span", Attribute: "class", AttributeValue: "name"}, "price": {Tag: "span", Attribute: "class", AttributeValue: "price"}},
+			want:    map[string]string{"name": "RTX 3080"},
+		},
+		{
+			name:    "Skip token beyond the last text",
+			body:    `<div class="product"><span class="name">RTX 3080</span><span class="price">$699</span></div>`,
+			details: map[string]ExtractFromTokenConfig{"name": {Tag: "span", Attribute: "class", AttributeValue: "name"}, "price": {Tag: "span", Attribute: "class", AttributeValue: "price", SkipToken: 5}},
+			want:    map[string]string{"name": "RTX 3080"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := tt.body
+			fetcher := FetcherFunc(func(request *http.Request) (*http.Response, error) {
+				return &http.Response{StatusCode: http.StatusOK, Header: http.Header{}, Body: ioutil.NopCloser(strings.NewReader(body)), Request: request}, nil
+			})
+			ws := &WebScraper{Logger: logrus.New(), Fetcher: fetcher}
+			itemsToGet := []ScrapeItemConfig{{ItemName: "Product", ItemToGet: ExtractFromTokenConfig{Tag: "div", Attribute: "class", AttributeValue: "product"}, ItemDetails: tt.details}}
+			done := make(chan *Response, 1)
+			go func() {
+				response, err := ws.Scrape(&URL{CurrentURL: "https://www.example.io/"}, itemsToGet)
+				if err != nil {
+					t.Errorf("WebScraper.Scrape() error = %v", err)
+				}
+				done <- response
+			}()
+			select {
+			case response := <-done:
+				if response == nil || len(response.ExtractedItem) != 1 || !reflect.DeepEqual(response.ExtractedItem[0].ItemDetails, tt.want) {
+					t.Errorf("WebScraper.Scrape() = %+v, want one item with item details %v", response, tt.want)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("WebScraper.Scrape() did not return at the end of the page")
+			}
+		})
+	}
+}
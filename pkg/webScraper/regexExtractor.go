@@ -0,0 +1,78 @@
+package webcrawler
+
+import (
+	"regexp"
+	"sync"
+)
+
+// regexCache holds the compiled regular expressions, so that the regular expressions of a crawl are only compiled once.
+var regexCache sync.Map
+
+// compileRegex compiles the regular expression of a config.
+func compileRegex(expr string) (*regexp.Regexp, error) {
+	if re, ok := regexCache.Load(expr); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	regexCache.Store(expr, re)
+	return re, nil
+}
+
+// matchRegex applies the regular expression of the config to the value extracted for the item detail. The value of
+// the item detail becomes the capture group named like the item detail, or else the first capture group, or else the
+// whole match. The other named groups that took part in the match are returned to become item details of their own.
+// Returns false if the value does not match. Values are returned as is if the config has no regular expression.
+func matchRegex(itemDetailName, value string, config ExtractFromTokenConfig) (string, map[string]string, bool) {
+	if config.Regex == "" {
+		return value, nil, true
+	}
+	re, err := compileRegex(config.Regex)
+	if err != nil {
+		return "", nil, false
+	}
+	match := re.FindStringSubmatchIndex(value)
+	if match == nil {
+		return "", nil, false
+	}
+	group := func(i int) (string, bool) {
+		if match[2*i] < 0 {
+			return "", false
+		}
+		return value[match[2*i]:match[2*i+1]], true
+	}
+	result, _ := group(0)
+	if re.NumSubexp() > 0 {
+		if first, ok := group(1); ok {
+			result = first
+		}
+	}
+	var groups map[string]string
+	for i, name := range re.SubexpNames() {
+		groupValue, ok := group(i)
+		if i == 0 || name == "" || !ok {
+			continue
+		}
+		if name == itemDetailName {
+			result = groupValue
+			continue
+		}
+		if groups == nil {
+			groups = make(map[string]string)
+		}
+		groups[name] = groupValue
+	}
+	return result, groups, true
+}
+
+// addRegexGroups adds the named groups of an item detail to the item details of the item. A group does not replace an
+// item detail configured with the same name.
+func addRegexGroups(item *Item, groups map[string]string, scrapeItemConfig ScrapeItemConfig) {
+	for name, value := range groups {
+		if _, configured := scrapeItemConfig.ItemDetails[name]; !configured {
+			item.ItemDetails[name] = value
+		}
+	}
+}
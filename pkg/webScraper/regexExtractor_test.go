@@ -0,0 +1,114 @@
+package webcrawler
+
+import (
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func Test_matchRegex(t *testing.T) {
+	tests := []struct {
+		name        string
+		value       string
+		regex       string
+		want        string
+		wantGroups  map[string]string
+		wantMatched bool
+	}{
+		{name: "rating", value: "4.5 out of 5 stars", want: "4.5 out of 5 stars", wantMatched: true},
+		{name: "rating", value: "4.5 out of 5 stars", regex: `[\d.]+`, want: "4.5", wantMatched: true},
+		{name: "rating", value: "4.5 out of 5 stars", regex: `([\d.]+) out of`, want: "4.5", wantMatched: true},
+		{
+			name:        "rating",
+			value:       "4.5 out of 5 stars",
+			regex:       `(?P<rating>[\d.]+) out of (?P<max>\d+)`,
+			want:        "4.5",
+			wantGroups:  map[string]string{"max": "5"},
+			wantMatched: true,
+		},
+		{
+			name:        "stars",
+			value:       "4.5 out of 5 stars",
+			regex:       `(?P<rating>[\d.]+) out of (?P<max>\d+)(?P<suffix> reviews)?`,
+			want:        "4.5",
+			wantGroups:  map[string]string{"rating": "4.5", "max": "5"},
+			wantMatched: true,
+		},
+		{name: "rating", value: "No reviews", regex: `[\d.]+`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.regex, func(t *testing.T) {
+			got, groups, matched := matchRegex(tt.name, tt.value, ExtractFromTokenConfig{Regex: tt.regex})
+			if got != tt.want || !reflect.DeepEqual(groups, tt.wantGroups) || matched != tt.wantMatched {
+				t.Errorf("matchRegex() = %v, %v, %v, want %v, %v, %v", got, groups, matched, tt.want, tt.wantGroups, tt.wantMatched)
+			}
+		})
+	}
+}
+
+func TestWebScraper_ScrapeRegex(t *testing.T) {
+	body := `<div class="product"><h3>Card</h3><span class="rating">4.5 out of 5 stars</span><a class="link" href="/p/123?ref=x">a</a></div>
+<div class="product"><h3>Fan</h3><span class="rating">No reviews</span><a class="link" href="/p/456">a</a></div>`
+	fetcher := FetcherFunc(func(request *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{},
+			Body:       ioutil.NopCloser(strings.NewReader(body)),
+			Request:    request,
+		}, nil
+	})
+	ws := &WebScraper{Logger: logrus.New(), Fetcher: fetcher}
+	details := map[string]ExtractFromTokenConfig{
+		"rating": {Tag: "span", Attribute: "class", AttributeValue: "rating", Regex: `(?P<rating>[\d.]+) out of (?P<max>\d+)`},
+		"id":     {Tag: "a", Attribute: "class", AttributeValue: "link", AttributeToGet: "href", Regex: `/p/(\d+)`},
+	}
+	want := []map[string]string{{"rating": "4.5", "max": "5", "id": "123"}, {"id": "456"}}
+	tests := []struct {
+		name      string
+		itemToGet ExtractFromTokenConfig
+	}{
+		{name: "Tokens", itemToGet: ExtractFromTokenConfig{Tag: "div", Attribute: "class", AttributeValue: "product"}},
+		{name: "DOM", itemToGet: ExtractFromTokenConfig{Selector: "div.product"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			itemsToGet := []ScrapeItemConfig{{ItemName: "Product", ItemToGet: tt.itemToGet, ItemDetails: details}}
+			urlsToGet := []ScrapeURLConfig{{ExtractFromTokenConfig: ExtractFromTokenConfig{Tag: "a", Attribute: "class", AttributeValue: "link", Regex: `^/p/\d+`}}}
+			response, err := ws.Scrape(&URL{CurrentURL: "https://www.example.io/"}, itemsToGet, urlsToGet...)
+			if err != nil {
+				t.Fatalf("WebScraper.Scrape() error = %v", err)
+			}
+			var got []map[string]string
+			for _, item := range response.ExtractedItem {
+				got = append(got, item.ItemDetails)
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("WebScraper.Scrape() item details = %v, want %v", got, want)
+			}
+		})
+	}
+
+	t.Run("URLs", func(t *testing.T) {
+		urlsToGet := []ScrapeURLConfig{{ExtractFromTokenConfig: ExtractFromTokenConfig{Tag: "a", Attribute: "class", AttributeValue: "link", Regex: `^/p/\d+`}}}
+		response, err := ws.Scrape(&URL{CurrentURL: "https://www.example.io/"}, nil, urlsToGet...)
+		if err != nil {
+			t.Fatalf("WebScraper.Scrape() error = %v", err)
+		}
+		var got []string
+		for _, u := range response.ExtractedURLs {
+			got = append(got, u.CurrentURL)
+		}
+		if want := []string{"https://www.example.io/p/123", "https://www.example.io/p/456"}; !reflect.DeepEqual(got, want) {
+			t.Errorf("WebScraper.Scrape() urls = %v, want %v", got, want)
+		}
+	})
+
+	invalid := []ScrapeItemConfig{{ItemDetails: map[string]ExtractFromTokenConfig{"rating": {Regex: `(`}}}}
+	if err := ValidateScrapeConfigs(invalid, nil); err == nil {
+		t.Errorf("ValidateScrapeConfigs() error = nil, want error for invalid regex")
+	}
+}
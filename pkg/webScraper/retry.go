@@ -0,0 +1,231 @@
+package webcrawler
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ErrorKind classifies the error of a failed request.
+type ErrorKind string
+
+const (
+	// ErrorKindTimeout is a request that timed out.
+	ErrorKindTimeout ErrorKind = "timeout"
+
+	// ErrorKindDNS is a host name that could not be resolved.
+	ErrorKindDNS ErrorKind = "dns"
+
+	// ErrorKindConnection is a connection that could not be established or was reset.
+	ErrorKindConnection ErrorKind = "connection"
+
+	// ErrorKindTLS is a failed TLS handshake or certificate verification.
+	ErrorKindTLS ErrorKind = "tls"
+
+	// ErrorKindStatus is a response with an error status code.
+	ErrorKindStatus ErrorKind = "status"
+
+	// ErrorKindThrottled is a 429 or 503 response.
+	ErrorKindThrottled ErrorKind = "throttled"
+
+	// ErrorKindCanceled is a request that was aborted because the crawl was stopped.
+	ErrorKindCanceled ErrorKind = "canceled"
+
+	// ErrorKindOther is any other error.
+	ErrorKindOther ErrorKind = "other"
+)
+
+// StatusError is returned by ScrapeContext when the website responds with an error status code, 400 or above, that is
+// not retryable or still is after every attempt of the retry policy. The response is not parsed.
+type StatusError struct {
+	URL        string
+	StatusCode int
+}
+
+// Error implements the error interface.
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("request to %v failed with status code %v", e.URL, e.StatusCode)
+}
+
+// RetryPolicy configures how the web scraper retries failed requests.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts per url, including the first one. 1 or less disables retries.
+	MaxAttempts int
+
+	// InitialBackoff is the delay before the first retry, it is multiplied by Multiplier for every further retry up
+	// to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+	Multiplier     float64
+
+	// Jitter randomizes every backoff by up to the given fraction of it in both directions, 0.2 means ±20%. The
+	// randomized backoff is still capped at MaxBackoff.
+	Jitter float64
+
+	// RetryableStatusCodes are the status codes of responses that are retried.
+	RetryableStatusCodes []int
+
+	// RetryableErrors are the kinds of transport errors that are retried.
+	RetryableErrors []ErrorKind
+}
+
+// DefaultRetryPolicy returns the retry policy used by the web crawler unless configured otherwise. It retries
+// timeouts, connection errors and transient server errors up to 3 attempts. 429 and 503 responses are not retried,
+// the web crawler slows down the host instead.
+func DefaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:          3,
+		InitialBackoff:       500 * time.Millisecond,
+		MaxBackoff:           10 * time.Second,
+		Multiplier:           2,
+		Jitter:               0.2,
+		RetryableStatusCodes: []int{http.StatusRequestTimeout, http.StatusInternalServerError, http.StatusBadGateway, http.StatusGatewayTimeout},
+		RetryableErrors:      []ErrorKind{ErrorKindTimeout, ErrorKindConnection},
+	}
+}
+
+// Clone returns a deep copy of the retry policy.
+func (p *RetryPolicy) Clone() *RetryPolicy {
+	if p == nil {
+		return nil
+	}
+	clone := *p
+	clone.RetryableStatusCodes = append([]int(nil), p.RetryableStatusCodes...)
+	clone.RetryableErrors = append([]ErrorKind(nil), p.RetryableErrors...)
+	return &clone
+}
+
+// Backoff returns the delay before the given retry, starting at 1 for the first retry.
+func (p *RetryPolicy) Backoff(retry int) time.Duration {
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+	backoff := float64(p.InitialBackoff) * math.Pow(multiplier, float64(retry-1))
+	if p.Jitter > 0 {
+		backoff += backoff * p.Jitter * (2*rand.Float64() - 1)
+	}
+	// The cap applies after the jitter, so that no backoff exceeds MaxBackoff.
+	if p.MaxBackoff > 0 && backoff > float64(p.MaxBackoff) {
+		backoff = float64(p.MaxBackoff)
+	}
+	return time.Duration(backoff)
+}
+
+// IsRetryableStatusCode checks if responses with the status code are retried.
+func (p *RetryPolicy) IsRetryableStatusCode(statusCode int) bool {
+	for _, retryable := range p.RetryableStatusCodes {
+		if retryable == statusCode {
+			return true
+		}
+	}
+	return false
+}
+
+// IsRetryableError checks if the transport error is retried.
+func (p *RetryPolicy) IsRetryableError(err error) bool {
+	kind := ClassifyError(err)
+	for _, retryable := range p.RetryableErrors {
+		if retryable == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// ClassifyError returns the kind of the error of a failed request.
+func ClassifyError(err error) ErrorKind {
+	var (
+		throttledError *ThrottledError
+		statusError    *StatusError
+		dnsError       *net.DNSError
+		netError       net.Error
+		opError        *net.OpError
+		certError      x509.UnknownAuthorityError
+		hostnameError  x509.HostnameError
+		invalidError   x509.CertificateInvalidError
+	)
+	switch {
+	case err == nil:
+		return ""
+	case errors.As(err, &throttledError):
+		return ErrorKindThrottled
+	case errors.As(err, &statusError):
+		return ErrorKindStatus
+	case errors.Is(err, context.Canceled):
+		return ErrorKindCanceled
+	case errors.Is(err, context.DeadlineExceeded):
+		return ErrorKindTimeout
+	case errors.As(err, &dnsError):
+		return ErrorKindDNS
+	case errors.As(err, &certError), errors.As(err, &hostnameError), errors.As(err, &invalidError):
+		return ErrorKindTLS
+	case errors.As(err, &netError) && netError.Timeout():
+		return ErrorKindTimeout
+	case errors.Is(err, syscall.ECONNREFUSED), errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.EPIPE),
+		errors.As(err, &opError):
+		return ErrorKindConnection
+	}
+	return ErrorKindOther
+}
+
+// fetcher returns the fetcher of the web scraper.
+func (ws *WebScraper) fetcher() Fetcher {
+	if ws.Fetcher == nil {
+		return DefaultFetcher
+	}
+	return ws.Fetcher
+}
+
+// logger returns the logger of the web scraper, the standard logger if none is set.
+func (ws *WebScraper) logger() *logrus.Logger {
+	if ws.Logger == nil {
+		return logrus.StandardLogger()
+	}
+	return ws.Logger
+}
+
+// connect executes the http request to the url following the retry policy of the web scraper. Returns the final
+// response along with the number of retries.
+func (ws *WebScraper) connect(ctx context.Context, rawURL string) (*http.Response, int, error) {
+	for attempt := 1; ; attempt++ {
+		response, err := FetchContext(ctx, ws.fetcher(), rawURL, ws.HeaderKey, ws.HeaderValue)
+		policy := ws.RetryPolicy
+		if policy == nil || attempt >= policy.MaxAttempts || ctx.Err() != nil {
+			return response, attempt - 1, err
+		}
+
+		backoff := policy.Backoff(attempt)
+		if err == nil {
+			if !policy.IsRetryableStatusCode(response.StatusCode) {
+				return response, attempt - 1, nil
+			}
+			if retryAfter := ParseRetryAfter(response.Header.Get("Retry-After"), time.Now()); retryAfter > backoff {
+				// A website cannot hold up the web scraper longer than the max backoff of the retry policy.
+				backoff = retryAfter
+				if policy.MaxBackoff > 0 && backoff > policy.MaxBackoff {
+					backoff = policy.MaxBackoff
+				}
+			}
+			response.Body.Close()
+		} else if !policy.IsRetryableError(err) {
+			return response, attempt - 1, err
+		}
+
+		ws.logger().WithError(err).WithField("url", rawURL).Debugf("Retrying request in %v, attempt %v of %v", backoff, attempt+1, policy.MaxAttempts)
+		select {
+		case <-time.After(backoff):
+		case <-ctx.Done():
+			return nil, attempt - 1, ctx.Err()
+		}
+	}
+}
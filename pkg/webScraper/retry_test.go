@@ -0,0 +1,135 @@
+package webcrawler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestRetryPolicy_Backoff(t *testing.T) {
+	policy := &RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: time.Second, Multiplier: 2, Jitter: 0.2}
+	tests := []struct {
+		name  string
+		retry int
+		want  time.Duration
+	}{
+		{name: "First retry", retry: 1, want: 100 * time.Millisecond},
+		{name: "Exponential", retry: 3, want: 400 * time.Millisecond},
+		{name: "Capped", retry: 10, want: time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			min, max := time.Duration(float64(tt.want)*0.8), time.Duration(float64(tt.want)*1.2)
+			if max > policy.MaxBackoff {
+				max = policy.MaxBackoff
+			}
+			// The jitter is random, every backoff must be within its range.
+			for i := 0; i < 20; i++ {
+				if got := policy.Backoff(tt.retry); got < min || got > max {
+					t.Fatalf("RetryPolicy.Backoff() = %v, want between %v and %v", got, min, max)
+				}
+			}
+		})
+	}
+}
+
+func TestClassifyError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want ErrorKind
+	}{
+		{name: "Throttled", err: &ThrottledError{StatusCode: http.StatusTooManyRequests}, want: ErrorKindThrottled},
+		{name: "Status", err: &StatusError{StatusCode: http.StatusBadGateway}, want: ErrorKindStatus},
+		{name: "Canceled", err: fmt.Errorf("get: %w", context.Canceled), want: ErrorKindCanceled},
+		{name: "DNS", err: &net.DNSError{Err: "no such host", Name: "example.invalid"}, want: ErrorKindDNS},
+		{name: "Connection", err: &net.OpError{Op: "dial", Err: errors.New("connection refused")}, want: ErrorKindConnection},
+		{name: "Other", err: errors.New("unsupported protocol scheme"), want: ErrorKindOther},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ClassifyError(tt.err); got != tt.want {
+				t.Errorf("ClassifyError() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebScraper_connect(t *testing.T) {
+	var requests int32
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/flaky":
+			if atomic.AddInt32(&requests, 1) < 3 {
+				rw.WriteHeader(http.StatusBadGateway)
+				return
+			}
+		case "/missing":
+			rw.WriteHeader(http.StatusNotFound)
+			return
+		case "/broken":
+			rw.WriteHeader(http.StatusInternalServerError)
+			return
+		case "/slow-down":
+			rw.Header().Set("Retry-After", "3600")
+			rw.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		rw.Write([]byte("<html></html>"))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name           string
+		path           string
+		wantStatusCode int
+		wantRetries    int
+	}{
+		{name: "Retried until success", path: "/flaky", wantStatusCode: http.StatusOK, wantRetries: 2},
+		{name: "Not retryable", path: "/missing", wantStatusCode: http.StatusNotFound, wantRetries: 0},
+		{name: "Retries exhausted", path: "/broken", wantStatusCode: http.StatusInternalServerError, wantRetries: 2},
+		{name: "Retry-After capped by max backoff", path: "/slow-down", wantStatusCode: http.StatusBadGateway, wantRetries: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := DefaultRetryPolicy()
+			policy.InitialBackoff = 10 * time.Millisecond
+			policy.MaxBackoff = 20 * time.Millisecond
+			ws := &WebScraper{HeaderKey: "User-Agent", HeaderValue: "test", RetryPolicy: policy}
+			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			defer cancel()
+			response, retries, err := ws.connect(ctx, server.URL+tt.path)
+			if err != nil {
+				t.Fatalf("WebScraper.connect() error = %v", err)
+			}
+			response.Body.Close()
+			if response.StatusCode != tt.wantStatusCode || retries != tt.wantRetries {
+				t.Errorf("WebScraper.connect() status code = %v, retries = %v, want %v and %v", response.StatusCode, retries, tt.wantStatusCode, tt.wantRetries)
+			}
+		})
+	}
+}
+
+func TestWebScraper_ScrapeStatusError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		rw.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	for _, policy := range []*RetryPolicy{DefaultRetryPolicy(), nil} {
+		ws := &WebScraper{Logger: logrus.New(), RetryPolicy: policy}
+		response, err := ws.Scrape(&URL{CurrentURL: server.URL + "/missing"}, nil)
+		var statusError *StatusError
+		if !errors.As(err, &statusError) || statusError.StatusCode != http.StatusNotFound || response.StatusCode != http.StatusNotFound {
+			t.Errorf("WebScraper.Scrape() with retry policy %v error = %v, want *StatusError with status code 404", policy, err)
+		}
+	}
+}
@@ -0,0 +1,549 @@
+package webcrawler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"golang.org/x/net/html"
+)
+
+// selectorCache holds the compiled selectors, so that the selectors of a crawl are only compiled once.
+var selectorCache sync.Map
+
+// Selector is a compiled CSS selector. It supports type, universal, class, id and attribute selectors with the =, ~=,
+// |=, ^=, $= and *= operators and the i flag, the descendant, child, next sibling and subsequent sibling combinators,
+// selector lists and the :first-child, :last-child, :only-child, :nth-child(), :nth-last-child(), :first-of-type,
+// :last-of-type, :nth-of-type(), :nth-last-of-type(), :empty and :not() pseudo classes.
+type Selector struct {
+	source    string
+	selectors []complexSelector
+}
+
+// complexSelector is a sequence of compound selectors joined by combinators, combinators[i] joins compounds[i] and
+// compounds[i+1].
+type complexSelector struct {
+	compounds   []compoundSelector
+	combinators []byte
+}
+
+// compoundSelector is a sequence of simple selectors that must all match the same element.
+type compoundSelector []func(n *html.Node) bool
+
+// CompileSelector compiles a CSS selector.
+func CompileSelector(source string) (*Selector, error) {
+	if s, ok := selectorCache.Load(source); ok {
+		return s.(*Selector), nil
+	}
+	p := &selectorParser{source: source}
+	selectors, err := p.parseSelectorList()
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector %q: %w", source, err)
+	}
+	if p.skipSpaces(); p.pos < len(p.source) {
+		return nil, fmt.Errorf("invalid selector %q: unexpected %q at offset %v", source, p.source[p.pos], p.pos)
+	}
+	s := &Selector{source: source, selectors: selectors}
+	selectorCache.Store(source, s)
+	return s, nil
+}
+
+// String returns the source of the selector.
+func (s *Selector) String() string {
+	return s.source
+}
+
+// Match reports whether the element matches the selector.
+func (s *Selector) Match(n *html.Node) bool {
+	if n == nil || n.Type != html.ElementNode {
+		return false
+	}
+	for _, selector := range s.selectors {
+		if selector.match(n, len(selector.compounds)-1) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchAll returns the descendants of the node that match the selector, in document order.
+func (s *Selector) MatchAll(root *html.Node) []*html.Node {
+	var matches []*html.Node
+	for n := nextNode(root, root); n != nil; n = nextNode(n, root) {
+		if s.Match(n) {
+			matches = append(matches, n)
+		}
+	}
+	return matches
+}
+
+// match reports whether the element matches the compound selector at index i and the compound selectors before it.
+func (c complexSelector) match(n *html.Node, i int) bool {
+	if !c.compounds[i].match(n) {
+		return false
+	}
+	if i == 0 {
+		return true
+	}
+	switch c.combinators[i-1] {
+	case '>':
+		parent := parentElement(n)
+		return parent != nil && c.match(parent, i-1)
+	case '+':
+		previous := previousElement(n)
+		return previous != nil && c.match(previous, i-1)
+	case '~':
+		for previous := previousElement(n); previous != nil; previous = previousElement(previous) {
+			if c.match(previous, i-1) {
+				return true
+			}
+		}
+	default:
+		for parent := parentElement(n); parent != nil; parent = parentElement(parent) {
+			if c.match(parent, i-1) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// match reports whether the element matches every simple selector of the compound selector.
+func (c compoundSelector) match(n *html.Node) bool {
+	for _, simple := range c {
+		if !simple(n) {
+			return false
+		}
+	}
+	return true
+}
+
+// selectorParser parses a CSS selector.
+type selectorParser struct {
+	source string
+	pos    int
+}
+
+// parseSelectorList parses comma separated complex selectors.
+func (p *selectorParser) parseSelectorList() ([]complexSelector, error) {
+	var selectors []complexSelector
+	for {
+		selector, err := p.parseComplexSelector()
+		if err != nil {
+			return nil, err
+		}
+		selectors = append(selectors, selector)
+		if p.skipSpaces(); !p.consume(',') {
+			return selectors, nil
+		}
+	}
+}
+
+// parseComplexSelector parses compound selectors joined by combinators.
+func (p *selectorParser) parseComplexSelector() (complexSelector, error) {
+	var c complexSelector
+	p.skipSpaces()
+	for {
+		compound, err := p.parseCompoundSelector()
+		if err != nil {
+			return c, err
+		}
+		c.compounds = append(c.compounds, compound)
+
+		spaces := p.skipSpaces()
+		if p.pos >= len(p.source) || p.source[p.pos] == ',' || p.source[p.pos] == ')' {
+			return c, nil
+		}
+		combinator := byte(' ')
+		if strings.IndexByte(">+~", p.source[p.pos]) >= 0 {
+			combinator = p.source[p.pos]
+			p.pos++
+			p.skipSpaces()
+		} else if !spaces {
+			return c, fmt.Errorf("unexpected %q at offset %v", p.source[p.pos], p.pos)
+		}
+		c.combinators = append(c.combinators, combinator)
+	}
+}
+
+// parseCompoundSelector parses an optional type selector followed by id, class, attribute and pseudo class selectors.
+func (p *selectorParser) parseCompoundSelector() (compoundSelector, error) {
+	var c compoundSelector
+	if p.consume('*') {
+		c = append(c, func(n *html.Node) bool { return true })
+	} else if p.pos < len(p.source) && isIdentStart(p.source[p.pos]) {
+		tag := strings.ToLower(p.parseIdent())
+		c = append(c, func(n *html.Node) bool { return n.Data == tag })
+	}
+	for p.pos < len(p.source) {
+		switch p.source[p.pos] {
+		case '#':
+			p.pos++
+			id := p.parseIdent()
+			if id == "" {
+				return nil, fmt.Errorf("missing id at offset %v", p.pos)
+			}
+			c = append(c, func(n *html.Node) bool { return attributeValue(n, "id") == id })
+		case '.':
+			p.pos++
+			class := p.parseIdent()
+			if class == "" {
+				return nil, fmt.Errorf("missing class at offset %v", p.pos)
+			}
+			c = append(c, func(n *html.Node) bool { return includesWord(attributeValue(n, "class"), class) })
+		case '[':
+			p.pos++
+			simple, err := p.parseAttributeSelector()
+			if err != nil {
+				return nil, err
+			}
+			c = append(c, simple)
+		case ':':
+			p.pos++
+			simple, err := p.parsePseudoClass()
+			if err != nil {
+				return nil, err
+			}
+			c = append(c, simple)
+		default:
+			if len(c) == 0 {
+				return nil, fmt.Errorf("unexpected %q at offset %v", p.source[p.pos], p.pos)
+			}
+			return c, nil
+		}
+	}
+	if len(c) == 0 {
+		return nil, fmt.Errorf("missing selector at offset %v", p.pos)
+	}
+	return c, nil
+}
+
+// parseAttributeSelector parses an attribute selector after its [.
+func (p *selectorParser) parseAttributeSelector() (func(n *html.Node) bool, error) {
+	p.skipSpaces()
+	key := strings.ToLower(p.parseIdent())
+	if key == "" {
+		return nil, fmt.Errorf("missing attribute name at offset %v", p.pos)
+	}
+	p.skipSpaces()
+	if p.consume(']') {
+		return func(n *html.Node) bool { return hasAttribute(n, key) }, nil
+	}
+	operator := ""
+	if p.pos < len(p.source) && strings.IndexByte("~|^$*", p.source[p.pos]) >= 0 {
+		operator = p.source[p.pos : p.pos+1]
+		p.pos++
+	}
+	if !p.consume('=') {
+		return nil, fmt.Errorf("missing = at offset %v", p.pos)
+	}
+	p.skipSpaces()
+	value, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	p.skipSpaces()
+	fold := false
+	if p.pos < len(p.source) && (p.source[p.pos] == 'i' || p.source[p.pos] == 'I') {
+		fold = true
+		p.pos++
+		p.skipSpaces()
+	}
+	if !p.consume(']') {
+		return nil, fmt.Errorf("missing ] at offset %v", p.pos)
+	}
+	if fold {
+		value = strings.ToLower(value)
+	}
+	return func(n *html.Node) bool {
+		if !hasAttribute(n, key) {
+			return false
+		}
+		actual := attributeValue(n, key)
+		if fold {
+			actual = strings.ToLower(actual)
+		}
+		switch operator {
+		case "~":
+			return includesWord(actual, value)
+		case "|":
+			return actual == value || strings.HasPrefix(actual, value+"-")
+		case "^":
+			return value != "" && strings.HasPrefix(actual, value)
+		case "$":
+			return value != "" && strings.HasSuffix(actual, value)
+		case "*":
+			return value != "" && strings.Contains(actual, value)
+		default:
+			return actual == value
+		}
+	}, nil
+}
+
+// parsePseudoClass parses a pseudo class after its :.
+func (p *selectorParser) parsePseudoClass() (func(n *html.Node) bool, error) {
+	name := strings.ToLower(p.parseIdent())
+	switch name {
+	case "first-child":
+		return nthMatcher(0, 1, false, false), nil
+	case "last-child":
+		return nthMatcher(0, 1, true, false), nil
+	case "only-child":
+		first, last := nthMatcher(0, 1, false, false), nthMatcher(0, 1, true, false)
+		return func(n *html.Node) bool { return first(n) && last(n) }, nil
+	case "first-of-type":
+		return nthMatcher(0, 1, false, true), nil
+	case "last-of-type":
+		return nthMatcher(0, 1, true, true), nil
+	case "empty":
+		return func(n *html.Node) bool {
+			for child := n.FirstChild; child != nil; child = child.NextSibling {
+				if child.Type == html.ElementNode || child.Type == html.TextNode {
+					return false
+				}
+			}
+			return true
+		}, nil
+	case "nth-child", "nth-last-child", "nth-of-type", "nth-last-of-type":
+		argument, err := p.parseArgument()
+		if err != nil {
+			return nil, err
+		}
+		a, b, err := parseNth(argument)
+		if err != nil {
+			return nil, err
+		}
+		return nthMatcher(a, b, strings.Contains(name, "last"), strings.HasSuffix(name, "of-type")), nil
+	case "not":
+		if !p.consume('(') {
+			return nil, fmt.Errorf("missing ( at offset %v", p.pos)
+		}
+		selectors, err := p.parseSelectorList()
+		if err != nil {
+			return nil, err
+		}
+		if p.skipSpaces(); !p.consume(')') {
+			return nil, fmt.Errorf("missing ) at offset %v", p.pos)
+		}
+		not := &Selector{selectors: selectors}
+		return func(n *html.Node) bool { return !not.Match(n) }, nil
+	}
+	return nil, fmt.Errorf("unsupported pseudo class :%v", name)
+}
+
+// parseArgument returns the raw argument of a functional pseudo class.
+func (p *selectorParser) parseArgument() (string, error) {
+	if !p.consume('(') {
+		return "", fmt.Errorf("missing ( at offset %v", p.pos)
+	}
+	end := strings.IndexByte(p.source[p.pos:], ')')
+	if end < 0 {
+		return "", fmt.Errorf("missing ) at offset %v", p.pos)
+	}
+	argument := p.source[p.pos : p.pos+end]
+	p.pos += end + 1
+	return strings.TrimSpace(argument), nil
+}
+
+// parseValue parses a quoted string or an identifier.
+func (p *selectorParser) parseValue() (string, error) {
+	if p.pos >= len(p.source) {
+		return "", fmt.Errorf("missing value at offset %v", p.pos)
+	}
+	quote := p.source[p.pos]
+	if quote != '"' && quote != '\'' {
+		value := p.parseIdent()
+		if value == "" {
+			return "", fmt.Errorf("missing value at offset %v", p.pos)
+		}
+		return value, nil
+	}
+	p.pos++
+	var value strings.Builder
+	for p.pos < len(p.source) {
+		c := p.source[p.pos]
+		p.pos++
+		switch {
+		case c == quote:
+			return value.String(), nil
+		case c == '\\' && p.pos < len(p.source):
+			value.WriteByte(p.source[p.pos])
+			p.pos++
+		default:
+			value.WriteByte(c)
+		}
+	}
+	return "", fmt.Errorf("unterminated string at offset %v", p.pos)
+}
+
+// parseIdent parses an identifier, backslash escapes are taken literally.
+func (p *selectorParser) parseIdent() string {
+	var ident strings.Builder
+	for p.pos < len(p.source) {
+		c := p.source[p.pos]
+		switch {
+		case c == '\\' && p.pos+1 < len(p.source):
+			ident.WriteByte(p.source[p.pos+1])
+			p.pos += 2
+		case isIdentStart(c) || c == '-' || (c >= '0' && c <= '9'):
+			ident.WriteByte(c)
+			p.pos++
+		default:
+			return ident.String()
+		}
+	}
+	return ident.String()
+}
+
+// skipSpaces skips white space, reports whether there was any.
+func (p *selectorParser) skipSpaces() bool {
+	start := p.pos
+	for p.pos < len(p.source) && strings.IndexByte(" \t\n\r\f", p.source[p.pos]) >= 0 {
+		p.pos++
+	}
+	return p.pos > start
+}
+
+// consume skips the byte if it is next.
+func (p *selectorParser) consume(c byte) bool {
+	if p.pos < len(p.source) && p.source[p.pos] == c {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+// isIdentStart reports whether the byte can start an identifier. Non ASCII bytes are part of identifiers.
+func isIdentStart(c byte) bool {
+	return c == '_' || c == '-' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
+
+// parseNth parses the an+b argument of the :nth-* pseudo classes, including odd and even.
+func parseNth(argument string) (a, b int, err error) {
+	argument = strings.ToLower(strings.Join(strings.Fields(argument), ""))
+	switch argument {
+	case "odd":
+		return 2, 1, nil
+	case "even":
+		return 2, 0, nil
+	}
+	n := strings.IndexByte(argument, 'n')
+	if n < 0 {
+		b, err = strconv.Atoi(argument)
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid nth argument %q", argument)
+		}
+		return 0, b, nil
+	}
+	switch coefficient := argument[:n]; coefficient {
+	case "", "+":
+		a = 1
+	case "-":
+		a = -1
+	default:
+		if a, err = strconv.Atoi(coefficient); err != nil {
+			return 0, 0, fmt.Errorf("invalid nth argument %q", argument)
+		}
+	}
+	if offset := argument[n+1:]; offset != "" {
+		if b, err = strconv.Atoi(offset); err != nil || (offset[0] != '+' && offset[0] != '-') {
+			return 0, 0, fmt.Errorf("invalid nth argument %q", argument)
+		}
+	}
+	return a, b, nil
+}
+
+// nthMatcher matches the elements whose 1 based position among their element siblings is a*k+b for some k >= 0,
+// counting from the last sibling if fromEnd is set and only the siblings of the same type if ofType is set.
+func nthMatcher(a, b int, fromEnd, ofType bool) func(n *html.Node) bool {
+	return func(n *html.Node) bool {
+		if n.Parent == nil {
+			return false
+		}
+		position := 1
+		for sibling := siblingElement(n, fromEnd); sibling != nil; sibling = siblingElement(sibling, fromEnd) {
+			if !ofType || sibling.Data == n.Data {
+				position++
+			}
+		}
+		if a == 0 {
+			return position == b
+		}
+		return (position-b)%a == 0 && (position-b)/a >= 0
+	}
+}
+
+// siblingElement returns the previous element sibling of the node, the next one if next is set.
+func siblingElement(n *html.Node, next bool) *html.Node {
+	if next {
+		for sibling := n.NextSibling; sibling != nil; sibling = sibling.NextSibling {
+			if sibling.Type == html.ElementNode {
+				return sibling
+			}
+		}
+		return nil
+	}
+	return previousElement(n)
+}
+
+// previousElement returns the previous element sibling of the node.
+func previousElement(n *html.Node) *html.Node {
+	for sibling := n.PrevSibling; sibling != nil; sibling = sibling.PrevSibling {
+		if sibling.Type == html.ElementNode {
+			return sibling
+		}
+	}
+	return nil
+}
+
+// parentElement returns the parent of the node if it is an element.
+func parentElement(n *html.Node) *html.Node {
+	if n.Parent != nil && n.Parent.Type == html.ElementNode {
+		return n.Parent
+	}
+	return nil
+}
+
+// nextNode returns the node that follows the node in document order, nil once the descendants of root are exhausted.
+func nextNode(n, root *html.Node) *html.Node {
+	if n.FirstChild != nil {
+		return n.FirstChild
+	}
+	for ; n != root && n != nil; n = n.Parent {
+		if n.NextSibling != nil {
+			return n.NextSibling
+		}
+	}
+	return nil
+}
+
+// hasAttribute reports whether the element has the attribute.
+func hasAttribute(n *html.Node, key string) bool {
+	for _, a := range n.Attr {
+		if a.Namespace == "" && a.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+// attributeValue returns the value of the attribute of the element, empty if it does not have it.
+func attributeValue(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Namespace == "" && a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+// includesWord reports whether the white space separated list contains the word.
+func includesWord(list, word string) bool {
+	for _, field := range strings.Fields(list) {
+		if field == word {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,159 @@
+package webcrawler
+
+import (
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/html"
+)
+
+const selectorTestPage = `<html><body>
+<ul id="results">
+	<li class="s-item s-item--watch" data-id="1"><a href="/1">First</a><span class="price">$10</span></li>
+	<li class="s-item" data-id="2"><a href="/2">Second</a><span class="price sale">$20</span></li>
+	<li class="s-item s-item--ad" data-id="3" lang="en-US"><a href="/3">Third</a></li>
+</ul>
+<p class="note"><b>Intro</b></p><p class="note"><b>Outro</b></p>
+</body></html>`
+
+func TestSelector_MatchAll(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(selectorTestPage))
+	if err != nil {
+		t.Fatalf("html.Parse() error = %v", err)
+	}
+	tests := []struct {
+		selector string
+		want     []string
+	}{
+		{selector: "li.s-item", want: []string{"1", "2", "3"}},
+		{selector: ".s-item.s-item--watch", want: []string{"1"}},
+		{selector: "#results > li:nth-child(2)", want: []string{"2"}},
+		{selector: "ul li:nth-child(odd)", want: []string{"1", "3"}},
+		{selector: "li:nth-child(-n+2)", want: []string{"1", "2"}},
+		{selector: "li:last-child, li:first-child", want: []string{"1", "3"}},
+		{selector: "li + li", want: []string{"2", "3"}},
+		{selector: "li[data-id='1'] ~ li", want: []string{"2", "3"}},
+		{selector: `li[class^="s-item s-item--w"]`, want: []string{"1"}},
+		{selector: "li[class$=ad]", want: []string{"3"}},
+		{selector: "li[class*=watch]", want: []string{"1"}},
+		{selector: "li[class~=s-item--ad]", want: []string{"3"}},
+		{selector: "li[lang|=en]", want: []string{"3"}},
+		{selector: "LI[LANG=EN-us i]", want: []string{"3"}},
+		{selector: "li:not(.s-item--watch, [lang])", want: []string{"2"}},
+		{selector: "body li span.sale", want: nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			selector, err := CompileSelector(tt.selector)
+			if err != nil {
+				t.Fatalf("CompileSelector() error = %v", err)
+			}
+			var got []string
+			for _, n := range selector.MatchAll(doc) {
+				if id := attributeValue(n, "data-id"); id != "" {
+					got = append(got, id)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Selector.MatchAll() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCompileSelector(t *testing.T) {
+	tests := []struct {
+		selector string
+		wantErr  bool
+	}{
+		{selector: "div > p + a ~ span"},
+		{selector: "a[href]"},
+		{selector: "p:nth-of-type(2n+1)"},
+		{selector: "", wantErr: true},
+		{selector: "div >", wantErr: true},
+		{selector: "a[href", wantErr: true},
+		{selector: "a[href='x]", wantErr: true},
+		{selector: "p:nth-child(x)", wantErr: true},
+		{selector: "p:hover", wantErr: true},
+		{selector: "div)", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			if _, err := CompileSelector(tt.selector); (err != nil) != tt.wantErr {
+				t.Errorf("CompileSelector() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWebScraper_ScrapeSelectors(t *testing.T) {
+	fetcher := FetcherFunc(func(request *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{},
+			Body:       ioutil.NopCloser(strings.NewReader(selectorTestPage)),
+			Request:    request,
+		}, nil
+	})
+	ws := &WebScraper{Logger: logrus.New(), Fetcher: fetcher}
+	itemsToGet := []ScrapeItemConfig{
+		{
+			ItemName:  "Result",
+			ItemToGet: ExtractFromTokenConfig{Selector: "#results > li.s-item"},
+			ItemDetails: map[string]ExtractFromTokenConfig{
+				"title": {Selector: "a"},
+				"link":  {Tag: "a", AttributeToGet: "href"},
+				"price": {Selector: "span.price"},
+			},
+		},
+		{
+			ItemName:    "Paragraph",
+			ItemToGet:   ExtractFromTokenConfig{Tag: "p", Attribute: "class", AttributeValue: "note"},
+			ItemDetails: map[string]ExtractFromTokenConfig{"text": {Tag: "b"}},
+		},
+	}
+	urlsToGet := []ScrapeURLConfig{{Name: "Results", ExtractFromTokenConfig: ExtractFromTokenConfig{Selector: "li:not(.s-item--ad) > a"}}}
+	response, err := ws.Scrape(&URL{CurrentURL: "https://www.example.io/search"}, itemsToGet, urlsToGet...)
+	if err != nil {
+		t.Fatalf("WebScraper.Scrape() error = %v", err)
+	}
+
+	var urls []string
+	for _, u := range response.ExtractedURLs {
+		urls = append(urls, u.CurrentURL)
+	}
+	if want := []string{"https://www.example.io/1", "https://www.example.io/2"}; !reflect.DeepEqual(urls, want) {
+		t.Errorf("WebScraper.Scrape() urls = %v, want %v", urls, want)
+	}
+	var (
+		details    []map[string]string
+		paragraphs int
+	)
+	for _, item := range response.ExtractedItem {
+		switch item.ItemName {
+		case "Result":
+			details = append(details, item.ItemDetails)
+		case "Paragraph":
+			paragraphs++
+		}
+	}
+	if paragraphs != 2 {
+		t.Errorf("WebScraper.Scrape() paragraphs = %v, want 2", paragraphs)
+	}
+	want := []map[string]string{
+		{"title": "First", "link": "/1", "price": "$10"},
+		{"title": "Second", "link": "/2", "price": "$20"},
+		{"title": "Third", "link": "/3"},
+	}
+	if !reflect.DeepEqual(details, want) {
+		t.Errorf("WebScraper.Scrape() item details = %v, want %v", details, want)
+	}
+
+	if err := ValidateScrapeConfigs([]ScrapeItemConfig{{ItemToGet: ExtractFromTokenConfig{Selector: "li["}}}, nil); err == nil {
+		t.Errorf("ValidateScrapeConfigs() error = nil, want error for invalid selector")
+	}
+}
@@ -0,0 +1,449 @@
+package webcrawler
+
+import (
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// openGraphPrefixes are the prefixes of the OpenGraph properties of meta tags, og: and the prefixes of its object types.
+var openGraphPrefixes = []string{"og:", "fb:", "article:", "book:", "books:", "music:", "product:", "profile:", "video:"}
+
+// microdataURLProperties are the elements whose Microdata value is the url of their src attribute.
+var microdataURLProperties = map[string]bool{"audio": true, "embed": true, "iframe": true, "img": true, "source": true, "track": true, "video": true}
+
+// StructuredData represents the structured data embedded in a page. JSON-LD, Microdata and RDFa items are decoded
+// into objects shaped like JSON-LD, the types of an item are found at "@type", its id at "@id" and its properties by
+// name. Repeated properties are arrays and nested items are objects. OpenGraph and Twitter meta tags map the property
+// to its content, the first tag of a property wins.
+type StructuredData struct {
+	JSONLD    []map[string]interface{}
+	Microdata []map[string]interface{}
+	RDFa      []map[string]interface{}
+	OpenGraph map[string]string
+	Twitter   map[string]string
+}
+
+// IsEmpty reports whether no structured data was found.
+func (d *StructuredData) IsEmpty() bool {
+	return d == nil || len(d.JSONLD) == 0 && len(d.Microdata) == 0 && len(d.RDFa) == 0 && len(d.OpenGraph) == 0 && len(d.Twitter) == 0
+}
+
+// Objects returns the JSON-LD, Microdata and RDFa objects of the schema.org type, such as Product, including the
+// objects nested within other objects. Types are compared by their local name, so Product matches
+// https://schema.org/Product and schema:Product.
+func (d *StructuredData) Objects(schemaType string) []map[string]interface{} {
+	var objects []map[string]interface{}
+	if d == nil {
+		return nil
+	}
+	for _, sources := range [][]map[string]interface{}{d.JSONLD, d.Microdata, d.RDFa} {
+		for _, object := range sources {
+			objects = schemaObjects(object, schemaType, objects)
+		}
+	}
+	return objects
+}
+
+// schemaObjects appends the objects of the schema.org type within the value, including the value itself, to objects.
+func schemaObjects(value interface{}, schemaType string, objects []map[string]interface{}) []map[string]interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		if hasSchemaType(value, schemaType) {
+			objects = append(objects, value)
+		}
+		objects = nestedSchemaObjects(value, schemaType, objects)
+	case []interface{}:
+		for _, element := range value {
+			objects = schemaObjects(element, schemaType, objects)
+		}
+	}
+	return objects
+}
+
+// nestedSchemaObjects appends the objects of the schema.org type nested within the properties of the object to objects.
+// Properties are walked in order of their names, so that nested objects are always found in the same order.
+func nestedSchemaObjects(object map[string]interface{}, schemaType string, objects []map[string]interface{}) []map[string]interface{} {
+	names := make([]string, 0, len(object))
+	for name := range object {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		objects = schemaObjects(object[name], schemaType, objects)
+	}
+	return objects
+}
+
+// extractStructuredData extracts the JSON-LD scripts, Microdata and RDFa Lite items and OpenGraph and Twitter meta tags
+// of the page. Urls of Microdata and RDFa items are resolved against the base url of the page.
+func extractStructuredData(doc *html.Node, base *url.URL) *StructuredData {
+	data := &StructuredData{}
+	for n := nextNode(doc, doc); n != nil; n = nextNode(n, doc) {
+		if n.Type != html.ElementNode {
+			continue
+		}
+		switch {
+		case n.Data == "script" && strings.EqualFold(strings.TrimSpace(attributeValue(n, "type")), "application/ld+json"):
+			data.JSONLD = append(data.JSONLD, decodeJSONLD(textContent(n))...)
+		case n.Data == "meta":
+			data.addMetaTag(n)
+		}
+		if hasAttribute(n, "itemscope") && !hasAttribute(n, "itemprop") {
+			data.Microdata = append(data.Microdata, microdataItem(doc, n, base, map[*html.Node]bool{}))
+		}
+	}
+	data.RDFa = rdfaResources(doc, nil, base, nil)
+	return data
+}
+
+// decodeJSONLD decodes the objects of a JSON-LD script, which holds an object or an array of objects. Numbers are kept
+// as json.Number, so that prices and identifiers are not rounded. Invalid scripts are skipped.
+func decodeJSONLD(script string) []map[string]interface{} {
+	script = strings.TrimSpace(script)
+	script = strings.TrimSpace(strings.TrimSuffix(strings.TrimPrefix(script, "<!--"), "-->"))
+	decoder := json.NewDecoder(strings.NewReader(script))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return nil
+	}
+	var objects []map[string]interface{}
+	switch value := value.(type) {
+	case map[string]interface{}:
+		objects = append(objects, value)
+	case []interface{}:
+		for _, element := range value {
+			if object, ok := element.(map[string]interface{}); ok {
+				objects = append(objects, object)
+			}
+		}
+	}
+	return objects
+}
+
+// addMetaTag adds the content of an OpenGraph or Twitter meta tag, unless a tag of the same property was added before.
+func (d *StructuredData) addMetaTag(n *html.Node) {
+	content := attributeValue(n, "content")
+	for _, property := range append(strings.Fields(attributeValue(n, "property")), attributeValue(n, "name")) {
+		switch {
+		case strings.HasPrefix(property, "twitter:"):
+			if d.Twitter == nil {
+				d.Twitter = make(map[string]string)
+			}
+			if _, exist := d.Twitter[property]; !exist {
+				d.Twitter[property] = content
+			}
+		case isOpenGraphProperty(property) && includesWord(attributeValue(n, "property"), property):
+			if d.OpenGraph == nil {
+				d.OpenGraph = make(map[string]string)
+			}
+			if _, exist := d.OpenGraph[property]; !exist {
+				d.OpenGraph[property] = content
+			}
+		}
+	}
+}
+
+// isOpenGraphProperty reports whether the property of a meta tag belongs to OpenGraph.
+func isOpenGraphProperty(property string) bool {
+	for _, prefix := range openGraphPrefixes {
+		if strings.HasPrefix(property, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// microdataItem returns the Microdata item of the element with the itemscope attribute. Its properties are the
+// elements with an itemprop attribute within the element and the elements referenced by its itemref attribute, not
+// descending into nested items. Items that are already being extracted are skipped, which breaks itemref cycles.
+func microdataItem(doc, n *html.Node, base *url.URL, extracting map[*html.Node]bool) map[string]interface{} {
+	item := make(map[string]interface{})
+	if types := strings.Fields(attributeValue(n, "itemtype")); len(types) > 0 {
+		item["@type"] = singleOrList(types)
+	}
+	if id := attributeValue(n, "itemid"); id != "" {
+		item["@id"] = absoluteURL(base, id)
+	}
+	extracting[n] = true
+	defer delete(extracting, n)
+
+	var addProperties func(e *html.Node)
+	addProperties = func(e *html.Node) {
+		for c := e.FirstChild; c != nil; c = c.NextSibling {
+			if c.Type != html.ElementNode {
+				continue
+			}
+			addMicrodataProperty(doc, c, item, base, extracting)
+			if !hasAttribute(c, "itemscope") {
+				addProperties(c)
+			}
+		}
+	}
+	addProperties(n)
+	for _, id := range strings.Fields(attributeValue(n, "itemref")) {
+		if ref := elementByID(doc, id); ref != nil && !extracting[ref] {
+			addMicrodataProperty(doc, ref, item, base, extracting)
+			if !hasAttribute(ref, "itemscope") {
+				addProperties(ref)
+			}
+		}
+	}
+	return item
+}
+
+// addMicrodataProperty adds the value of the element to the item under each name of its itemprop attribute.
+func addMicrodataProperty(doc, e *html.Node, item map[string]interface{}, base *url.URL, extracting map[*html.Node]bool) {
+	names := strings.Fields(attributeValue(e, "itemprop"))
+	if len(names) == 0 {
+		return
+	}
+	var value interface{}
+	switch {
+	case hasAttribute(e, "itemscope"):
+		if extracting[e] {
+			return
+		}
+		value = microdataItem(doc, e, base, extracting)
+	case e.Data == "meta":
+		value = attributeValue(e, "content")
+	case microdataURLProperties[e.Data]:
+		value = absoluteURL(base, attributeValue(e, "src"))
+	case e.Data == "a" || e.Data == "area" || e.Data == "link":
+		value = absoluteURL(base, attributeValue(e, "href"))
+	case e.Data == "object":
+		value = absoluteURL(base, attributeValue(e, "data"))
+	case e.Data == "data" || e.Data == "meter":
+		value = attributeValue(e, "value")
+	case e.Data == "time" && hasAttribute(e, "datetime"):
+		value = attributeValue(e, "datetime")
+	default:
+		value = strings.TrimSpace(textContent(e))
+	}
+	for _, name := range names {
+		addProperty(item, name, value)
+	}
+}
+
+// rdfaResources returns the RDFa Lite resources of the elements with a typeof attribute within the node. The
+// properties of the elements with a property attribute are added to the closest resource, the subject. Resources
+// that are the value of a property are nested within their subject, the others are returned.
+func rdfaResources(n *html.Node, subject map[string]interface{}, base *url.URL, resources []map[string]interface{}) []map[string]interface{} {
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if c.Type != html.ElementNode {
+			continue
+		}
+		names := strings.Fields(attributeValue(c, "property"))
+		childSubject := subject
+		switch {
+		case hasAttribute(c, "typeof"):
+			resource := make(map[string]interface{})
+			if types := strings.Fields(attributeValue(c, "typeof")); len(types) > 0 {
+				resource["@type"] = singleOrList(types)
+			}
+			if id := attributeValue(c, "resource"); id != "" {
+				resource["@id"] = absoluteURL(base, id)
+			}
+			if subject != nil && len(names) > 0 {
+				for _, name := range names {
+					addProperty(subject, name, resource)
+				}
+			} else {
+				resources = append(resources, resource)
+			}
+			childSubject = resource
+		case subject != nil && len(names) > 0:
+			for _, name := range names {
+				addProperty(subject, name, rdfaValue(c, base))
+			}
+		}
+		resources = rdfaResources(c, childSubject, base, resources)
+	}
+	return resources
+}
+
+// rdfaValue returns the value of a property element, its content attribute, the url of its resource, href or src
+// attribute, its datetime attribute or else its text.
+func rdfaValue(e *html.Node, base *url.URL) string {
+	if hasAttribute(e, "content") {
+		return attributeValue(e, "content")
+	}
+	for _, attribute := range []string{"resource", "href", "src"} {
+		if hasAttribute(e, attribute) {
+			return absoluteURL(base, attributeValue(e, attribute))
+		}
+	}
+	if e.Data == "time" && hasAttribute(e, "datetime") {
+		return attributeValue(e, "datetime")
+	}
+	return strings.TrimSpace(textContent(e))
+}
+
+// addProperty adds the value to the property of the object, a property with several values becomes an array.
+func addProperty(object map[string]interface{}, name string, value interface{}) {
+	existing, exist := object[name]
+	if !exist {
+		object[name] = value
+		return
+	}
+	if values, ok := existing.([]interface{}); ok {
+		object[name] = append(values, value)
+		return
+	}
+	object[name] = []interface{}{existing, value}
+}
+
+// singleOrList returns the only value of the list, or else the list, like the values of JSON-LD.
+func singleOrList(list []string) interface{} {
+	if len(list) == 1 {
+		return list[0]
+	}
+	values := make([]interface{}, 0, len(list))
+	for _, value := range list {
+		values = append(values, value)
+	}
+	return values
+}
+
+// absoluteURL resolves the url against the base url of the page, it is returned as is if it cannot be parsed.
+func absoluteURL(base *url.URL, rawURL string) string {
+	rawURL = strings.TrimSpace(rawURL)
+	ref, err := url.Parse(rawURL)
+	if err != nil || base == nil || rawURL == "" {
+		return rawURL
+	}
+	return base.ResolveReference(ref).String()
+}
+
+// elementByID returns the element with the id, nil if there is none.
+func elementByID(doc *html.Node, id string) *html.Node {
+	for n := nextNode(doc, doc); n != nil; n = nextNode(n, doc) {
+		if n.Type == html.ElementNode && attributeValue(n, "id") == id {
+			return n
+		}
+	}
+	return nil
+}
+
+// textContent returns the text of the node and its descendants.
+func textContent(n *html.Node) string {
+	var text strings.Builder
+	for d := nextNode(n, n); d != nil; d = nextNode(d, n) {
+		if d.Type == html.TextNode {
+			text.WriteString(d.Data)
+		}
+	}
+	return text.String()
+}
+
+// hasSchemaType reports whether one of the types of the object has the local name of the schema.org type.
+func hasSchemaType(object map[string]interface{}, schemaType string) bool {
+	switch types := object["@type"].(type) {
+	case string:
+		return localName(types) == schemaType
+	case []interface{}:
+		for _, t := range types {
+			if t, ok := t.(string); ok && localName(t) == schemaType {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// localName returns the name of a type or property without its vocabulary, Product for https://schema.org/Product or
+// schema:Product.
+func localName(name string) string {
+	return name[strings.LastIndexAny(name, "/#:")+1:]
+}
+
+// schemaValues returns the values found at the dot separated path of property names within the value, such as
+// offers.price. Arrays resolve to all of their elements unless the path indexes them, such as offers.1.price, so
+// offers.price returns the price of every offer. Properties are also found by their local name, so price matches
+// https://schema.org/price. Objects are returned as JSON unless they hold a JSON-LD @value.
+func schemaValues(value interface{}, path []string) []string {
+	switch value := value.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		if len(path) > 0 {
+			if i, err := strconv.Atoi(path[0]); err == nil {
+				if i < 0 || i >= len(value) {
+					return nil
+				}
+				return schemaValues(value[i], path[1:])
+			}
+		}
+		var values []string
+		for _, element := range value {
+			values = append(values, schemaValues(element, path)...)
+		}
+		return values
+	case map[string]interface{}:
+		if len(path) > 0 {
+			if property, ok := value[path[0]]; ok {
+				return schemaValues(property, path[1:])
+			}
+			for name, property := range value {
+				if localName(name) == path[0] {
+					return schemaValues(property, path[1:])
+				}
+			}
+			return nil
+		}
+		if literal, ok := value["@value"]; ok {
+			return schemaValues(literal, nil)
+		}
+	}
+	if len(path) > 0 {
+		return nil
+	}
+	switch value := value.(type) {
+	case string:
+		return []string{strings.TrimSpace(value)}
+	case json.Number:
+		return []string{value.String()}
+	case bool:
+		return []string{strconv.FormatBool(value)}
+	}
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return nil
+	}
+	return []string{string(encoded)}
+}
+
+// extractItemsFromStructuredData extracts an item from every object of the schema.org type of the item config.
+func extractItemsFromStructuredData(data *StructuredData, scrapeItemConfig ScrapeItemConfig) []Item {
+	var items []Item
+	for _, object := range data.Objects(scrapeItemConfig.ItemToGet.Schema) {
+		if item, ok := extractItemFromObject(object, scrapeItemConfig); ok {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// extractItemFromObject extracts the item details of the item found at their schema paths within the object. The
+// children of the item are extracted from the objects of their schema type nested within the object.
+func extractItemFromObject(object map[string]interface{}, scrapeItemConfig ScrapeItemConfig) (Item, bool) {
+	item, ok := extractItem(scrapeItemConfig, func(config ExtractFromTokenConfig) []string {
+		return schemaValues(object, strings.Split(config.Schema, "."))
+	})
+	if !ok {
+		return Item{}, false
+	}
+	for _, child := range scrapeItemConfig.Children {
+		for _, childObject := range nestedSchemaObjects(object, child.ItemToGet.Schema, nil) {
+			if childItem, ok := extractItemFromObject(childObject, child); ok {
+				addChild(&item, childItem)
+			}
+		}
+	}
+	return item, true
+}
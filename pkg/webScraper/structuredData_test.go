@@ -0,0 +1,206 @@
+package webcrawler
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/html"
+)
+
+const structuredDataTestPage = `<html><head>
+<meta property="og:title" content="Graphics Card">
+<meta property="og:image" content="https://www.example.io/card.png">
+<meta property="og:image" content="https://www.example.io/card-2.png">
+<meta property="product:price:amount" content="499.99">
+<meta name="twitter:card" content="summary">
+<meta name="description" content="Not structured">
+<script type="application/ld+json">
+{"@context": "https://schema.org", "@graph": [
+	{"@type": "BreadcrumbList", "itemListElement": []},
+	{"@type": "Product", "name": "Graphics Card", "gtin13": 4006381333931,
+	 "offers": [{"@type": "Offer", "price": 499.99, "priceCurrency": "USD"}, {"@type": "Offer", "price": "549.00"}],
+	 "brand": {"@type": "Brand", "name": "Acme"}}
+]}
+</script>
+<script type="application/ld+json">{"@type": "Product", "name": </script>
+</head><body>
+<div itemscope itemtype="https://schema.org/Product" itemref="rating">
+	<h1 itemprop="name">Fan</h1>
+	<img itemprop="image" src="/fan.png">
+	<div itemprop="offers" itemscope itemtype="https://schema.org/Offer">
+		<meta itemprop="priceCurrency" content="USD"><span itemprop="price">19.99</span>
+	</div>
+	<span itemprop="color">White</span><span itemprop="color">Black</span>
+</div>
+<p id="rating" itemprop="aggregateRating" itemscope itemtype="https://schema.org/AggregateRating"><span itemprop="ratingValue">4.5</span></p>
+<div vocab="https://schema.org/" typeof="Product">
+	<span property="name">Case</span>
+	<a property="url" href="/case">Case</a>
+	<div property="offers" typeof="Offer"><span property="price" content="79.00">$79</span></div>
+</div>
+</body></html>`
+
+func TestExtractStructuredData(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(structuredDataTestPage))
+	if err != nil {
+		t.Fatalf("html.Parse() error = %v", err)
+	}
+	base, _ := url.Parse("https://www.example.io/products")
+	data := extractStructuredData(doc, base)
+
+	if len(data.JSONLD) != 1 {
+		t.Fatalf("extractStructuredData() JSONLD = %v, want 1 object", data.JSONLD)
+	}
+	wantMicrodata := []map[string]interface{}{{
+		"@type": "https://schema.org/Product",
+		"name":  "Fan",
+		"image": "https://www.example.io/fan.png",
+		"offers": map[string]interface{}{
+			"@type":         "https://schema.org/Offer",
+			"priceCurrency": "USD",
+			"price":         "19.99",
+		},
+		"color":           []interface{}{"White", "Black"},
+		"aggregateRating": map[string]interface{}{"@type": "https://schema.org/AggregateRating", "ratingValue": "4.5"},
+	}}
+	if !reflect.DeepEqual(data.Microdata, wantMicrodata) {
+		t.Errorf("extractStructuredData() Microdata = %v, want %v", data.Microdata, wantMicrodata)
+	}
+	wantRDFa := []map[string]interface{}{{
+		"@type":  "Product",
+		"name":   "Case",
+		"url":    "https://www.example.io/case",
+		"offers": map[string]interface{}{"@type": "Offer", "price": "79.00"},
+	}}
+	if !reflect.DeepEqual(data.RDFa, wantRDFa) {
+		t.Errorf("extractStructuredData() RDFa = %v, want %v", data.RDFa, wantRDFa)
+	}
+	wantOpenGraph := map[string]string{"og:title": "Graphics Card", "og:image": "https://www.example.io/card.png", "product:price:amount": "499.99"}
+	if !reflect.DeepEqual(data.OpenGraph, wantOpenGraph) {
+		t.Errorf("extractStructuredData() OpenGraph = %v, want %v", data.OpenGraph, wantOpenGraph)
+	}
+	if want := map[string]string{"twitter:card": "summary"}; !reflect.DeepEqual(data.Twitter, want) {
+		t.Errorf("extractStructuredData() Twitter = %v, want %v", data.Twitter, want)
+	}
+
+	var names []string
+	for _, object := range data.Objects("Product") {
+		names = append(names, schemaValues(object, []string{"name"})...)
+	}
+	if want := []string{"Graphics Card", "Fan", "Case"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("StructuredData.Objects() = %v, want %v", names, want)
+	}
+}
+
+func Test_schemaValues(t *testing.T) {
+	var object map[string]interface{}
+	decoder := json.NewDecoder(strings.NewReader(`{
+		"@type": "Product",
+		"gtin13": 4006381333931,
+		"http://schema.org/sku": "A-1",
+		"inStock": true,
+		"description": {"@value": "A card", "@language": "en"},
+		"offers": [{"price": 499.99}, {"price": "549.00"}],
+		"brand": {"@type": "Brand", "name": "Acme"},
+		"review": []
+	}`))
+	decoder.UseNumber()
+	if err := decoder.Decode(&object); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	tests := []struct {
+		path string
+		want []string
+	}{
+		{path: "offers.price", want: []string{"499.99", "549.00"}},
+		{path: "offers.1.price", want: []string{"549.00"}},
+		{path: "offers.2.price"},
+		{path: "gtin13", want: []string{"4006381333931"}},
+		{path: "sku", want: []string{"A-1"}},
+		{path: "inStock", want: []string{"true"}},
+		{path: "description", want: []string{"A card"}},
+		{path: "brand.name", want: []string{"Acme"}},
+		{path: "brand", want: []string{`{"@type":"Brand","name":"Acme"}`}},
+		{path: "review"},
+		{path: "review.rating"},
+		{path: "color"},
+		{path: "name.first"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := schemaValues(object, strings.Split(tt.path, ".")); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("schemaValues() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWebScraper_ScrapeStructuredData(t *testing.T) {
+	fetcher := FetcherFunc(func(request *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{},
+			Body:       ioutil.NopCloser(strings.NewReader(structuredDataTestPage)),
+			Request:    request,
+		}, nil
+	})
+	itemsToGet := []ScrapeItemConfig{{
+		ItemName:  "Product",
+		ItemToGet: ExtractFromTokenConfig{Schema: "Product"},
+		ItemDetails: map[string]ExtractFromTokenConfig{
+			"name":     {Schema: "name"},
+			"price":    {Schema: "offers.price", ItemFilterConfiguration: FilterConfiguration{IsLessThan: 500.0, ConvertStringToNumber: "true"}},
+			"currency": {Schema: "offers.priceCurrency"},
+		},
+	}}
+
+	ws := &WebScraper{Logger: logrus.New(), Fetcher: fetcher}
+	response, err := ws.Scrape(&URL{CurrentURL: "https://www.example.io/products"}, itemsToGet)
+	if err != nil {
+		t.Fatalf("WebScraper.Scrape() error = %v", err)
+	}
+	var got []map[string]string
+	for _, item := range response.ExtractedItem {
+		got = append(got, item.ItemDetails)
+	}
+	want := []map[string]string{
+		{"name": "Graphics Card", "price": "499.99", "currency": "USD"},
+		{"name": "Fan", "price": "19.99", "currency": "USD"},
+		{"name": "Case", "price": "79.00"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("WebScraper.Scrape() item details = %v, want %v", got, want)
+	}
+	if response.StructuredData != nil {
+		t.Errorf("WebScraper.Scrape() StructuredData = %v, want nil unless ExtractStructuredData is set", response.StructuredData)
+	}
+
+	ws.ExtractStructuredData = true
+	response, err = ws.Scrape(&URL{CurrentURL: "https://www.example.io/products"}, nil)
+	if err != nil {
+		t.Fatalf("WebScraper.Scrape() error = %v", err)
+	}
+	if data := response.StructuredData; data == nil || len(data.JSONLD) != 1 || len(data.Microdata) != 1 || len(data.RDFa) != 1 || data.OpenGraph["og:title"] != "Graphics Card" {
+		t.Errorf("WebScraper.Scrape() StructuredData = %v, want the structured data of the page", data)
+	}
+
+	invalid := [][]ScrapeItemConfig{
+		{{ItemToGet: ExtractFromTokenConfig{Schema: "Product"}, ItemDetails: map[string]ExtractFromTokenConfig{"name": {Selector: "h1"}}}},
+		{{ItemToGet: ExtractFromTokenConfig{Selector: "div"}, ItemDetails: map[string]ExtractFromTokenConfig{"price": {Schema: "offers.price"}}}},
+		{{ItemToGet: ExtractFromTokenConfig{Schema: "Product", XPath: "//div"}}},
+	}
+	for _, itemsToGet := range invalid {
+		if err := ValidateScrapeConfigs(itemsToGet, nil); err == nil {
+			t.Errorf("ValidateScrapeConfigs(%v) error = nil, want error", itemsToGet)
+		}
+	}
+	if err := ValidateScrapeConfigs(nil, []ScrapeURLConfig{{ExtractFromTokenConfig: ExtractFromTokenConfig{Schema: "Product"}}}); err == nil {
+		t.Errorf("ValidateScrapeConfigs() error = nil, want error for url config with schema")
+	}
+}
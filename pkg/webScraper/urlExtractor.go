@@ -3,6 +3,7 @@ package webcrawler
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	"golang.org/x/net/html"
 )
@@ -18,6 +19,17 @@ type URL struct {
 	CurrentURL   string
 	CurrentDepth int
 	MaxDepth     int
+
+	// Tag is the tag of the seed the url was found from.
+	Tag string
+
+	// LastModified and Priority are the <lastmod> and <priority> of a url found in a sitemap, zero otherwise.
+	LastModified time.Time
+	Priority     float64
+
+	// Title and Published are the title and publish date of a url found in an RSS or Atom feed, zero otherwise.
+	Title     string
+	Published time.Time
 }
 
 //ScrapeURLConfig configuration used to extract url from html token
@@ -30,7 +42,7 @@ type ScrapeURLConfig struct {
 //ExtractURL extracts url from html token. Checks for duplicates
 func ExtractURL(t html.Token, extractedUrls map[string]bool) string {
 	url, _ := extractURLFromToken(t)
-	if url != "" && !isDuplicateURL(url, extractedUrls) && isURL(url) {
+	if url != "" && !isDuplicateURL(url, extractedUrls) {
 		return url
 	}
 	return ""
@@ -39,7 +51,10 @@ func ExtractURL(t html.Token, extractedUrls map[string]bool) string {
 // ExtractURLWithScrapURLConfig extracts url from html token using a list of scrape url config which allows
 // for selective extraction.
 func ExtractURLWithScrapURLConfig(t html.Token, urlsToCheck map[string]bool, tagsToCheck map[string]bool, scrapeURLConfigs []ScrapeURLConfig) (string, error) {
-	var url string
+	var (
+		url     string
+		matched bool
+	)
 	for _, scrapeURLConfig := range scrapeURLConfigs {
 		if !IsEmpty(scrapeURLConfig.ExtractFromTokenConfig) {
 			if _, exist := tagsToCheck[t.Data]; exist {
@@ -48,12 +63,15 @@ func ExtractURLWithScrapURLConfig(t html.Token, urlsToCheck map[string]bool, tag
 		} else {
 			url, _ = extractURLFromToken(t)
 		}
-		if url == "" || !isURL(url) {
+		if url == "" {
+			continue
+		}
+		if url, _, matched = matchRegex("", url, scrapeURLConfig.ExtractFromTokenConfig); !matched {
 			continue
 		}
 		if !IsEmpty(scrapeURLConfig.FormatURLConfig) {
 			formatedURL := formatURL(url, scrapeURLConfig.FormatURLConfig)
-			if formatedURL == "" || !isURL(formatedURL) {
+			if formatedURL == "" {
 				continue
 			}
 			if !isDuplicateURL(formatedURL, urlsToCheck) && url != "" {
@@ -61,9 +79,6 @@ func ExtractURLWithScrapURLConfig(t html.Token, urlsToCheck map[string]bool, tag
 
 			}
 		} else {
-			if !isURL(url) {
-				continue
-			}
 			return url, nil
 		}
 	}
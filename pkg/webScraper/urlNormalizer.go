@@ -0,0 +1,138 @@
+package webcrawler
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// DefaultIgnoredQueryParams are the tracking query parameters removed by NormalizeURL unless configured otherwise.
+var DefaultIgnoredQueryParams = []string{"utm_*", "gclid", "fbclid"}
+
+// ResolveURL resolves the href of a link against the base url of the page. Returns false for links that cannot be
+// crawled, such as javascript:, mailto: or fragment only links.
+func ResolveURL(base *url.URL, href string) (string, bool) {
+	href = strings.TrimSpace(href)
+	if href == "" || strings.HasPrefix(href, "#") {
+		return "", false
+	}
+	ref, err := url.Parse(href)
+	if err != nil {
+		return "", false
+	}
+	if base != nil {
+		ref = base.ResolveReference(ref)
+	}
+	resolved := ref.String()
+	if !isURL(resolved) {
+		return "", false
+	}
+	return resolved, true
+}
+
+// pageURL returns the url of the page, which is the url of the last request if the response was redirected.
+func pageURL(response *http.Response, rawURL string) *url.URL {
+	if response.Request != nil && response.Request.URL != nil {
+		return response.Request.URL
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil
+	}
+	return u
+}
+
+// baseURL returns the base url set by the href of a <base> token, resolved against the url of the page. Returns the
+// url of the page and false if the token has no valid href.
+func baseURL(page *url.URL, token html.Token) (*url.URL, bool) {
+	href, err := extractAttributeValue(token, hrefAttribute)
+	if err != nil || strings.TrimSpace(href) == "" {
+		return page, false
+	}
+	ref, err := url.Parse(strings.TrimSpace(href))
+	if err != nil {
+		return page, false
+	}
+	if page != nil {
+		ref = page.ResolveReference(ref)
+	}
+	return ref, true
+}
+
+// NormalizeURL normalizes the url so that equivalent urls compare equal. The scheme and host are lower cased, default
+// ports, dot segments and the fragment are removed, an empty path becomes / and the query parameters are sorted.
+// Query parameters matching one of the ignored parameters are removed, a trailing * matches any suffix.
+func NormalizeURL(rawURL string, ignoredQueryParams []string) (string, error) {
+	u, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil {
+		return "", err
+	}
+	if !u.IsAbs() || u.Host == "" {
+		return "", fmt.Errorf("url %v is not absolute", rawURL)
+	}
+
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	if port := u.Port(); (u.Scheme == "http" && port == "80") || (u.Scheme == "https" && port == "443") {
+		u.Host = strings.TrimSuffix(u.Host, ":"+port)
+	}
+	u.Fragment = ""
+	u.RawFragment = ""
+	u.Path = removeDotSegments(u.Path)
+	u.RawPath = removeDotSegments(u.RawPath)
+	if u.Path == "" {
+		u.Path = "/"
+	}
+
+	if u.RawQuery != "" {
+		query := u.Query()
+		for param := range query {
+			if isIgnoredQueryParam(param, ignoredQueryParams) {
+				query.Del(param)
+			}
+		}
+		for _, values := range query {
+			sort.Strings(values)
+		}
+		// Encode sorts the query parameters by key.
+		u.RawQuery = query.Encode()
+	}
+	u.ForceQuery = false
+	return u.String(), nil
+}
+
+// removeDotSegments removes the . and .. segments of a path, as described in RFC 3986 section 5.2.4. A trailing
+// slash is kept.
+func removeDotSegments(p string) string {
+	if p == "" || !strings.Contains(p, ".") {
+		return p
+	}
+	cleaned := path.Clean(p)
+	if cleaned == "." {
+		cleaned = "/"
+	}
+	last := p[strings.LastIndex(p, "/")+1:]
+	if (strings.HasSuffix(p, "/") || last == "." || last == "..") && !strings.HasSuffix(cleaned, "/") {
+		cleaned += "/"
+	}
+	return cleaned
+}
+
+// isIgnoredQueryParam checks if the query parameter matches one of the ignored query parameters.
+func isIgnoredQueryParam(param string, ignoredQueryParams []string) bool {
+	for _, ignored := range ignoredQueryParams {
+		if strings.HasSuffix(ignored, "*") {
+			if strings.HasPrefix(param, strings.TrimSuffix(ignored, "*")) {
+				return true
+			}
+		} else if param == ignored {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+package webcrawler
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+)
+
+func TestNormalizeURL(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		want    string
+		wantErr bool
+	}{
+		{name: "Scheme and host case", url: "HTTPS://WWW.Example.COM/Products", want: "https://www.example.com/Products"},
+		{name: "Default port", url: "http://example.com:80/a", want: "http://example.com/a"},
+		{name: "Non default port", url: "http://example.com:8080/a", want: "http://example.com:8080/a"},
+		{name: "Dot segments", url: "https://example.com/a/./b/../c/", want: "https://example.com/a/c/"},
+		{name: "Fragment", url: "https://example.com/a#reviews", want: "https://example.com/a"},
+		{name: "Empty path", url: "https://example.com", want: "https://example.com/"},
+		{name: "Sorted query", url: "https://example.com/search?q=gpu&page=2", want: "https://example.com/search?page=2&q=gpu"},
+		{name: "Ignored query params", url: "https://example.com/a?utm_source=mail&utm_medium=x&id=1&gclid=abc", want: "https://example.com/a?id=1"},
+		{name: "Relative url", url: "/a", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeURL(tt.url, DefaultIgnoredQueryParams)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NormalizeURL() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeURL() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveURL(t *testing.T) {
+	base, _ := url.Parse("https://www.example.co.uk/shop/gpus/index.html")
+	tests := []struct {
+		name   string
+		href   string
+		want   string
+		wantOk bool
+	}{
+		{name: "Absolute path", href: "/product/123", want: "https://www.example.co.uk/product/123", wantOk: true},
+		{name: "Relative path", href: "rtx-3080.html", want: "https://www.example.co.uk/shop/gpus/rtx-3080.html", wantOk: true},
+		{name: "Parent path", href: "../cpus/", want: "https://www.example.co.uk/shop/cpus/", wantOk: true},
+		{name: "Protocol relative", href: "//cdn.example.io/a", want: "https://cdn.example.io/a", wantOk: true},
+		{name: "Absolute url", href: "https://shop.example.de/a", want: "https://shop.example.de/a", wantOk: true},
+		{name: "Fragment only", href: "#top", wantOk: false},
+		{name: "Javascript", href: "javascript:void(0)", wantOk: false},
+		{name: "Mail", href: "mailto:sales@example.co.uk", wantOk: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ResolveURL(base, tt.href)
+			if got != tt.want || ok != tt.wantOk {
+				t.Errorf("ResolveURL() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestWebScraper_ScrapeResolvesLinks(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{
+			name: "Relative links",
+			body: `<a href="/product/123">a</a><a href="reviews.html">b</a>`,
+			want: []string{"https://www.example.io/product/123", "https://www.example.io/shop/reviews.html"},
+		},
+		{
+			name: "Base href",
+			body: `<head><base href="https://static.example.io/v2/"></head><a href="product/123">a</a>`,
+			want: []string{"https://static.example.io/v2/product/123"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fetcher := FetcherFunc(func(request *http.Request) (*http.Response, error) {
+				return &http.Response{
+					StatusCode: http.StatusOK,
+					Header:     http.Header{},
+					Body:       ioutil.NopCloser(strings.NewReader(tt.body)),
+					Request:    request,
+				}, nil
+			})
+			ws := &WebScraper{Logger: logrus.New(), Fetcher: fetcher}
+			response, err := ws.Scrape(&URL{CurrentURL: "https://www.example.io/shop/index.html"}, nil)
+			if err != nil {
+				t.Fatalf("WebScraper.Scrape() error = %v", err)
+			}
+			var got []string
+			for _, u := range response.ExtractedURLs {
+				got = append(got, u.CurrentURL)
+			}
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("WebScraper.Scrape() urls = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
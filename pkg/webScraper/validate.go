@@ -2,6 +2,7 @@ package webcrawler
 
 import (
 	"log"
+	"net/url"
 	"regexp"
 	"strconv"
 	"strings"
@@ -140,9 +141,11 @@ func ConvertStringToNunber(s string) interface{} {
 	return nil
 }
 
-func isURL(url string) bool {
-	if strings.Contains(url, ".com") || strings.Contains(url, ".net") || strings.Contains(url, ".org") {
-		return true
+// isURL checks if the url is an absolute http or https url.
+func isURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
 	}
-	return false
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
 }
@@ -1,24 +1,76 @@
 package webcrawler
 
 import (
+	"context"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 )
 
+// ThrottledError is returned by ScrapeContext when the website responds with 429 Too Many Requests or 503 Service
+// Unavailable, the response is not parsed. RetryAfter holds the delay requested by the Retry-After header, 0 if the
+// header is missing or invalid.
+type ThrottledError struct {
+	URL        string
+	StatusCode int
+	RetryAfter time.Duration
+}
+
+// Error implements the error interface.
+func (e *ThrottledError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("website throttled request to %v with status code %v, retry after %v", e.URL, e.StatusCode, e.RetryAfter)
+	}
+	return fmt.Sprintf("website throttled request to %v with status code %v", e.URL, e.StatusCode)
+}
+
 //ConnectToWebsite Executes a HTTP request to the url and returns the response.
 func ConnectToWebsite(url, headerKey, headerValue string) (*http.Response, error) {
-	client := &http.Client{
-		Timeout: 60 * time.Second,
-	}
-	request, err := http.NewRequest("GET", url, nil)
+	return ConnectToWebsiteContext(context.Background(), url, headerKey, headerValue)
+}
+
+//ConnectToWebsiteContext Executes a HTTP request to the url bound to the given context and returns the response.
+// Cancelling the context aborts the request, including reading the response body. The request is executed by the
+// DefaultFetcher.
+func ConnectToWebsiteContext(ctx context.Context, url, headerKey, headerValue string) (*http.Response, error) {
+	return FetchContext(ctx, DefaultFetcher, url, headerKey, headerValue)
+}
+
+//FetchContext Executes a HTTP request to the url bound to the given context with the fetcher and returns the response.
+func FetchContext(ctx context.Context, fetcher Fetcher, url, headerKey, headerValue string) (*http.Response, error) {
+	request, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
-		return request.Response, err
+		return nil, err
+	}
+	if headerKey != "" {
+		request.Header.Set(headerKey, headerValue)
 	}
-	request.Header.Set(headerKey, headerValue)
+	return fetcher.Fetch(request)
+}
 
-	response, err := client.Do(request)
-	if err != nil {
-		return request.Response, err
+// isThrottled checks if the status code signals that the website is rate limiting the web scraper.
+func isThrottled(statusCode int) bool {
+	return statusCode == http.StatusTooManyRequests || statusCode == http.StatusServiceUnavailable
+}
+
+// ParseRetryAfter parses the value of a Retry-After header, either a number of seconds or an http date. Returns 0 if
+// the value is empty, invalid or lies in the past.
+func ParseRetryAfter(value string, now time.Time) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+	date, err := http.ParseTime(value)
+	if err != nil || !date.After(now) {
+		return 0
 	}
-	return response, nil
+	return date.Sub(now)
 }
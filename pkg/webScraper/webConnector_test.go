@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"reflect"
 	"testing"
+	"time"
 )
 
 func TestConnectToWebsite(t *testing.T) {
@@ -37,3 +38,25 @@ func TestConnectToWebsite(t *testing.T) {
 		})
 	}
 }
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2022, time.March, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		value string
+		want  time.Duration
+	}{
+		{name: "Seconds", value: "120", want: 2 * time.Minute},
+		{name: "Http date", value: "Tue, 01 Mar 2022 12:00:30 GMT", want: 30 * time.Second},
+		{name: "Http date in the past", value: "Tue, 01 Mar 2022 11:00:00 GMT", want: 0},
+		{name: "Empty", value: "", want: 0},
+		{name: "Invalid", value: "soon", want: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseRetryAfter(tt.value, now); got != tt.want {
+				t.Errorf("ParseRetryAfter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,9 +1,16 @@
 package webcrawler
 
 import (
-	"strings"
+	"bytes"
+	"context"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
 	"sync"
+	"time"
 
+	"github.com/cody6750/web-crawler/pkg/robots"
 	"github.com/sirupsen/logrus"
 	"golang.org/x/net/html"
 )
@@ -35,13 +42,29 @@ type WebScraper struct {
 
 	// HeaderKey Used to set http header
 	HeaderValue string
+
+	// RetryPolicy used to retry failed requests, nil disables retries.
+	RetryPolicy *RetryPolicy
+
+	// Fetcher used to execute http requests, nil uses the DefaultFetcher.
+	Fetcher Fetcher
+
+	// ExtractStructuredData used to return the JSON-LD, Microdata, RDFa and OpenGraph data of every page on the response.
+	ExtractStructuredData bool
 }
 
 //Response represents the response the web scraper returns to the web cralwer.
 type Response struct {
-	RootURL       string
-	ExtractedItem []*Item
-	ExtractedURLs []*URL
+	RootURL         string
+	StatusCode      int
+	Retries         int
+	BytesDownloaded int64
+	ExtractedItem   []*Item
+	ExtractedURLs   []*URL
+	StructuredData  *StructuredData
+
+	// PageURL is the url of the page after redirects.
+	PageURL string
 }
 
 //New initializes a web scraper with default options
@@ -55,6 +78,15 @@ func New() *WebScraper {
 // the html content for urls and items. It parses the html content by generating tokens for each html element. Tags
 // and attributes are extracted for each token,and are used to extract the url and items based on the config parameters.
 func (ws *WebScraper) Scrape(u *URL, itemsToGet []ScrapeItemConfig, urlsToGet ...ScrapeURLConfig) (*Response, error) {
+	return ws.ScrapeContext(context.Background(), u, itemsToGet, urlsToGet...)
+}
+
+//ScrapeContext is the context aware version of Scrape. Cancelling the context aborts the http request to the url. If
+// the website responds with 429 Too Many Requests or 503 Service Unavailable a *ThrottledError is returned. Failed
+// requests are retried following the RetryPolicy, if the website then responds with any other status code of 400 or
+// above a *StatusError is returned. The scrape configs are not validated for every page, check them once with
+// ValidateScrapeConfigs, an invalid selector, XPath expression or regular expression matches nothing.
+func (ws *WebScraper) ScrapeContext(ctx context.Context, u *URL, itemsToGet []ScrapeItemConfig, urlsToGet ...ScrapeURLConfig) (*Response, error) {
 	var (
 		url             string
 		urls            []*URL
@@ -62,45 +94,83 @@ func (ws *WebScraper) Scrape(u *URL, itemsToGet []ScrapeItemConfig, urlsToGet ..
 		itemTagsToCheck map[string]bool
 		urlTagsToCheck  map[string]bool
 		urlsToCheck     map[string]bool = make(map[string]bool)
+		reader          io.Reader
+		page            []byte
 	)
 
-	response, err := ConnectToWebsite(u.CurrentURL, ws.HeaderKey, ws.HeaderValue)
+	tokenItems, domItems, tokenURLs, domURLs := splitScrapeConfigs(itemsToGet, urlsToGet)
+
+	response, retries, err := ws.connect(ctx, u.CurrentURL)
 	if err != nil {
-		return &Response{}, err
+		return &Response{Retries: retries}, err
+	}
+	if isThrottled(response.StatusCode) {
+		response.Body.Close()
+		return &Response{RootURL: u.RootURL, StatusCode: response.StatusCode, Retries: retries}, &ThrottledError{
+			URL:        u.CurrentURL,
+			StatusCode: response.StatusCode,
+			RetryAfter: ParseRetryAfter(response.Header.Get("Retry-After"), time.Now()),
+		}
+	}
+	if response.StatusCode >= http.StatusBadRequest {
+		response.Body.Close()
+		return &Response{RootURL: u.RootURL, StatusCode: response.StatusCode, Retries: retries}, &StatusError{URL: u.CurrentURL, StatusCode: response.StatusCode}
 	}
-	body := response.Body
-	if !IsEmpty(urlsToGet) {
-		urlTagsToCheck = ws.generateTagsToCheckMap(urlsToGet)
+	body := &countingBody{ReadCloser: response.Body}
+	// Links are resolved against the url of the page after redirects, or the first <base href> of the page.
+	base, hasBase := pageURL(response, u.CurrentURL), false
+	location := u.CurrentURL
+	if base != nil {
+		location = base.String()
 	}
-	if !IsEmpty(itemsToGet) {
-		itemTagsToCheck = ws.generateTagsToCheckMap(itemsToGet)
+	if !IsEmpty(tokenURLs) {
+		urlTagsToCheck = ws.generateTagsToCheckMap(tokenURLs)
+	}
+	if !IsEmpty(tokenItems) {
+		itemTagsToCheck = ws.generateTagsToCheckMap(tokenItems)
 	}
 	defer body.Close()
+	reader = body
+	if len(domItems) > 0 || len(domURLs) > 0 || ws.ExtractStructuredData {
+		// The page is kept in memory to be parsed into a DOM once it has been tokenized.
+		page, _ = ioutil.ReadAll(body)
+		reader = bytes.NewReader(page)
+	}
 	// Parse HTML response by turning it into Tokens
-	z := html.NewTokenizer(body)
+	z := html.NewTokenizer(reader)
 	// This while loop parses through all of the tokens generated for the HTML response.
 	for {
 		//Iterate through each token
 		tt := z.Next()
 		// For every token, we check the token type. We parse URL from the start token.
 		switch {
+		case tt == html.SelfClosingTagToken:
+			if t := z.Token(); t.Data == "base" && !hasBase {
+				base, hasBase = baseURL(base, t)
+			}
 		case tt == html.StartTagToken:
 			t := z.Token()
+			// The href of <base> is not a link.
+			if t.Data == "base" {
+				if !hasBase {
+					base, hasBase = baseURL(base, t)
+				}
+				continue
+			}
+			url = ""
 			if IsEmpty(urlsToGet) {
 				//TODO: Replace ExtractedURL with a channel
 				url = ExtractURL(t, urlsToCheck)
-			} else {
-				url, _ = ExtractURLWithScrapURLConfig(t, urlsToCheck, urlTagsToCheck, urlsToGet)
+			} else if !IsEmpty(tokenURLs) {
+				url, _ = ExtractURLWithScrapURLConfig(t, urlsToCheck, urlTagsToCheck, tokenURLs)
 			}
 
-			if url != "" && isURL(url) {
-				if isBlackListedURLPath := ws.isBlackListedURLPath(url); !isBlackListedURLPath {
-					urls = append(urls, &URL{CurrentURL: url, ParentURL: u.CurrentURL, RootURL: u.RootURL, CurrentDepth: u.CurrentDepth + 1, MaxDepth: u.MaxDepth})
-				}
+			if child := ws.childURL(u, base, url); child != nil {
+				urls = append(urls, child)
 			}
 
-			if !IsEmpty(itemsToGet) {
-				item, err := ExtractItemWithScrapItemConfig(t, z, itemTagsToCheck, itemsToGet)
+			if !IsEmpty(tokenItems) {
+				item, err := ExtractItemWithScrapItemConfig(t, z, itemTagsToCheck, tokenItems)
 				if err != nil || IsEmpty(item) {
 					continue
 				}
@@ -110,11 +180,32 @@ func (ws *WebScraper) Scrape(u *URL, itemsToGet []ScrapeItemConfig, urlsToGet ..
 
 			// This is our break statement
 		case tt == html.ErrorToken:
-			return &Response{RootURL: u.RootURL, ExtractedURLs: urls, ExtractedItem: items}, nil
+			if ctx.Err() != nil {
+				return &Response{RootURL: u.RootURL, PageURL: location, StatusCode: response.StatusCode, Retries: retries, BytesDownloaded: body.n, ExtractedURLs: urls, ExtractedItem: items}, ctx.Err()
+			}
+			var structuredData *StructuredData
+			if page != nil {
+				domURLsFound, domItemsFound, data := ws.scrapeDocument(page, u, base, urlsToCheck, domItems, domURLs)
+				urls, items = append(urls, domURLsFound...), append(items, domItemsFound...)
+				if ws.ExtractStructuredData && !data.IsEmpty() {
+					structuredData = data
+				}
+			}
+			return &Response{RootURL: u.RootURL, PageURL: location, StatusCode: response.StatusCode, Retries: retries, BytesDownloaded: body.n, ExtractedURLs: urls, ExtractedItem: items, StructuredData: structuredData}, nil
 		}
 	}
 }
 
+// childURL returns the url found at the href of a link of the page of the url, resolved against the base url of the
+// page. Returns nil if the link cannot be crawled or its path is blacklisted.
+func (ws *WebScraper) childURL(u *URL, base *url.URL, href string) *URL {
+	resolved, ok := ResolveURL(base, href)
+	if !ok || ws.isBlackListedURLPath(resolved) {
+		return nil
+	}
+	return &URL{CurrentURL: resolved, ParentURL: u.CurrentURL, RootURL: u.RootURL, CurrentDepth: u.CurrentDepth + 1, MaxDepth: u.MaxDepth, Tag: u.Tag}
+}
+
 // generateTagsToCheckMap generates a map of tags to check, given the scrape tag configuration. The map is used for both
 // url and item scraping. The map is used to check whether or not the html element should be used to extract from.
 func (ws *WebScraper) generateTagsToCheckMap(t interface{}) map[string]bool {
@@ -136,31 +227,27 @@ func (ws *WebScraper) generateTagsToCheckMap(t interface{}) map[string]bool {
 		}
 		return itemTagsToCheck
 	default:
-		ws.Logger.WithField("Type", t).Warn("Unable to generate tags to check map")
+		ws.logger().WithField("Type", t).Warn("Unable to generate tags to check map")
 	}
 	return map[string]bool{}
 }
 
-// isBlackListedURLPath breaks down a url path and checks if it is blacklisted.
-func (ws *WebScraper) isBlackListedURLPath(url string) bool {
-	var urlToCheck string
-	splitURLPath := strings.SplitN(url, "/", 4)
-	if len(splitURLPath) < 4 {
+// isBlackListedURLPath checks if the path of the url is blacklisted. Blacklisted url paths follow the robots.txt
+// pattern syntax, so they match as a prefix, may contain * wildcards and may end with a $ anchor.
+func (ws *WebScraper) isBlackListedURLPath(rawURL string) bool {
+	if len(ws.BlackListedURLPaths) == 0 {
 		return false
 	}
-	urlPath := "/" + splitURLPath[3]
-	splitURLPath = strings.Split(urlPath, "/")
-	for _, splitURL := range splitURLPath {
-		urlToCheck += splitURL
-		if urlToCheck == "" {
-			urlToCheck += "/"
-			continue
-		}
-		if _, exist := ws.BlackListedURLPaths[urlToCheck]; exist {
-			return true
-		}
-		urlToCheck += "/"
-		if _, exist := ws.BlackListedURLPaths[urlToCheck]; exist {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+	path := u.EscapedPath()
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+	for blackListedURLPath := range ws.BlackListedURLPaths {
+		if robots.Match(blackListedURLPath, path) {
 			return true
 		}
 	}
@@ -0,0 +1,1064 @@
+package webcrawler
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"golang.org/x/net/html"
+)
+
+// xpathCache holds the compiled XPath expressions, so that the expressions of a crawl are only compiled once.
+var xpathCache sync.Map
+
+// XPath is a compiled XPath 1.0 expression evaluated against a parsed html page. It supports location paths with every
+// axis but the namespace axis, the abbreviated syntax, predicates, the operators and the core function library but
+// id(), lang() and variables.
+type XPath struct {
+	source string
+	expr   xpathExpr
+}
+
+// CompileXPath compiles an XPath 1.0 expression.
+func CompileXPath(source string) (*XPath, error) {
+	if x, ok := xpathCache.Load(source); ok {
+		return x.(*XPath), nil
+	}
+	tokens, err := lexXPath(source)
+	if err != nil {
+		return nil, fmt.Errorf("invalid xpath %q: %w", source, err)
+	}
+	p := &xpathParser{tokens: tokens}
+	expr, err := p.parseExpr()
+	if err == nil && p.peek().kind != xpathEOF {
+		err = fmt.Errorf("unexpected %q at offset %v", p.peek().value, p.peek().pos)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid xpath %q: %w", source, err)
+	}
+	x := &XPath{source: source, expr: expr}
+	xpathCache.Store(source, x)
+	return x, nil
+}
+
+// String returns the source of the expression.
+func (x *XPath) String() string {
+	return x.source
+}
+
+// Select returns the element, text and comment nodes selected by the expression from the node, in document order.
+func (x *XPath) Select(n *html.Node) []*html.Node {
+	nodes, _ := x.evaluate(n).([]xnode)
+	var selected []*html.Node
+	for _, node := range nodes {
+		if node.attr < 0 {
+			selected = append(selected, node.node)
+		}
+	}
+	return selected
+}
+
+// Values returns the string value of every node selected by the expression from the node, in document order. If the
+// expression does not select nodes, such as count(//li), its value is converted to a string.
+func (x *XPath) Values(n *html.Node) []string {
+	return x.values(n, "")
+}
+
+// values returns the values of the nodes selected by the expression from the node, the attribute of the selected
+// elements if attribute is set and the string value of the other nodes.
+func (x *XPath) values(n *html.Node, attribute string) []string {
+	result := x.evaluate(n)
+	nodes, ok := result.([]xnode)
+	if !ok {
+		return []string{xpathString(result)}
+	}
+	values := make([]string, 0, len(nodes))
+	for _, node := range nodes {
+		if attribute != "" && node.attr < 0 && node.node.Type == html.ElementNode {
+			values = append(values, attributeValue(node.node, attribute))
+		} else {
+			values = append(values, node.String())
+		}
+	}
+	return values
+}
+
+// evaluate evaluates the expression with the node as the context node.
+func (x *XPath) evaluate(n *html.Node) interface{} {
+	return x.expr.eval(&xpathContext{node: xnode{node: n, attr: -1}, position: 1, size: 1, order: &xpathOrder{}})
+}
+
+// xnode is a node of the XPath data model. Attributes are not html nodes, an attribute is the index of the attribute
+// in the attributes of its element, attr is -1 for every other node.
+type xnode struct {
+	node *html.Node
+	attr int
+}
+
+// String returns the string value of the node.
+func (n xnode) String() string {
+	if n.attr >= 0 {
+		return n.node.Attr[n.attr].Val
+	}
+	switch n.node.Type {
+	case html.TextNode, html.CommentNode:
+		return n.node.Data
+	}
+	var text strings.Builder
+	for d := nextNode(n.node, n.node); d != nil; d = nextNode(d, n.node) {
+		if d.Type == html.TextNode {
+			text.WriteString(d.Data)
+		}
+	}
+	return text.String()
+}
+
+// name returns the name of the element or attribute, empty for other nodes.
+func (n xnode) name() string {
+	if n.attr >= 0 {
+		return n.node.Attr[n.attr].Key
+	}
+	if n.node.Type == html.ElementNode {
+		return n.node.Data
+	}
+	return ""
+}
+
+// xpathOrder holds the document order of the nodes of a page, computed once it is first needed.
+type xpathOrder struct {
+	keys map[*html.Node]int
+}
+
+// key returns the position of the node in document order. Attributes follow their element and precede its children.
+func (o *xpathOrder) key(n xnode) int {
+	if o.keys == nil {
+		o.keys = make(map[*html.Node]int)
+		root := n.node
+		for root.Parent != nil {
+			root = root.Parent
+		}
+		position := 0
+		for d := root; d != nil; d = nextNode(d, root) {
+			o.keys[d] = position
+			position += 1 + len(d.Attr)
+		}
+	}
+	return o.keys[n.node] + n.attr + 1
+}
+
+// sort sorts the nodes in document order and removes duplicates.
+func (o *xpathOrder) sort(nodes []xnode) []xnode {
+	seen := make(map[xnode]bool, len(nodes))
+	unique := nodes[:0:0]
+	for _, n := range nodes {
+		if !seen[n] {
+			seen[n] = true
+			unique = append(unique, n)
+		}
+	}
+	sort.SliceStable(unique, func(i, j int) bool { return o.key(unique[i]) < o.key(unique[j]) })
+	return unique
+}
+
+// xpathContext is the context an expression is evaluated in.
+type xpathContext struct {
+	node     xnode
+	position int
+	size     int
+	order    *xpathOrder
+}
+
+// xpathExpr is an XPath expression. Its value is a node set ([]xnode in document order), a string, a float64 or a
+// bool.
+type xpathExpr interface {
+	eval(c *xpathContext) interface{}
+}
+
+type (
+	xpathLiteral string
+	xpathNumber  float64
+
+	// xpathBinary is a binary operator, or, and, a comparison, an arithmetic operator or the union operator |.
+	xpathBinary struct {
+		op          string
+		left, right xpathExpr
+	}
+
+	xpathNegate struct {
+		expr xpathExpr
+	}
+
+	xpathFunction struct {
+		name string
+		args []xpathExpr
+	}
+
+	// xpathFilter is a primary expression filtered by predicates.
+	xpathFilter struct {
+		primary    xpathExpr
+		predicates []xpathExpr
+	}
+
+	// xpathPath is a location path, starting from the node set of the filter if there is one, from the root of the
+	// page if it is absolute or else from the context node.
+	xpathPath struct {
+		filter   xpathExpr
+		absolute bool
+		steps    []xpathStep
+	}
+
+	xpathStep struct {
+		axis       string
+		test       xpathNodeTest
+		predicates []xpathExpr
+	}
+
+	// xpathNodeTest is a node test, kind is a node type such as text, node or comment, * or a name.
+	xpathNodeTest struct {
+		kind string
+		name string
+	}
+)
+
+func (e xpathLiteral) eval(c *xpathContext) interface{} { return string(e) }
+
+func (e xpathNumber) eval(c *xpathContext) interface{} { return float64(e) }
+
+func (e *xpathNegate) eval(c *xpathContext) interface{} { return -xpathNumberOf(e.expr.eval(c)) }
+
+func (e *xpathBinary) eval(c *xpathContext) interface{} {
+	switch e.op {
+	case "or":
+		return xpathBoolean(e.left.eval(c)) || xpathBoolean(e.right.eval(c))
+	case "and":
+		return xpathBoolean(e.left.eval(c)) && xpathBoolean(e.right.eval(c))
+	case "|":
+		left, _ := e.left.eval(c).([]xnode)
+		right, _ := e.right.eval(c).([]xnode)
+		return c.order.sort(append(append([]xnode(nil), left...), right...))
+	case "=", "!=", "<", "<=", ">", ">=":
+		return xpathCompare(e.op, e.left.eval(c), e.right.eval(c))
+	}
+	left, right := xpathNumberOf(e.left.eval(c)), xpathNumberOf(e.right.eval(c))
+	switch e.op {
+	case "+":
+		return left + right
+	case "-":
+		return left - right
+	case "*":
+		return left * right
+	case "div":
+		return left / right
+	default:
+		return math.Mod(left, right)
+	}
+}
+
+func (e *xpathFilter) eval(c *xpathContext) interface{} {
+	value := e.primary.eval(c)
+	if len(e.predicates) == 0 {
+		return value
+	}
+	nodes, _ := value.([]xnode)
+	for _, predicate := range e.predicates {
+		nodes = xpathPredicate(c, nodes, predicate)
+	}
+	return nodes
+}
+
+func (e *xpathPath) eval(c *xpathContext) interface{} {
+	var nodes []xnode
+	switch {
+	case e.filter != nil:
+		nodes, _ = e.filter.eval(c).([]xnode)
+	case e.absolute:
+		root := c.node.node
+		for root.Parent != nil {
+			root = root.Parent
+		}
+		nodes = []xnode{{node: root, attr: -1}}
+	default:
+		nodes = []xnode{c.node}
+	}
+	for _, step := range e.steps {
+		nodes = step.apply(c, nodes)
+	}
+	return nodes
+}
+
+// apply returns the nodes selected by the step from every node, in document order.
+func (s xpathStep) apply(c *xpathContext, nodes []xnode) []xnode {
+	var selected []xnode
+	for _, n := range nodes {
+		var matches []xnode
+		for _, candidate := range xpathAxis(s.axis, n) {
+			if s.test.match(candidate, s.axis == "attribute") {
+				matches = append(matches, candidate)
+			}
+		}
+		for _, predicate := range s.predicates {
+			matches = xpathPredicate(c, matches, predicate)
+		}
+		selected = append(selected, matches...)
+	}
+	if len(nodes) > 1 || xpathReverseAxes[s.axis] {
+		selected = c.order.sort(selected)
+	}
+	return selected
+}
+
+// match reports whether the node passes the node test. Names match elements, or attributes on the attribute axis.
+func (t xpathNodeTest) match(n xnode, attributeAxis bool) bool {
+	switch t.kind {
+	case "node":
+		return true
+	case "text":
+		return n.attr < 0 && n.node.Type == html.TextNode
+	case "comment":
+		return n.attr < 0 && n.node.Type == html.CommentNode
+	case "processing-instruction":
+		return false
+	}
+	if attributeAxis {
+		return n.attr >= 0 && (t.kind == "*" || strings.EqualFold(n.node.Attr[n.attr].Key, t.name))
+	}
+	return n.attr < 0 && n.node.Type == html.ElementNode && (t.kind == "*" || strings.EqualFold(n.node.Data, t.name))
+}
+
+// xpathPredicate filters the nodes, in proximity order, with the predicate. A number predicate selects the node at
+// that position.
+func xpathPredicate(c *xpathContext, nodes []xnode, predicate xpathExpr) []xnode {
+	var kept []xnode
+	for i, n := range nodes {
+		value := predicate.eval(&xpathContext{node: n, position: i + 1, size: len(nodes), order: c.order})
+		if number, ok := value.(float64); ok {
+			if number == float64(i+1) {
+				kept = append(kept, n)
+			}
+		} else if xpathBoolean(value) {
+			kept = append(kept, n)
+		}
+	}
+	return kept
+}
+
+// xpathAxes are the supported axes, xpathReverseAxes the axes whose nodes are in reverse document order.
+var (
+	xpathAxes = map[string]bool{
+		"ancestor": true, "ancestor-or-self": true, "attribute": true, "child": true, "descendant": true,
+		"descendant-or-self": true, "following": true, "following-sibling": true, "parent": true, "preceding": true,
+		"preceding-sibling": true, "self": true,
+	}
+	xpathReverseAxes = map[string]bool{"ancestor": true, "ancestor-or-self": true, "preceding": true, "preceding-sibling": true}
+)
+
+// xpathAxis returns the nodes of the axis of the node, in proximity order. Doctypes are not part of the data model.
+func xpathAxis(axis string, n xnode) []xnode {
+	var nodes []xnode
+	add := func(node *html.Node) {
+		if node.Type != html.DoctypeNode {
+			nodes = append(nodes, xnode{node: node, attr: -1})
+		}
+	}
+	descendants := func(node *html.Node) {
+		for d := nextNode(node, node); d != nil; d = nextNode(d, node) {
+			add(d)
+		}
+	}
+	switch axis {
+	case "self":
+		return []xnode{n}
+	case "attribute":
+		if n.attr < 0 && n.node.Type == html.ElementNode {
+			for i := range n.node.Attr {
+				nodes = append(nodes, xnode{node: n.node, attr: i})
+			}
+		}
+	case "parent", "ancestor", "ancestor-or-self":
+		if axis == "ancestor-or-self" {
+			nodes = append(nodes, n)
+		}
+		parent := n.node.Parent
+		if n.attr >= 0 {
+			parent = n.node
+		}
+		for ; parent != nil; parent = parent.Parent {
+			add(parent)
+			if axis == "parent" {
+				break
+			}
+		}
+	case "child", "descendant", "descendant-or-self":
+		if axis == "descendant-or-self" {
+			nodes = append(nodes, n)
+		}
+		if n.attr >= 0 {
+			break
+		}
+		for child := n.node.FirstChild; child != nil; child = child.NextSibling {
+			add(child)
+			if axis != "child" {
+				descendants(child)
+			}
+		}
+	case "following-sibling", "preceding-sibling":
+		if n.attr >= 0 {
+			break
+		}
+		for sibling := xpathSibling(n.node, axis == "following-sibling"); sibling != nil; sibling = xpathSibling(sibling, axis == "following-sibling") {
+			add(sibling)
+		}
+	case "following":
+		start := n.node
+		if n.attr >= 0 {
+			descendants(start)
+		}
+		for ; start != nil; start = start.Parent {
+			for sibling := start.NextSibling; sibling != nil; sibling = sibling.NextSibling {
+				add(sibling)
+				descendants(sibling)
+			}
+		}
+	case "preceding":
+		for start := n.node; start != nil; start = start.Parent {
+			for sibling := start.PrevSibling; sibling != nil; sibling = sibling.PrevSibling {
+				subtree := []*html.Node{sibling}
+				for d := nextNode(sibling, sibling); d != nil; d = nextNode(d, sibling) {
+					subtree = append(subtree, d)
+				}
+				for i := len(subtree) - 1; i >= 0; i-- {
+					add(subtree[i])
+				}
+			}
+		}
+	}
+	return nodes
+}
+
+// xpathSibling returns the next sibling of the node, or the previous sibling if next is not set.
+func xpathSibling(n *html.Node, next bool) *html.Node {
+	if next {
+		return n.NextSibling
+	}
+	return n.PrevSibling
+}
+
+// xpathFunctionArity is the minimum and maximum number of arguments of the functions, -1 for any number.
+var xpathFunctionArity = map[string][2]int{
+	"last": {0, 0}, "position": {0, 0}, "count": {1, 1}, "local-name": {0, 1}, "name": {0, 1},
+	"string": {0, 1}, "concat": {2, -1}, "starts-with": {2, 2}, "contains": {2, 2}, "substring-before": {2, 2},
+	"substring-after": {2, 2}, "substring": {2, 3}, "string-length": {0, 1}, "normalize-space": {0, 1},
+	"translate": {3, 3}, "boolean": {1, 1}, "not": {1, 1}, "true": {0, 0}, "false": {0, 0}, "number": {0, 1},
+	"sum": {1, 1}, "floor": {1, 1}, "ceiling": {1, 1}, "round": {1, 1},
+}
+
+func (e *xpathFunction) eval(c *xpathContext) interface{} {
+	arg := func(i int) interface{} {
+		if i < len(e.args) {
+			return e.args[i].eval(c)
+		}
+		return []xnode{c.node}
+	}
+	str := func(i int) string { return xpathString(arg(i)) }
+	switch e.name {
+	case "last":
+		return float64(c.size)
+	case "position":
+		return float64(c.position)
+	case "count":
+		nodes, _ := arg(0).([]xnode)
+		return float64(len(nodes))
+	case "local-name", "name":
+		if nodes, _ := arg(0).([]xnode); len(nodes) > 0 {
+			return nodes[0].name()
+		}
+		return ""
+	case "string":
+		return str(0)
+	case "concat":
+		var s strings.Builder
+		for i := range e.args {
+			s.WriteString(str(i))
+		}
+		return s.String()
+	case "starts-with":
+		return strings.HasPrefix(str(0), str(1))
+	case "contains":
+		return strings.Contains(str(0), str(1))
+	case "substring-before":
+		s, sep := str(0), str(1)
+		if i := strings.Index(s, sep); i >= 0 {
+			return s[:i]
+		}
+		return ""
+	case "substring-after":
+		s, sep := str(0), str(1)
+		if i := strings.Index(s, sep); i >= 0 {
+			return s[i+len(sep):]
+		}
+		return ""
+	case "substring":
+		runes := []rune(str(0))
+		start, end := xpathRound(xpathNumberOf(arg(1))), math.Inf(1)
+		if len(e.args) == 3 {
+			end = start + xpathRound(xpathNumberOf(arg(2)))
+		}
+		var s strings.Builder
+		for i, r := range runes {
+			if position := float64(i + 1); position >= start && position < end {
+				s.WriteRune(r)
+			}
+		}
+		return s.String()
+	case "string-length":
+		return float64(utf8.RuneCountInString(str(0)))
+	case "normalize-space":
+		return strings.Join(strings.Fields(str(0)), " ")
+	case "translate":
+		from, to := []rune(str(1)), []rune(str(2))
+		return strings.Map(func(r rune) rune {
+			for i, f := range from {
+				if f == r {
+					if i < len(to) {
+						return to[i]
+					}
+					return -1
+				}
+			}
+			return r
+		}, str(0))
+	case "boolean":
+		return xpathBoolean(arg(0))
+	case "not":
+		return !xpathBoolean(arg(0))
+	case "true":
+		return true
+	case "false":
+		return false
+	case "number":
+		return xpathNumberOf(arg(0))
+	case "sum":
+		nodes, _ := arg(0).([]xnode)
+		sum := 0.0
+		for _, n := range nodes {
+			sum += xpathNumberOf(n.String())
+		}
+		return sum
+	case "floor":
+		return math.Floor(xpathNumberOf(arg(0)))
+	case "ceiling":
+		return math.Ceil(xpathNumberOf(arg(0)))
+	default:
+		return xpathRound(xpathNumberOf(arg(0)))
+	}
+}
+
+// xpathRound rounds the number to the closest integer, rounding halves towards positive infinity.
+func xpathRound(f float64) float64 {
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return f
+	}
+	return math.Floor(f + 0.5)
+}
+
+// xpathString converts a value to a string. A node set is converted to the string value of its first node.
+func xpathString(value interface{}) string {
+	switch value := value.(type) {
+	case string:
+		return value
+	case bool:
+		return strconv.FormatBool(value)
+	case float64:
+		switch {
+		case math.IsNaN(value):
+			return "NaN"
+		case math.IsInf(value, 1):
+			return "Infinity"
+		case math.IsInf(value, -1):
+			return "-Infinity"
+		case value == 0:
+			return "0"
+		}
+		return strconv.FormatFloat(value, 'f', -1, 64)
+	case []xnode:
+		if len(value) > 0 {
+			return value[0].String()
+		}
+	}
+	return ""
+}
+
+// xpathNumberOf converts a value to a number, NaN if it is not a number.
+func xpathNumberOf(value interface{}) float64 {
+	switch value := value.(type) {
+	case float64:
+		return value
+	case bool:
+		if value {
+			return 1
+		}
+		return 0
+	}
+	number, err := strconv.ParseFloat(strings.TrimSpace(xpathString(value)), 64)
+	if err != nil {
+		return math.NaN()
+	}
+	return number
+}
+
+// xpathBoolean converts a value to a boolean.
+func xpathBoolean(value interface{}) bool {
+	switch value := value.(type) {
+	case bool:
+		return value
+	case float64:
+		return value != 0 && !math.IsNaN(value)
+	case string:
+		return value != ""
+	case []xnode:
+		return len(value) > 0
+	}
+	return false
+}
+
+// xpathCompare compares two values. A comparison with a node set is true if it is true for the string value of one of
+// its nodes.
+func xpathCompare(op string, left, right interface{}) bool {
+	if nodes, ok := left.([]xnode); ok {
+		if _, ok := right.(bool); ok {
+			return xpathCompare(op, len(nodes) > 0, right)
+		}
+		for _, n := range nodes {
+			if xpathCompare(op, n.String(), right) {
+				return true
+			}
+		}
+		return false
+	}
+	if nodes, ok := right.([]xnode); ok {
+		if _, ok := left.(bool); ok {
+			return xpathCompare(op, left, len(nodes) > 0)
+		}
+		for _, n := range nodes {
+			if xpathCompare(op, left, n.String()) {
+				return true
+			}
+		}
+		return false
+	}
+	if op == "=" || op == "!=" {
+		var equal bool
+		_, leftBool := left.(bool)
+		_, rightBool := right.(bool)
+		_, leftNumber := left.(float64)
+		_, rightNumber := right.(float64)
+		switch {
+		case leftBool || rightBool:
+			equal = xpathBoolean(left) == xpathBoolean(right)
+		case leftNumber || rightNumber:
+			equal = xpathNumberOf(left) == xpathNumberOf(right)
+		default:
+			equal = xpathString(left) == xpathString(right)
+		}
+		return equal == (op == "=")
+	}
+	l, r := xpathNumberOf(left), xpathNumberOf(right)
+	switch op {
+	case "<":
+		return l < r
+	case "<=":
+		return l <= r
+	case ">":
+		return l > r
+	default:
+		return l >= r
+	}
+}
+
+// xpathToken kinds.
+const (
+	xpathEOF = iota
+	xpathName
+	xpathNumberToken
+	xpathLiteralToken
+	xpathOperator
+)
+
+// xpathToken is a token of an XPath expression.
+type xpathToken struct {
+	kind  int
+	value string
+	pos   int
+}
+
+// lexXPath splits an XPath expression into tokens.
+func lexXPath(source string) ([]xpathToken, error) {
+	var tokens []xpathToken
+	for i := 0; i < len(source); {
+		c := source[i]
+		start := i
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+			continue
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(source[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated literal at offset %v", i)
+			}
+			tokens = append(tokens, xpathToken{kind: xpathLiteralToken, value: source[i+1 : i+1+end], pos: start})
+			i += end + 2
+			continue
+		case isDigit(c) || (c == '.' && i+1 < len(source) && isDigit(source[i+1])):
+			for i < len(source) && isDigit(source[i]) {
+				i++
+			}
+			if i < len(source) && source[i] == '.' {
+				i++
+				for i < len(source) && isDigit(source[i]) {
+					i++
+				}
+			}
+			tokens = append(tokens, xpathToken{kind: xpathNumberToken, value: source[start:i], pos: start})
+			continue
+		case isIdentStart(c) && c != '-':
+			for i < len(source) && (isIdentStart(source[i]) || isDigit(source[i]) || source[i] == '.' ||
+				(source[i] == ':' && i+1 < len(source) && source[i+1] != ':' && isIdentStart(source[i+1]))) {
+				i++
+			}
+			tokens = append(tokens, xpathToken{kind: xpathName, value: source[start:i], pos: start})
+			continue
+		}
+		operator := string(c)
+		if i+1 < len(source) {
+			switch two := source[i : i+2]; two {
+			case "//", "..", "::", "!=", "<=", ">=":
+				operator = two
+			}
+		}
+		if !xpathOperators[operator] {
+			return nil, fmt.Errorf("unexpected %q at offset %v", operator, i)
+		}
+		tokens = append(tokens, xpathToken{kind: xpathOperator, value: operator, pos: start})
+		i += len(operator)
+	}
+	return append(tokens, xpathToken{kind: xpathEOF, pos: len(source)}), nil
+}
+
+// xpathOperators are the operator tokens.
+var xpathOperators = map[string]bool{
+	"/": true, "//": true, ".": true, "..": true, "::": true, "@": true, ",": true, "(": true, ")": true, "[": true,
+	"]": true, "|": true, "+": true, "-": true, "*": true, "=": true, "!=": true, "<": true, "<=": true, ">": true,
+	">=": true,
+}
+
+// isDigit reports whether the byte is an ASCII digit.
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// xpathParser parses the tokens of an XPath expression.
+type xpathParser struct {
+	tokens []xpathToken
+	pos    int
+}
+
+func (p *xpathParser) peek() xpathToken {
+	return p.tokens[p.pos]
+}
+
+func (p *xpathParser) peekAt(offset int) xpathToken {
+	if p.pos+offset < len(p.tokens) {
+		return p.tokens[p.pos+offset]
+	}
+	return p.tokens[len(p.tokens)-1]
+}
+
+func (p *xpathParser) next() xpathToken {
+	t := p.tokens[p.pos]
+	if t.kind != xpathEOF {
+		p.pos++
+	}
+	return t
+}
+
+// isOperator reports whether the next token is one of the operators.
+func (p *xpathParser) isOperator(operators ...string) bool {
+	t := p.peek()
+	for _, operator := range operators {
+		if t.kind == xpathOperator && t.value == operator {
+			return true
+		}
+	}
+	return false
+}
+
+// isOperatorName reports whether the next token is the operator name, such as and or div.
+func (p *xpathParser) isOperatorName(name string) bool {
+	return p.peek().kind == xpathName && p.peek().value == name
+}
+
+func (p *xpathParser) expect(operator string) error {
+	if !p.isOperator(operator) {
+		return fmt.Errorf("expected %q at offset %v", operator, p.peek().pos)
+	}
+	p.next()
+	return nil
+}
+
+func (p *xpathParser) parseExpr() (xpathExpr, error) {
+	return p.parseBinary(0)
+}
+
+// xpathPrecedence lists the binary operators from the lowest to the highest precedence.
+var xpathPrecedence = [][]string{{"or"}, {"and"}, {"=", "!="}, {"<", "<=", ">", ">="}, {"+", "-"}, {"*", "div", "mod"}}
+
+// parseBinary parses the binary operators of the precedence level and above.
+func (p *xpathParser) parseBinary(level int) (xpathExpr, error) {
+	if level == len(xpathPrecedence) {
+		return p.parseUnary()
+	}
+	left, err := p.parseBinary(level + 1)
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op := ""
+		for _, operator := range xpathPrecedence[level] {
+			if p.isOperator(operator) || p.isOperatorName(operator) {
+				op = operator
+			}
+		}
+		if op == "" {
+			return left, nil
+		}
+		p.next()
+		right, err := p.parseBinary(level + 1)
+		if err != nil {
+			return nil, err
+		}
+		left = &xpathBinary{op: op, left: left, right: right}
+	}
+}
+
+func (p *xpathParser) parseUnary() (xpathExpr, error) {
+	if p.isOperator("-") {
+		p.next()
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &xpathNegate{expr: expr}, nil
+	}
+	left, err := p.parsePath()
+	if err != nil {
+		return nil, err
+	}
+	for p.isOperator("|") {
+		p.next()
+		right, err := p.parsePath()
+		if err != nil {
+			return nil, err
+		}
+		left = &xpathBinary{op: "|", left: left, right: right}
+	}
+	return left, nil
+}
+
+// xpathNodeTypes are the node types that can be used as node tests.
+var xpathNodeTypes = map[string]bool{"comment": true, "text": true, "processing-instruction": true, "node": true}
+
+// parsePath parses a location path, or a filter expression optionally followed by a relative location path.
+func (p *xpathParser) parsePath() (xpathExpr, error) {
+	t := p.peek()
+	isFunction := t.kind == xpathName && p.peekAt(1).kind == xpathOperator && p.peekAt(1).value == "(" && !xpathNodeTypes[t.value]
+	if t.kind == xpathLiteralToken || t.kind == xpathNumberToken || p.isOperator("(") || isFunction {
+		filter, err := p.parseFilter()
+		if err != nil {
+			return nil, err
+		}
+		if !p.isOperator("/", "//") {
+			return filter, nil
+		}
+		steps, err := p.parseRelativePath()
+		if err != nil {
+			return nil, err
+		}
+		return &xpathPath{filter: filter, steps: steps}, nil
+	}
+
+	path := &xpathPath{}
+	if p.isOperator("/") {
+		p.next()
+		path.absolute = true
+		if !p.startsStep() {
+			return path, nil
+		}
+	} else if p.isOperator("//") {
+		path.absolute = true
+	}
+	if !p.isOperator("//") && !p.startsStep() {
+		return nil, fmt.Errorf("unexpected %q at offset %v", t.value, t.pos)
+	}
+	steps, err := p.parseRelativePath()
+	if err != nil {
+		return nil, err
+	}
+	path.steps = steps
+	return path, nil
+}
+
+// startsStep reports whether the next token starts a step.
+func (p *xpathParser) startsStep() bool {
+	return p.peek().kind == xpathName || p.isOperator("*", ".", "..", "@")
+}
+
+// parseRelativePath parses steps separated by / or //, a leading / or // is allowed.
+func (p *xpathParser) parseRelativePath() ([]xpathStep, error) {
+	var steps []xpathStep
+	for first := true; first || p.isOperator("/", "//"); first = false {
+		if p.isOperator("//") {
+			steps = append(steps, xpathStep{axis: "descendant-or-self", test: xpathNodeTest{kind: "node"}})
+			p.next()
+		} else if p.isOperator("/") {
+			p.next()
+		}
+		step, err := p.parseStep()
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, step)
+	}
+	return steps, nil
+}
+
+func (p *xpathParser) parseStep() (xpathStep, error) {
+	switch {
+	case p.isOperator("."):
+		p.next()
+		return xpathStep{axis: "self", test: xpathNodeTest{kind: "node"}}, nil
+	case p.isOperator(".."):
+		p.next()
+		return xpathStep{axis: "parent", test: xpathNodeTest{kind: "node"}}, nil
+	}
+	step := xpathStep{axis: "child"}
+	if p.isOperator("@") {
+		p.next()
+		step.axis = "attribute"
+	} else if p.peek().kind == xpathName && p.peekAt(1).kind == xpathOperator && p.peekAt(1).value == "::" {
+		axis := p.next()
+		if !xpathAxes[axis.value] {
+			return step, fmt.Errorf("unsupported axis %q at offset %v", axis.value, axis.pos)
+		}
+		step.axis = axis.value
+		p.next()
+	}
+
+	t := p.next()
+	switch {
+	case t.kind == xpathOperator && t.value == "*":
+		step.test = xpathNodeTest{kind: "*"}
+	case t.kind == xpathName && xpathNodeTypes[t.value] && p.isOperator("("):
+		p.next()
+		if t.value == "processing-instruction" && p.peek().kind == xpathLiteralToken {
+			p.next()
+		}
+		if err := p.expect(")"); err != nil {
+			return step, err
+		}
+		step.test = xpathNodeTest{kind: t.value}
+	case t.kind == xpathName:
+		name := t.value
+		if i := strings.LastIndexByte(name, ':'); i >= 0 {
+			name = name[i+1:]
+		}
+		step.test = xpathNodeTest{kind: "name", name: name}
+	default:
+		return step, fmt.Errorf("expected a node test at offset %v", t.pos)
+	}
+
+	for p.isOperator("[") {
+		predicate, err := p.parsePredicate()
+		if err != nil {
+			return step, err
+		}
+		step.predicates = append(step.predicates, predicate)
+	}
+	return step, nil
+}
+
+func (p *xpathParser) parsePredicate() (xpathExpr, error) {
+	p.next()
+	predicate, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	return predicate, p.expect("]")
+}
+
+// parseFilter parses a primary expression, a literal, a number, a function call or a parenthesized expression,
+// followed by predicates.
+func (p *xpathParser) parseFilter() (xpathExpr, error) {
+	var primary xpathExpr
+	t := p.next()
+	switch t.kind {
+	case xpathLiteralToken:
+		primary = xpathLiteral(t.value)
+	case xpathNumberToken:
+		number, err := strconv.ParseFloat(t.value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q at offset %v", t.value, t.pos)
+		}
+		primary = xpathNumber(number)
+	case xpathName:
+		arity, ok := xpathFunctionArity[t.value]
+		if !ok {
+			return nil, fmt.Errorf("unsupported function %v() at offset %v", t.value, t.pos)
+		}
+		p.next()
+		function := &xpathFunction{name: t.value}
+		for !p.isOperator(")") {
+			if len(function.args) > 0 {
+				if err := p.expect(","); err != nil {
+					return nil, err
+				}
+			}
+			arg, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			function.args = append(function.args, arg)
+		}
+		p.next()
+		if len(function.args) < arity[0] || (arity[1] >= 0 && len(function.args) > arity[1]) {
+			return nil, fmt.Errorf("wrong number of arguments for %v() at offset %v", t.value, t.pos)
+		}
+		primary = function
+	default:
+		expr, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		primary = expr
+	}
+
+	filter := &xpathFilter{primary: primary}
+	for p.isOperator("[") {
+		predicate, err := p.parsePredicate()
+		if err != nil {
+			return nil, err
+		}
+		filter.predicates = append(filter.predicates, predicate)
+	}
+	if len(filter.predicates) == 0 {
+		return primary, nil
+	}
+	return filter, nil
+}
@@ -0,0 +1,150 @@
+package webcrawler
+
+import (
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/net/html"
+)
+
+func TestXPath_Values(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(selectorTestPage))
+	if err != nil {
+		t.Fatalf("html.Parse() error = %v", err)
+	}
+	tests := []struct {
+		expr string
+		want []string
+	}{
+		{expr: "//li/@data-id", want: []string{"1", "2", "3"}},
+		{expr: "//li[contains(@class, 's-item--ad')]/a/text()", want: []string{"Third"}},
+		{expr: "/html/body/ul/li[2]/a", want: []string{"Second"}},
+		{expr: "//li[last()]/@data-id", want: []string{"3"}},
+		{expr: "(//a)[position() > 1]/@href", want: []string{"/2", "/3"}},
+		{expr: "//span[@class='price sale']/ancestor::li/@data-id", want: []string{"2"}},
+		{expr: "//span/../@data-id | //li[@lang]/@data-id", want: []string{"1", "2", "3"}},
+		{expr: "//li[3]/preceding-sibling::li[1]/@data-id", want: []string{"2"}},
+		{expr: "//li[1]/following::span/text()", want: []string{"$20"}},
+		{expr: "//ul/descendant::*[self::span]", want: []string{"$10", "$20"}},
+		{expr: "count(//li[span])", want: []string{"2"}},
+		{expr: "sum(//li/@data-id) div 2", want: []string{"3"}},
+		{expr: "substring-after(//li[1]/span, '$') * 2 + 1", want: []string{"21"}},
+		{expr: "normalize-space(concat(' a ', ' b '))", want: []string{"a b"}},
+		{expr: "translate(//li[1]/a, 'Fir', 'fIR')", want: []string{"fIRst"}},
+		{expr: "//li[@data-id >= 2 and not(@lang)]/@data-id", want: []string{"2"}},
+		{expr: "//p[@class = 'note'][2]/b", want: []string{"Outro"}},
+		{expr: "boolean(//table)", want: []string{"false"}},
+		{expr: "//li[1]/a/attribute::href", want: []string{"/1"}},
+		{expr: "//table", want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			x, err := CompileXPath(tt.expr)
+			if err != nil {
+				t.Fatalf("CompileXPath() error = %v", err)
+			}
+			if got := x.Values(doc); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("XPath.Values() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestXPath_Select(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(selectorTestPage))
+	if err != nil {
+		t.Fatalf("html.Parse() error = %v", err)
+	}
+	x, err := CompileXPath("//li")
+	if err != nil {
+		t.Fatalf("CompileXPath() error = %v", err)
+	}
+	items := x.Select(doc)
+	if len(items) != 3 {
+		t.Fatalf("XPath.Select() = %v nodes, want 3", len(items))
+	}
+	relative, err := CompileXPath(".//a/@href")
+	if err != nil {
+		t.Fatalf("CompileXPath() error = %v", err)
+	}
+	if got := relative.Values(items[1]); !reflect.DeepEqual(got, []string{"/2"}) {
+		t.Errorf("XPath.Values() = %v, want [/2]", got)
+	}
+}
+
+func TestCompileXPath(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr bool
+	}{
+		{expr: "//div[@id='main']//a[starts-with(@href, 'http')]"},
+		{expr: "count(//li) mod 2 = 1 or -1 < 0"},
+		{expr: "/"},
+		{expr: "//comment() | //processing-instruction('x')"},
+		{expr: "", wantErr: true},
+		{expr: "//li[", wantErr: true},
+		{expr: "//li[@id='x]", wantErr: true},
+		{expr: "unknown(//li)", wantErr: true},
+		{expr: "contains('a')", wantErr: true},
+		{expr: "namespace::x", wantErr: true},
+		{expr: "$price", wantErr: true},
+		{expr: "//li)", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			if _, err := CompileXPath(tt.expr); (err != nil) != tt.wantErr {
+				t.Errorf("CompileXPath() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestWebScraper_ScrapeXPath(t *testing.T) {
+	fetcher := FetcherFunc(func(request *http.Request) (*http.Response, error) {
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Header:     http.Header{},
+			Body:       ioutil.NopCloser(strings.NewReader(selectorTestPage)),
+			Request:    request,
+		}, nil
+	})
+	ws := &WebScraper{Logger: logrus.New(), Fetcher: fetcher}
+	itemsToGet := []ScrapeItemConfig{{
+		ItemName:  "Result",
+		ItemToGet: ExtractFromTokenConfig{XPath: "//ul[@id='results']/li[span]"},
+		ItemDetails: map[string]ExtractFromTokenConfig{
+			"title": {XPath: "./a/text()"},
+			"link":  {XPath: ".//a", AttributeToGet: "href"},
+			"price": {XPath: "substring-after(span, '$')", ItemFilterConfiguration: FilterConfiguration{IsGreaterThan: 15.0, ConvertStringToNumber: "true"}},
+		},
+	}}
+	urlsToGet := []ScrapeURLConfig{{Name: "Results", ExtractFromTokenConfig: ExtractFromTokenConfig{XPath: "//li[not(@lang)]/a/@href"}}}
+	response, err := ws.Scrape(&URL{CurrentURL: "https://www.example.io/search"}, itemsToGet, urlsToGet...)
+	if err != nil {
+		t.Fatalf("WebScraper.Scrape() error = %v", err)
+	}
+
+	var urls []string
+	for _, u := range response.ExtractedURLs {
+		urls = append(urls, u.CurrentURL)
+	}
+	if want := []string{"https://www.example.io/1", "https://www.example.io/2"}; !reflect.DeepEqual(urls, want) {
+		t.Errorf("WebScraper.Scrape() urls = %v, want %v", urls, want)
+	}
+	var details []map[string]string
+	for _, item := range response.ExtractedItem {
+		details = append(details, item.ItemDetails)
+	}
+	if want := []map[string]string{{"title": "Second", "link": "/2", "price": "20"}}; !reflect.DeepEqual(details, want) {
+		t.Errorf("WebScraper.Scrape() item details = %v, want %v", details, want)
+	}
+
+	invalid := []ScrapeItemConfig{{ItemToGet: ExtractFromTokenConfig{Selector: "li", XPath: "//li"}}}
+	if err := ValidateScrapeConfigs(invalid, nil); err == nil {
+		t.Errorf("ValidateScrapeConfigs() error = nil, want error for both selector and xpath")
+	}
+}
@@ -34,6 +34,15 @@ func GetEnvInt(envVar string) (int, error) {
 	return v, nil
 }
 
+// GetEnvFloat converts string environment variables to floats.
+func GetEnvFloat(envVar string) (float64, error) {
+	s := os.Getenv(envVar)
+	if s == "" {
+		return 0, fmt.Errorf("")
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
 //GetEnvTime converts string to time duration
 func GetEnvTime(input string) (time.Duration, error) {
 	duration, err := strconv.Atoi(input)
@@ -42,3 +51,16 @@ func GetEnvTime(input string) (time.Duration, error) {
 	}
 	return time.Duration(float64(duration) * float64(time.Second)), nil
 }
+
+// GetEnvDuration converts string environment variables to time durations. The value is either a duration such as
+// "500ms" or a number of seconds such as "0.5".
+func GetEnvDuration(envVar string) (time.Duration, error) {
+	s := os.Getenv(envVar)
+	if s == "" {
+		return 0, fmt.Errorf("")
+	}
+	if seconds, err := strconv.ParseFloat(s, 64); err == nil {
+		return time.Duration(seconds * float64(time.Second)), nil
+	}
+	return time.ParseDuration(s)
+}
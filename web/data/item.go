@@ -1,7 +1,9 @@
 package data
 
 import (
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 
 	webcrawler "github.com/cody6750/web-crawler/pkg"
@@ -13,7 +15,47 @@ import (
 type Payload struct {
 	ScrapeItemConfiguration []webscraper.ScrapeItemConfig `json:"ScrapeItemConfiguration"`
 	ScrapeURLConfiguration  []webscraper.ScrapeURLConfig  `json:"ScrapeURLConfiguration"`
-	RootURL                 string                        `json:"RootURL"`
+	RootURL                 RootURLs                      `json:"RootURL"`
+}
+
+// RootURLs represents the seeds to crawl. It is decoded from a single url, an array of urls or an array of seeds with
+// an optional max depth and tag, for example [{"URL": "https://www.example.com", "MaxDepth": 1, "Tag": "example"}].
+type RootURLs []webcrawler.Seed
+
+// UnmarshalJSON decodes a single url, an array of urls or an array of seeds.
+func (r *RootURLs) UnmarshalJSON(b []byte) error {
+	var url string
+	if err := json.Unmarshal(b, &url); err == nil {
+		*r = RootURLs{{URL: url}}
+		if url == "" {
+			*r = nil
+		}
+		return nil
+	}
+	var elements []json.RawMessage
+	if err := json.Unmarshal(b, &elements); err != nil {
+		return fmt.Errorf("RootURL must be a url or an array of urls or seeds: %w", err)
+	}
+	seeds := make(RootURLs, 0, len(elements))
+	for _, element := range elements {
+		var seed webcrawler.Seed
+		if err := json.Unmarshal(element, &seed.URL); err != nil {
+			if err := json.Unmarshal(element, &seed); err != nil {
+				return fmt.Errorf("RootURL must be a url or an array of urls or seeds: %w", err)
+			}
+		}
+		seeds = append(seeds, seed)
+	}
+	*r = seeds
+	return nil
+}
+
+// MarshalJSON encodes a single seed without max depth and tag as a url, like the payloads before seeds were supported.
+func (r RootURLs) MarshalJSON() ([]byte, error) {
+	if len(r) == 1 && r[0].MaxDepth == nil && r[0].Tag == "" {
+		return json.Marshal(r[0].URL)
+	}
+	return json.Marshal([]webcrawler.Seed(r))
 }
 
 // DecodeToPayload used to decode web crawler response request into a usuable struct that the web crawler server
@@ -27,11 +69,11 @@ func DecodeToPayload(r *http.Request) (Payload, error) {
 	return payload, nil
 }
 
-// GetItem executes the crawl function within the web crawler. Returns a http response with
-// the webcrawler response as the response body.
-func GetItem(crawler *webcrawler.WebCrawler, logger *logrus.Logger, url string, itemsToget []webscraper.ScrapeItemConfig, ScrapeURLConfiguration ...webscraper.ScrapeURLConfig) (*webcrawler.Response, error) {
+// GetItem executes the crawl function within the web crawler for every seed. Returns a http response with
+// the webcrawler response as the response body. The crawl is stopped once the context is cancelled.
+func GetItem(ctx context.Context, crawler *webcrawler.WebCrawler, logger *logrus.Logger, seeds RootURLs, itemsToget []webscraper.ScrapeItemConfig, ScrapeURLConfiguration ...webscraper.ScrapeURLConfig) (*webcrawler.Response, error) {
 	crawler.Logger = logger
-	response, err := crawler.Crawl(url, itemsToget, ScrapeURLConfiguration...)
+	response, err := crawler.CrawlSeedsContext(ctx, seeds, itemsToget, ScrapeURLConfiguration...)
 	if err != nil {
 		logger.WithError(err).Errorf("Failed to get item")
 		return response, err
@@ -0,0 +1,60 @@
+package data
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	webcrawler "github.com/cody6750/web-crawler/pkg"
+)
+
+// JobStatus represents the state of an asynchronous crawl job.
+type JobStatus string
+
+const (
+	JobStatusQueued    JobStatus = "queued"
+	JobStatusRunning   JobStatus = "running"
+	JobStatusSucceeded JobStatus = "succeeded"
+	JobStatusFailed    JobStatus = "failed"
+	JobStatusCancelled JobStatus = "cancelled"
+)
+
+// ErrJobNotFound is returned by a job store when no job exists for the given ID.
+var ErrJobNotFound = errors.New("job not found")
+
+// Job represents an asynchronous crawl job started through the web crawler server.
+type Job struct {
+	ID         string               `json:"ID"`
+	Status     JobStatus            `json:"Status"`
+	Payload    Payload              `json:"Payload"`
+	Metrics    *webcrawler.Metrics  `json:"Metrics,omitempty"`
+	Error      string               `json:"Error,omitempty"`
+	CreatedAt  time.Time            `json:"CreatedAt"`
+	StartedAt  *time.Time           `json:"StartedAt,omitempty"`
+	FinishedAt *time.Time           `json:"FinishedAt,omitempty"`
+	Response   *webcrawler.Response `json:"Response,omitempty"`
+}
+
+// JobStore stores the state of crawl jobs. Implementations must be safe for concurrent use and must not hand out
+// references to the jobs they store, callers always work on copies.
+type JobStore interface {
+	Create(job Job) error
+	Get(id string) (Job, error)
+	Update(job Job) error
+	Delete(id string) error
+}
+
+// NewJob creates a queued job with a random ID for the given payload.
+func NewJob(payload Payload) (Job, error) {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return Job{}, err
+	}
+	return Job{ID: hex.EncodeToString(id), Status: JobStatusQueued, Payload: payload, CreatedAt: time.Now()}, nil
+}
+
+// IsFinished returns whether the job has reached a final state.
+func (j Job) IsFinished() bool {
+	return j.Status == JobStatusSucceeded || j.Status == JobStatusFailed || j.Status == JobStatusCancelled
+}
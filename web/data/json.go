@@ -2,14 +2,30 @@ package data
 
 import (
 	"encoding/json"
+	"fmt"
 	"io"
-
-	webcrawler "github.com/cody6750/web-crawler/pkg"
 )
 
-// ToJSON encodes the web crawler response to JSON and writes that to the end user over http.
-func ToJSON(w io.Writer, r *webcrawler.Response) error {
+// ToJSON encodes the given value, such as the web crawler response or a job, to JSON and writes that to the end
+// user over http.
+func ToJSON(w io.Writer, i interface{}) error {
 	e := json.NewEncoder(w)
 	e.SetIndent("", "    ")
-	return e.Encode(r)
+	return e.Encode(i)
+}
+
+// WriteEvent encodes the given value to JSON and writes it as a Server-Sent Event with the given event name.
+func WriteEvent(w io.Writer, event string, i interface{}) error {
+	out, err := json.Marshal(i)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, out)
+	return err
+}
+
+// WriteComment writes a Server-Sent Events comment, which clients ignore. Used to keep idle streams open.
+func WriteComment(w io.Writer, comment string) error {
+	_, err := fmt.Fprintf(w, ": %s\n\n", comment)
+	return err
 }
@@ -1,7 +1,15 @@
 package handler
 
 import (
+	"context"
+	"sync"
+	"time"
+
 	webcrawler "github.com/cody6750/web-crawler/pkg"
+	crawleroptions "github.com/cody6750/web-crawler/pkg/options"
+	webscraper "github.com/cody6750/web-crawler/pkg/webScraper"
+	"github.com/cody6750/web-crawler/web/data"
+	"github.com/cody6750/web-crawler/web/options"
 	"github.com/sirupsen/logrus"
 )
 
@@ -11,12 +19,48 @@ var (
 
 // Crawler handler for getting items from web crawler
 type Crawler struct {
-	crawler    *webcrawler.WebCrawler
-	logger     *logrus.Logger
-	Identifier string
+	// CrawlerOptions is the options template every crawl is created from. Each crawl receives its own copy.
+	CrawlerOptions *crawleroptions.Options
+	limiter        *crawlLimiter
+	queueTimeout   time.Duration
+	jobs           data.JobStore
+	runningJobs    map[string]*runningJob
+	runningLock    sync.Mutex
+	events         *eventHub
+	fetcher        webscraper.Fetcher
+	fetcherOnce    sync.Once
+	logger         *logrus.Logger
+	Identifier     string
+}
+
+// runningJob holds the handles of a job that is queued or running in this process.
+type runningJob struct {
+	crawler *webcrawler.WebCrawler
+	cancel  context.CancelFunc
+}
+
+// NewCrawler returns a new crawler handler with the given logger. The number of crawls running at the same time
+// is capped by the web crawler server options. Asynchronous crawl jobs are kept in the given job store.
+func NewCrawler(l *logrus.Logger, o *options.Options, jobs data.JobStore) *Crawler {
+	return &Crawler{
+		Identifier:     Identifier,
+		logger:         l,
+		CrawlerOptions: crawleroptions.New(),
+		limiter:        newCrawlLimiter(o.MaxConcurrentCrawls),
+		queueTimeout:   o.CrawlQueueTimeout,
+		jobs:           jobs,
+		runningJobs:    make(map[string]*runningJob),
+		events:         newEventHub(),
+	}
 }
 
-// NewCrawler returns a new crawler handler with the given logger
-func NewCrawler(l *logrus.Logger) *Crawler {
-	return &Crawler{Identifier: Identifier, logger: l, crawler: webcrawler.NewCrawler()}
+// newWebCrawler creates an isolated web crawler for a single crawl from the options template. Every web crawler shares
+// the connections of a single fetcher, created from the options of the first web crawler.
+func (c *Crawler) newWebCrawler() *webcrawler.WebCrawler {
+	wc := webcrawler.NewWithOptions(c.CrawlerOptions.Clone())
+	c.fetcherOnce.Do(func() {
+		c.fetcher = webscraper.NewHTTPFetcher(wc.Options.Transport)
+	})
+	wc.Fetcher = c.fetcher
+	return wc
 }
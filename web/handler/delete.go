@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/cody6750/web-crawler/web/data"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// DeleteJob represents a DELETE request handler for the web crawler server. A queued or running job is cancelled,
+// its partial results stay available. A finished job is removed from the job store.
+func (c *Crawler) DeleteJob(rw http.ResponseWriter, r *http.Request) {
+	c.logger.WithFields(logrus.Fields{"Handler": c.Identifier, "Function": "deleteJob"}).Info("Starting to call handler")
+	id := mux.Vars(r)["id"]
+	job, err := c.jobs.Get(id)
+	if err != nil {
+		c.writeJobError(rw, err)
+		return
+	}
+
+	if !job.IsFinished() && c.cancelJob(id) {
+		rw.WriteHeader(http.StatusAccepted)
+		c.logger.WithFields(logrus.Fields{"Handler": c.Identifier, "Function": "deleteJob", "Job": id}).Info("Successfully cancelled job")
+		return
+	}
+
+	err = c.jobs.Delete(id)
+	if err != nil {
+		c.writeJobError(rw, err)
+		return
+	}
+	rw.WriteHeader(http.StatusNoContent)
+	c.logger.WithFields(logrus.Fields{"Handler": c.Identifier, "Function": "deleteJob", "Job": id}).Info("Successfully deleted job")
+}
+
+// writeJobError writes the http error matching an error returned by the job store.
+func (c *Crawler) writeJobError(rw http.ResponseWriter, err error) {
+	if err == data.ErrJobNotFound {
+		http.Error(rw, "Job not found", http.StatusNotFound)
+		return
+	}
+	c.logger.WithError(err).Error("Unable to access job store")
+	http.Error(rw, "Unable to access job store", http.StatusInternalServerError)
+}
@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"sync"
+	"time"
+
+	webcrawler "github.com/cody6750/web-crawler/pkg"
+)
+
+// eventBufferSize is the number of events buffered per subscriber. Progress events are dropped for subscribers that
+// fall further behind, so a slow client cannot stall a crawl.
+const eventBufferSize = 64
+
+// eventHeartbeatInterval is the interval between two heartbeat comments sent to idle event streams, so that proxies
+// between the server and the client do not close the connection while a crawl is slow to make progress.
+var eventHeartbeatInterval = 15 * time.Second
+
+// eventHub fans out the progress events of running jobs to the clients subscribed to them.
+type eventHub struct {
+	subscribers map[string]map[chan *webcrawler.Progress]struct{}
+	lock        sync.Mutex
+}
+
+// newEventHub creates an event hub without subscribers.
+func newEventHub() *eventHub {
+	return &eventHub{subscribers: make(map[string]map[chan *webcrawler.Progress]struct{})}
+}
+
+// subscribe returns a channel that receives the progress events of the job. The channel is closed once the job
+// has finished. The returned function unsubscribes and must be called once the subscriber is done.
+func (h *eventHub) subscribe(id string) (<-chan *webcrawler.Progress, func()) {
+	events := make(chan *webcrawler.Progress, eventBufferSize)
+	h.lock.Lock()
+	if h.subscribers[id] == nil {
+		h.subscribers[id] = make(map[chan *webcrawler.Progress]struct{})
+	}
+	h.subscribers[id][events] = struct{}{}
+	h.lock.Unlock()
+
+	return events, func() {
+		h.lock.Lock()
+		defer h.lock.Unlock()
+		if _, exist := h.subscribers[id][events]; exist {
+			delete(h.subscribers[id], events)
+			close(events)
+		}
+		if len(h.subscribers[id]) == 0 {
+			delete(h.subscribers, id)
+		}
+	}
+}
+
+// publish sends the progress event to every subscriber of the job.
+func (h *eventHub) publish(id string, progress *webcrawler.Progress) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	for events := range h.subscribers[id] {
+		select {
+		case events <- progress:
+		default:
+		}
+	}
+}
+
+// close closes the channels of every subscriber of the job.
+func (h *eventHub) close(id string) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	for events := range h.subscribers[id] {
+		close(events)
+	}
+	delete(h.subscribers, id)
+}
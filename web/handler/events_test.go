@@ -0,0 +1,52 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cody6750/web-crawler/web/data"
+	"github.com/cody6750/web-crawler/web/handler"
+)
+
+func TestCrawler_GetJobEvents(t *testing.T) {
+	defer handler.SetEventHeartbeatInterval(20 * time.Millisecond)()
+	release := make(chan struct{})
+	site := newTestSite(t, map[string]string{"/": `<html><a href="/">home</a></html>`}, release)
+	// The stream must outlive the write timeout of the server.
+	ts, _ := newTestServer(t, newMemoryJobStore(), 100*time.Millisecond)
+
+	response, err := http.Post(ts.URL+"/crawler/jobs", "application/json", strings.NewReader(`{"RootURL": "`+site.URL+`/"}`))
+	if err != nil {
+		t.Fatalf("http.Post() error = %v", err)
+	}
+	var job data.Job
+	if err := json.NewDecoder(response.Body).Decode(&job); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	response.Body.Close()
+
+	events, err := http.Get(ts.URL + "/crawler/jobs/" + job.ID + "/events")
+	if err != nil {
+		t.Fatalf("http.Get() error = %v", err)
+	}
+	defer events.Body.Close()
+	if got := events.Header.Get("Content-Type"); got != "text/event-stream" {
+		t.Errorf("GetJobEvents() Content-Type = %v, want text/event-stream", got)
+	}
+	time.AfterFunc(300*time.Millisecond, func() { close(release) })
+	body, err := ioutil.ReadAll(events.Body)
+	if err != nil {
+		t.Fatalf("ReadAll() error = %v, want the stream to outlive the write timeout", err)
+	}
+	stream := string(body)
+	if !strings.Contains(stream, ": heartbeat\n\n") {
+		t.Errorf("GetJobEvents() stream = %q, want heartbeat comments", stream)
+	}
+	if !strings.Contains(stream, "event: progress\n") || !strings.Contains(stream, "event: done\n") {
+		t.Errorf("GetJobEvents() stream = %q, want progress and done events", stream)
+	}
+}
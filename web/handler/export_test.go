@@ -0,0 +1,11 @@
+package handler
+
+import "time"
+
+// SetEventHeartbeatInterval sets the interval between two heartbeat comments of event streams, returning a function
+// that restores it.
+func SetEventHeartbeatInterval(interval time.Duration) func() {
+	previous := eventHeartbeatInterval
+	eventHeartbeatInterval = interval
+	return func() { eventHeartbeatInterval = previous }
+}
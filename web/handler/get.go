@@ -2,9 +2,16 @@ package handler
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"time"
 
+	webcrawler "github.com/cody6750/web-crawler/pkg"
+	webscraper "github.com/cody6750/web-crawler/pkg/webScraper"
 	"github.com/cody6750/web-crawler/web/data"
+	"github.com/gorilla/mux"
 	"github.com/sirupsen/logrus"
 )
 
@@ -13,10 +20,28 @@ import (
 func (c *Crawler) GetItem(rw http.ResponseWriter, r *http.Request) {
 	c.logger.WithFields(logrus.Fields{"Handler": c.Identifier, "Function": "getItem"}).Info("Starting to call handler")
 	payload := r.Context().Value(KeyItem{}).(data.Payload)
-	products, err := data.GetItem(c.crawler, c.logger, payload.RootURL, payload.ScrapeItemConfiguration, payload.ScrapeURLConfiguration...)
+	if !c.limiter.acquire(r.Context(), c.queueTimeout) {
+		c.logger.WithFields(logrus.Fields{"Handler": c.Identifier, "Function": "getItem"}).Warn("Maximum number of concurrent crawls reached")
+		rw.Header().Set("Retry-After", strconv.Itoa(int(c.queueTimeout/time.Second)+1))
+		http.Error(rw, "Maximum number of concurrent crawls reached, please try again later", http.StatusTooManyRequests)
+		return
+	}
+	defer c.limiter.release()
+
+	// The request context is cancelled when the client disconnects, which stops the crawl.
+	products, err := data.GetItem(r.Context(), c.newWebCrawler(), c.logger, payload.RootURL, payload.ScrapeItemConfiguration, payload.ScrapeURLConfiguration...)
 	if err != nil {
 		c.logger.WithError(err).Error("Unable to call GetItem from the crawler handler")
-		return
+		if r.Context().Err() != nil {
+			// The client has disconnected, there is no one to respond to.
+			return
+		}
+		if products == nil {
+			http.Error(rw, "Unable to crawl url", http.StatusInternalServerError)
+			return
+		}
+		// The partial response is returned along with the failed urls and the stop reason of the crawl.
+		rw.WriteHeader(crawlErrorStatus(err))
 	}
 
 	err = data.ToJSON(rw, products)
@@ -28,6 +53,137 @@ func (c *Crawler) GetItem(rw http.ResponseWriter, r *http.Request) {
 	c.logger.WithFields(logrus.Fields{"Handler": c.Identifier, "Function": "getItem"}).Info("Successfully called handler")
 }
 
+// crawlErrorStatus returns the status code of a crawl that failed with the error. A crawl aborted by its error budget
+// failed because of the crawled websites rather than the server.
+func crawlErrorStatus(err error) int {
+	if errors.Is(err, webcrawler.ErrErrorBudgetExceeded) {
+		return http.StatusBadGateway
+	}
+	return http.StatusInternalServerError
+}
+
+// GetJob represents a GET request handler for the web crawler server. It returns the status of a crawl job
+// along with its metrics, which are live while the job is running.
+func (c *Crawler) GetJob(rw http.ResponseWriter, r *http.Request) {
+	c.logger.WithFields(logrus.Fields{"Handler": c.Identifier, "Function": "getJob"}).Info("Starting to call handler")
+	job, err := c.jobs.Get(mux.Vars(r)["id"])
+	if err != nil {
+		c.writeJobError(rw, err)
+		return
+	}
+
+	if metrics, running := c.liveMetrics(job.ID); running && !job.IsFinished() {
+		job.Metrics = &metrics
+	}
+	// The results are served by GetJobResults.
+	job.Response = nil
+	err = data.ToJSON(rw, job)
+	if err != nil {
+		c.logger.WithError(err).Error("Unable to write getJob using JSON from the crawler handler")
+		http.Error(rw, "Unable to marshal json", http.StatusInternalServerError)
+		return
+	}
+	c.logger.WithFields(logrus.Fields{"Handler": c.Identifier, "Function": "getJob"}).Info("Successfully called handler")
+}
+
+// GetJobResults represents a GET request handler for the web crawler server. It returns the web crawler response
+// of a finished crawl job. Cancelled and failed jobs return the partial response gathered before they stopped.
+func (c *Crawler) GetJobResults(rw http.ResponseWriter, r *http.Request) {
+	c.logger.WithFields(logrus.Fields{"Handler": c.Identifier, "Function": "getJobResults"}).Info("Starting to call handler")
+	job, err := c.jobs.Get(mux.Vars(r)["id"])
+	if err != nil {
+		c.writeJobError(rw, err)
+		return
+	}
+
+	if !job.IsFinished() {
+		http.Error(rw, "Job has not finished yet", http.StatusConflict)
+		return
+	}
+	if job.Response == nil {
+		http.Error(rw, "Job has no results", http.StatusNotFound)
+		return
+	}
+	err = data.ToJSON(rw, job.Response)
+	if err != nil {
+		c.logger.WithError(err).Error("Unable to write getJobResults using JSON from the crawler handler")
+		http.Error(rw, "Unable to marshal json", http.StatusInternalServerError)
+		return
+	}
+	c.logger.WithFields(logrus.Fields{"Handler": c.Identifier, "Function": "getJobResults"}).Info("Successfully called handler")
+}
+
+// GetJobEvents represents a GET request handler for the web crawler server. It streams the progress of a crawl job
+// to the client using Server-Sent Events. A progress event is sent for every crawled url, holding the url, its depth,
+// the status code, the number of items found and the running metrics of the crawl. A done event holding the job is
+// sent once the job has finished. The stream is exempt from the server WRITE_TIMEOUT and a heartbeat comment is sent
+// while no event is, so that the stream stays open for crawls of any duration.
+func (c *Crawler) GetJobEvents(rw http.ResponseWriter, r *http.Request) {
+	c.logger.WithFields(logrus.Fields{"Handler": c.Identifier, "Function": "getJobEvents"}).Info("Starting to call handler")
+	flusher, ok := rw.(http.Flusher)
+	if !ok {
+		http.Error(rw, "Streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	// Subscribe before checking the job, so that a job finishing in between closes the subscription.
+	id := mux.Vars(r)["id"]
+	events, unsubscribe := c.events.subscribe(id)
+	defer unsubscribe()
+	job, err := c.jobs.Get(id)
+	if err != nil {
+		c.writeJobError(rw, err)
+		return
+	}
+
+	if err := http.NewResponseController(rw).SetWriteDeadline(time.Time{}); err != nil {
+		c.logger.WithError(err).Warn("Unable to clear the write deadline, the stream is cut off by the server WRITE_TIMEOUT")
+	}
+	rw.Header().Set("Content-Type", "text/event-stream")
+	rw.Header().Set("Cache-Control", "no-cache")
+	rw.Header().Set("Connection", "keep-alive")
+	rw.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(eventHeartbeatInterval)
+	defer heartbeat.Stop()
+	for finished := job.IsFinished(); !finished; {
+		select {
+		case progress, open := <-events:
+			if !open {
+				job, err = c.jobs.Get(id)
+				if err != nil {
+					c.logger.WithError(err).Error("Unable to get finished job")
+					return
+				}
+				finished = true
+				continue
+			}
+			if err := data.WriteEvent(rw, "progress", progress); err != nil {
+				c.logger.WithError(err).Error("Unable to write progress event")
+				return
+			}
+			flusher.Flush()
+		case <-heartbeat.C:
+			if err := data.WriteComment(rw, "heartbeat"); err != nil {
+				c.logger.WithError(err).Error("Unable to write heartbeat")
+				return
+			}
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+
+	job.Response = nil
+	if err := data.WriteEvent(rw, "done", job); err != nil {
+		c.logger.WithError(err).Error("Unable to write done event")
+		return
+	}
+	flusher.Flush()
+	c.logger.WithFields(logrus.Fields{"Handler": c.Identifier, "Function": "getJobEvents"}).Info("Successfully called handler")
+}
+
 // KeyItem is used in the context.WithValue as a key to retrieve the payload.
 type KeyItem struct {
 }
@@ -44,9 +200,17 @@ func (c *Crawler) MiddlewareItemValidation(next http.Handler) http.Handler {
 			return
 		}
 
-		if payload.RootURL == "" {
+		if len(payload.RootURL) == 0 {
 			c.logger.Error("Missing url to crawl. Please set RootURL in payload")
 			http.Error(rw, "Missing url to crawl. Please set RootURL in payload", http.StatusBadRequest)
+			return
+		}
+
+		err = webscraper.ValidateScrapeConfigs(payload.ScrapeItemConfiguration, payload.ScrapeURLConfiguration)
+		if err != nil {
+			c.logger.WithError(err).Error("Invalid scrape configuration")
+			http.Error(rw, fmt.Sprintf("Invalid scrape configuration: %v", err), http.StatusBadRequest)
+			return
 		}
 
 		ctx := context.WithValue(r.Context(), KeyItem{}, payload)
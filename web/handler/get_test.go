@@ -0,0 +1,92 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	webcrawler "github.com/cody6750/web-crawler/pkg"
+	webscraper "github.com/cody6750/web-crawler/pkg/webScraper"
+)
+
+func TestCrawler_GetItem_errorBudgetExceeded(t *testing.T) {
+	site := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			http.NotFound(rw, r)
+			return
+		}
+		http.Error(rw, "unavailable", http.StatusInternalServerError)
+	}))
+	defer site.Close()
+	ts, crawler := newTestServer(t, newMemoryJobStore(), 0)
+	crawler.CrawlerOptions.MaxFailedUrls = 1
+	crawler.CrawlerOptions.RetryPolicy = &webscraper.RetryPolicy{MaxAttempts: 1, RetryableStatusCodes: []int{http.StatusInternalServerError}}
+
+	payload := `{"RootURL": ["` + site.URL + `/a", "` + site.URL + `/b", "` + site.URL + `/c"]}`
+	request, err := http.NewRequest(http.MethodGet, ts.URL+"/crawler/item", strings.NewReader(payload))
+	if err != nil {
+		t.Fatalf("http.NewRequest() error = %v", err)
+	}
+	response, err := http.DefaultClient.Do(request)
+	if err != nil {
+		t.Fatalf("http.Do() error = %v", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusBadGateway {
+		t.Errorf("GetItem() status code = %v, want %v", response.StatusCode, http.StatusBadGateway)
+	}
+	var got webcrawler.Response
+	if err := json.NewDecoder(response.Body).Decode(&got); err != nil {
+		t.Fatalf("Decode() error = %v, want the partial response", err)
+	}
+	if got.StopReason != webcrawler.StopReasonErrorBudget || len(got.Errors) < 2 {
+		t.Errorf("GetItem() stop reason = %v, errors = %v, want %v and the failed urls", got.StopReason, len(got.Errors), webcrawler.StopReasonErrorBudget)
+	}
+}
+
+func TestCrawler_MiddlewareItemValidation(t *testing.T) {
+	ts, _ := newTestServer(t, newMemoryJobStore(), 0)
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		payload  string
+		wantBody string
+	}{
+		{name: "Missing url", method: http.MethodGet, path: "/crawler/item", payload: `{}`, wantBody: "Missing url to crawl"},
+		{
+			name:     "Invalid selector",
+			method:   http.MethodGet,
+			path:     "/crawler/item",
+			payload:  `{"RootURL": "https://www.example.com", "ScrapeItemConfiguration": [{"ItemName": "Product", "ItemToGet": {"Selector": "li["}}]}`,
+			wantBody: "Invalid scrape configuration",
+		},
+		{
+			name:     "Invalid regex of a job",
+			method:   http.MethodPost,
+			path:     "/crawler/jobs",
+			payload:  `{"RootURL": "https://www.example.com", "ScrapeURLConfiguration": [{"Name": "links", "ExtractFromTokenConfig": {"Tag": "a", "Regex": "("}}]}`,
+			wantBody: "invalid regex",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request, err := http.NewRequest(tt.method, ts.URL+tt.path, strings.NewReader(tt.payload))
+			if err != nil {
+				t.Fatalf("http.NewRequest() error = %v", err)
+			}
+			response, err := http.DefaultClient.Do(request)
+			if err != nil {
+				t.Fatalf("http.Do() error = %v", err)
+			}
+			defer response.Body.Close()
+			body, _ := ioutil.ReadAll(response.Body)
+			if response.StatusCode != http.StatusBadRequest || !strings.Contains(string(body), tt.wantBody) {
+				t.Errorf("MiddlewareItemValidation() = %v %q, want %v containing %q", response.StatusCode, body, http.StatusBadRequest, tt.wantBody)
+			}
+		})
+	}
+}
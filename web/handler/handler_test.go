@@ -0,0 +1,74 @@
+package handler_test
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/cody6750/web-crawler/web/data"
+	"github.com/cody6750/web-crawler/web/handler"
+	"github.com/cody6750/web-crawler/web/options"
+	"github.com/cody6750/web-crawler/web/server"
+	"github.com/gorilla/mux"
+	"github.com/sirupsen/logrus"
+)
+
+// newTestServer starts a web crawler server with the routes of the crawler handler, like the server of the web
+// crawler server package, and a write timeout.
+func newTestServer(t *testing.T, jobs data.JobStore, writeTimeout time.Duration) (*httptest.Server, *handler.Crawler) {
+	t.Helper()
+	logger := logrus.New()
+	logger.SetOutput(ioutil.Discard)
+	crawler := handler.NewCrawler(logger, options.New(), jobs)
+	crawler.CrawlerOptions.CrawlDelay = 0
+
+	router := mux.NewRouter()
+	getRouter := router.Methods(http.MethodGet).Subrouter()
+	getRouter.HandleFunc("/crawler/item", crawler.GetItem)
+	getRouter.Use(crawler.MiddlewareItemValidation)
+	postRouter := router.Methods(http.MethodPost).Subrouter()
+	postRouter.HandleFunc("/crawler/jobs", crawler.CreateJob)
+	postRouter.Use(crawler.MiddlewareItemValidation)
+	getJobRouter := router.Methods(http.MethodGet).Subrouter()
+	getJobRouter.HandleFunc("/crawler/jobs/{id}", crawler.GetJob)
+	getJobRouter.HandleFunc("/crawler/jobs/{id}/results", crawler.GetJobResults)
+	getJobRouter.HandleFunc("/crawler/jobs/{id}/events", crawler.GetJobEvents)
+	deleteRouter := router.Methods(http.MethodDelete).Subrouter()
+	deleteRouter.HandleFunc("/crawler/jobs/{id}", crawler.DeleteJob)
+
+	ts := httptest.NewUnstartedServer(router)
+	ts.Config.WriteTimeout = writeTimeout
+	ts.Start()
+	t.Cleanup(ts.Close)
+	return ts, crawler
+}
+
+// newTestSite starts a website serving the pages by path, every other path is not found. Pages are served once
+// release is closed, so that tests control how long a crawl runs.
+func newTestSite(t *testing.T, pages map[string]string, release <-chan struct{}) *httptest.Server {
+	t.Helper()
+	site := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		page, exist := pages[r.URL.Path]
+		if !exist {
+			http.NotFound(rw, r)
+			return
+		}
+		if release != nil {
+			select {
+			case <-release:
+			case <-r.Context().Done():
+				return
+			}
+		}
+		rw.Write([]byte(page))
+	}))
+	t.Cleanup(site.Close)
+	return site
+}
+
+// newMemoryJobStore returns the in memory job store of the web crawler server.
+func newMemoryJobStore() data.JobStore {
+	return server.NewMemoryJobStore()
+}
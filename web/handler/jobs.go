@@ -0,0 +1,106 @@
+package handler
+
+import (
+	"context"
+	"time"
+
+	webcrawler "github.com/cody6750/web-crawler/pkg"
+	"github.com/cody6750/web-crawler/web/data"
+	"github.com/sirupsen/logrus"
+)
+
+// startJob registers the job as running in this process and executes the crawl in the background. The crawl
+// waits for a free crawl slot, so the job stays queued until the server has capacity.
+func (c *Crawler) startJob(job data.Job) {
+	ctx, cancel := context.WithCancel(context.Background())
+	rj := &runningJob{crawler: c.newWebCrawler(), cancel: cancel}
+	rj.crawler.OnEvent = func(event *webcrawler.Event) {
+		if event.Type == webcrawler.EventProgress {
+			c.events.publish(job.ID, event.Progress)
+		}
+	}
+	c.runningLock.Lock()
+	c.runningJobs[job.ID] = rj
+	c.runningLock.Unlock()
+
+	go func() {
+		defer cancel()
+		defer func() {
+			c.runningLock.Lock()
+			delete(c.runningJobs, job.ID)
+			c.runningLock.Unlock()
+		}()
+		c.runJob(ctx, rj, job)
+	}()
+}
+
+// runJob executes the crawl of the job and records its state in the job store.
+func (c *Crawler) runJob(ctx context.Context, rj *runningJob, job data.Job) {
+	logger := c.logger.WithFields(logrus.Fields{"Handler": c.Identifier, "Job": job.ID})
+	if !c.limiter.wait(ctx) {
+		c.finishJob(job, nil, ctx.Err())
+		return
+	}
+	defer c.limiter.release()
+
+	startedAt := time.Now()
+	job.Status = data.JobStatusRunning
+	job.StartedAt = &startedAt
+	if err := c.jobs.Update(job); err != nil {
+		logger.WithError(err).Error("Unable to update job")
+	}
+
+	logger.Info("Starting crawl job")
+	response, err := data.GetItem(ctx, rj.crawler, c.logger, job.Payload.RootURL, job.Payload.ScrapeItemConfiguration, job.Payload.ScrapeURLConfiguration...)
+	if ctx.Err() != nil {
+		err = ctx.Err()
+	}
+	c.finishJob(job, response, err)
+	logger.WithField("Status", job.Status).Info("Finished crawl job")
+}
+
+// finishJob records the final state of the job in the job store.
+func (c *Crawler) finishJob(job data.Job, response *webcrawler.Response, err error) {
+	finishedAt := time.Now()
+	job.FinishedAt = &finishedAt
+	job.Response = response
+	if response != nil {
+		job.Metrics = response.Metrics
+	}
+	switch {
+	case err == context.Canceled:
+		job.Status = data.JobStatusCancelled
+	case err != nil:
+		job.Status = data.JobStatusFailed
+		job.Error = err.Error()
+	default:
+		job.Status = data.JobStatusSucceeded
+	}
+	if err := c.jobs.Update(job); err != nil {
+		c.logger.WithError(err).WithField("Job", job.ID).Error("Unable to update job")
+	}
+	c.events.close(job.ID)
+}
+
+// cancelJob cancels a job that is queued or running in this process. Returns false if there is no such job.
+func (c *Crawler) cancelJob(id string) bool {
+	c.runningLock.Lock()
+	defer c.runningLock.Unlock()
+	rj, exist := c.runningJobs[id]
+	if !exist {
+		return false
+	}
+	rj.cancel()
+	return true
+}
+
+// liveMetrics returns the metrics of a job that is running in this process.
+func (c *Crawler) liveMetrics(id string) (webcrawler.Metrics, bool) {
+	c.runningLock.Lock()
+	rj, exist := c.runningJobs[id]
+	c.runningLock.Unlock()
+	if !exist {
+		return webcrawler.Metrics{}, false
+	}
+	return rj.crawler.Metrics(), true
+}
@@ -0,0 +1,116 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	webcrawler "github.com/cody6750/web-crawler/pkg"
+	"github.com/cody6750/web-crawler/web/data"
+)
+
+// createJob starts a crawl job of the url and returns it.
+func createJob(t *testing.T, serverURL, url string) data.Job {
+	t.Helper()
+	response, err := http.Post(serverURL+"/crawler/jobs", "application/json", strings.NewReader(`{"RootURL": "`+url+`"}`))
+	if err != nil {
+		t.Fatalf("http.Post() error = %v", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusAccepted {
+		t.Fatalf("CreateJob() status code = %v, want %v", response.StatusCode, http.StatusAccepted)
+	}
+	var job data.Job
+	if err := json.NewDecoder(response.Body).Decode(&job); err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	if got, want := response.Header.Get("Location"), "/crawler/jobs/"+job.ID; job.ID == "" || got != want {
+		t.Fatalf("CreateJob() job id = %q, Location = %q, want an id and %q", job.ID, got, want)
+	}
+	return job
+}
+
+// getJob returns the status code of the request of the job path and decodes its body into value on success.
+func getJob(t *testing.T, url string, value interface{}) int {
+	t.Helper()
+	response, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("http.Get() error = %v", err)
+	}
+	defer response.Body.Close()
+	if response.StatusCode == http.StatusOK && value != nil {
+		if err := json.NewDecoder(response.Body).Decode(value); err != nil {
+			t.Fatalf("Decode() error = %v", err)
+		}
+	}
+	return response.StatusCode
+}
+
+func TestCrawler_Jobs(t *testing.T) {
+	release := make(chan struct{})
+	site := newTestSite(t, map[string]string{"/": `<html><a href="/a">a</a></html>`, "/a": `<html></html>`}, release)
+	ts, crawler := newTestServer(t, newMemoryJobStore(), 0)
+	crawler.CrawlerOptions.AllowEmptyItem = true
+
+	job := createJob(t, ts.URL, site.URL+"/")
+	if job.Status != data.JobStatusQueued {
+		t.Errorf("CreateJob() status = %v, want %v", job.Status, data.JobStatusQueued)
+	}
+	if got := getJob(t, ts.URL+"/crawler/jobs/"+job.ID+"/results", nil); got != http.StatusConflict {
+		t.Errorf("GetJobResults() of unfinished job status code = %v, want %v", got, http.StatusConflict)
+	}
+	close(release)
+
+	deadline := time.Now().Add(10 * time.Second)
+	for !job.IsFinished() {
+		if time.Now().After(deadline) {
+			t.Fatalf("GetJob() status = %v, want the job to finish", job.Status)
+		}
+		time.Sleep(20 * time.Millisecond)
+		if got := getJob(t, ts.URL+"/crawler/jobs/"+job.ID, &job); got != http.StatusOK {
+			t.Fatalf("GetJob() status code = %v, want %v", got, http.StatusOK)
+		}
+	}
+	if job.Status != data.JobStatusSucceeded || job.Metrics == nil || job.Metrics.UrlsVisited != 2 || job.Response != nil {
+		t.Errorf("GetJob() = %+v, want a succeeded job with its metrics and without its results", job)
+	}
+
+	var results webcrawler.Response
+	if got := getJob(t, ts.URL+"/crawler/jobs/"+job.ID+"/results", &results); got != http.StatusOK {
+		t.Fatalf("GetJobResults() status code = %v, want %v", got, http.StatusOK)
+	}
+	if results.StopReason != webcrawler.StopReasonCompleted || results.Metrics == nil || results.Metrics.UrlsVisited != 2 {
+		t.Errorf("GetJobResults() = %+v, want the completed crawl", results)
+	}
+}
+
+func TestCrawler_Jobs_notFound(t *testing.T) {
+	ts, _ := newTestServer(t, newMemoryJobStore(), 0)
+	tests := []struct {
+		name   string
+		method string
+		path   string
+	}{
+		{name: "Get job", method: http.MethodGet, path: "/crawler/jobs/missing"},
+		{name: "Get job results", method: http.MethodGet, path: "/crawler/jobs/missing/results"},
+		{name: "Delete job", method: http.MethodDelete, path: "/crawler/jobs/missing"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			request, err := http.NewRequest(tt.method, ts.URL+tt.path, nil)
+			if err != nil {
+				t.Fatalf("http.NewRequest() error = %v", err)
+			}
+			response, err := http.DefaultClient.Do(request)
+			if err != nil {
+				t.Fatalf("http.Do() error = %v", err)
+			}
+			response.Body.Close()
+			if response.StatusCode != http.StatusNotFound {
+				t.Errorf("%v %v status code = %v, want %v", tt.method, tt.path, response.StatusCode, http.StatusNotFound)
+			}
+		})
+	}
+}
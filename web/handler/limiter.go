@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"context"
+	"time"
+)
+
+// crawlLimiter caps the number of crawls running at the same time across the web crawler server.
+type crawlLimiter struct {
+	slots chan struct{}
+}
+
+// newCrawlLimiter returns a crawl limiter that allows max concurrent crawls.
+func newCrawlLimiter(max int) *crawlLimiter {
+	if max < 1 {
+		max = 1
+	}
+	return &crawlLimiter{slots: make(chan struct{}, max)}
+}
+
+// acquire waits up to timeout for a free crawl slot. Returns false if no slot was freed in time or the context
+// is done. Every successful acquire must be followed by a release.
+func (l *crawlLimiter) acquire(ctx context.Context, timeout time.Duration) bool {
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	default:
+	}
+	if timeout <= 0 {
+		return false
+	}
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	case <-timer.C:
+		return false
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// wait blocks until a crawl slot is free or the context is done. Returns false if the context is done first.
+func (l *crawlLimiter) wait(ctx context.Context) bool {
+	select {
+	case l.slots <- struct{}{}:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
+
+// release frees a crawl slot.
+func (l *crawlLimiter) release() {
+	<-l.slots
+}
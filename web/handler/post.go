@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/cody6750/web-crawler/web/data"
+	"github.com/sirupsen/logrus"
+)
+
+// CreateJob represents a POST request handler for the web crawler server. It creates an asynchronous crawl job
+// from the payload and returns the job, including its ID, immediately. The crawl is executed in the background.
+func (c *Crawler) CreateJob(rw http.ResponseWriter, r *http.Request) {
+	c.logger.WithFields(logrus.Fields{"Handler": c.Identifier, "Function": "createJob"}).Info("Starting to call handler")
+	payload := r.Context().Value(KeyItem{}).(data.Payload)
+	job, err := data.NewJob(payload)
+	if err != nil {
+		c.logger.WithError(err).Error("Unable to create job")
+		http.Error(rw, "Unable to create job", http.StatusInternalServerError)
+		return
+	}
+
+	err = c.jobs.Create(job)
+	if err != nil {
+		c.logger.WithError(err).Error("Unable to store job")
+		http.Error(rw, "Unable to store job", http.StatusInternalServerError)
+		return
+	}
+	c.startJob(job)
+
+	rw.Header().Set("Location", "/crawler/jobs/"+job.ID)
+	rw.WriteHeader(http.StatusAccepted)
+	err = data.ToJSON(rw, job)
+	if err != nil {
+		c.logger.WithError(err).Error("Unable to write createJob using JSON from the crawler handler")
+		return
+	}
+	c.logger.WithFields(logrus.Fields{"Handler": c.Identifier, "Function": "createJob", "Job": job.ID}).Info("Successfully called handler")
+}
@@ -5,28 +5,40 @@ import (
 )
 
 var (
-	defaultLogLevel     = "INFO"
-	defaultPort         = ":9090"
-	defaultIdleTimeout  = time.Second * 120
-	defaultReadTimeout  = time.Second * 60
-	defaultWriteTimeout = time.Second * 60
+	defaultLogLevel            = "INFO"
+	defaultPort                = ":9090"
+	defaultMaxConcurrentCrawls = 5
+	defaultIdleTimeout         = time.Second * 120
+	defaultReadTimeout         = time.Second * 60
+	defaultWriteTimeout        = time.Second * 60
+	defaultCrawlQueueTimeout   = time.Second * 30
+	defaultJobTTL              = time.Hour * 24
+	defaultMaxFinishedJobs     = 1000
 )
 
 // Options includes the overrideable option configurations for the web crawler server
 type Options struct {
-	LogLevel     string
-	Port         string
-	IdleTimeout  time.Duration
-	ReadTimeout  time.Duration
-	WriteTimeout time.Duration
+	LogLevel            string
+	Port                string
+	MaxConcurrentCrawls int
+	IdleTimeout         time.Duration
+	ReadTimeout         time.Duration
+	WriteTimeout        time.Duration
+	CrawlQueueTimeout   time.Duration
+	JobTTL              time.Duration
+	MaxFinishedJobs     int
 }
 
 func New() *Options {
 	return &Options{
-		LogLevel:     defaultLogLevel,
-		Port:         defaultPort,
-		IdleTimeout:  defaultIdleTimeout,
-		ReadTimeout:  defaultReadTimeout,
-		WriteTimeout: defaultWriteTimeout,
+		LogLevel:            defaultLogLevel,
+		Port:                defaultPort,
+		MaxConcurrentCrawls: defaultMaxConcurrentCrawls,
+		IdleTimeout:         defaultIdleTimeout,
+		ReadTimeout:         defaultReadTimeout,
+		WriteTimeout:        defaultWriteTimeout,
+		CrawlQueueTimeout:   defaultCrawlQueueTimeout,
+		JobTTL:              defaultJobTTL,
+		MaxFinishedJobs:     defaultMaxFinishedJobs,
 	}
 }
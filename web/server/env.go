@@ -12,12 +12,12 @@ import (
 func (wcs *WebCrawlerServer) processEnvironmentVariables() error {
 	if os.Getenv("PORT") != "" {
 		wcs.Options.Port = os.Getenv("PORT")
-		wcs.logger.WithField("PORT", wcs.Options.Port).Debug("PORT overide found. Overriding with value %v", wcs.Options.Port)
+		wcs.logger.WithField("PORT", wcs.Options.Port).Debugf("PORT overide found. Overriding with value %v", wcs.Options.Port)
 	}
 
 	if os.Getenv("LOG_LEVEL") != "" {
 		wcs.Options.LogLevel = os.Getenv("LOG_LEVEL")
-		wcs.logger.WithField("LOG_LEVEL", wcs.Options.LogLevel).Debug("LOG_LEVEL overide found. Overriding with value %v", wcs.Options.LogLevel)
+		wcs.logger.WithField("LOG_LEVEL", wcs.Options.LogLevel).Debugf("LOG_LEVEL overide found. Overriding with value %v", wcs.Options.LogLevel)
 		switch wcs.Options.LogLevel {
 		case "INFO":
 			wcs.logger.SetLevel(logrus.InfoLevel)
@@ -26,13 +26,13 @@ func (wcs *WebCrawlerServer) processEnvironmentVariables() error {
 		case "DEBUG":
 			wcs.logger.SetLevel(logrus.DebugLevel)
 		default:
-			wcs.logger.Error("unsupported log type %v, using default logger", wcs.Options.LogLevel)
+			wcs.logger.Errorf("unsupported log type %v, using default logger", wcs.Options.LogLevel)
 		}
 	}
 
 	if os.Getenv("IDLE_TIMEOUT") != "" {
 		time, err := env.GetEnvTime(os.Getenv("IDLE_TIMEOUT"))
-		wcs.logger.WithField("IDLE_TIMEOUT", time.Seconds()).Debug("IDLE_TIMEOUT overide found. Overriding with value %v", time.Seconds())
+		wcs.logger.WithField("IDLE_TIMEOUT", time.Seconds()).Debugf("IDLE_TIMEOUT overide found. Overriding with value %v", time.Seconds())
 		if err != nil {
 			return err
 		}
@@ -40,7 +40,7 @@ func (wcs *WebCrawlerServer) processEnvironmentVariables() error {
 	}
 	if os.Getenv("READ_TIMEOUT") != "" {
 		time, err := env.GetEnvTime(os.Getenv("READ_TIMEOUT"))
-		wcs.logger.WithField("READ_TIMEOUT", time.Seconds()).Debug("READ_TIMEOUT overide found. Overriding with value %v", time.Seconds())
+		wcs.logger.WithField("READ_TIMEOUT", time.Seconds()).Debugf("READ_TIMEOUT overide found. Overriding with value %v", time.Seconds())
 		if err != nil {
 			return err
 		}
@@ -48,11 +48,43 @@ func (wcs *WebCrawlerServer) processEnvironmentVariables() error {
 	}
 	if os.Getenv("WRITE_TIMEOUT") != "" {
 		time, err := env.GetEnvTime(os.Getenv("WRITE_TIMEOUT"))
-		wcs.logger.WithField("WRITE_TIMEOUT", time.Seconds()).Debug("WRITE_TIMEOUT overide found. Overriding with value %v", time.Seconds())
+		wcs.logger.WithField("WRITE_TIMEOUT", time.Seconds()).Debugf("WRITE_TIMEOUT overide found. Overriding with value %v", time.Seconds())
 		if err != nil {
 			return err
 		}
 		wcs.Options.WriteTimeout = time
 	}
+	if os.Getenv("MAX_CONCURRENT_CRAWLS") != "" {
+		maxConcurrentCrawls, err := env.GetEnvInt("MAX_CONCURRENT_CRAWLS")
+		wcs.logger.WithField("MAX_CONCURRENT_CRAWLS", maxConcurrentCrawls).Debugf("MAX_CONCURRENT_CRAWLS overide found. Overriding with value %v", maxConcurrentCrawls)
+		if err != nil {
+			return err
+		}
+		wcs.Options.MaxConcurrentCrawls = maxConcurrentCrawls
+	}
+	if os.Getenv("CRAWL_QUEUE_TIMEOUT") != "" {
+		time, err := env.GetEnvTime(os.Getenv("CRAWL_QUEUE_TIMEOUT"))
+		wcs.logger.WithField("CRAWL_QUEUE_TIMEOUT", time.Seconds()).Debugf("CRAWL_QUEUE_TIMEOUT overide found. Overriding with value %v", time.Seconds())
+		if err != nil {
+			return err
+		}
+		wcs.Options.CrawlQueueTimeout = time
+	}
+	if os.Getenv("JOB_TTL") != "" {
+		jobTTL, err := env.GetEnvTime(os.Getenv("JOB_TTL"))
+		if err != nil {
+			return err
+		}
+		wcs.logger.WithField("JOB_TTL", jobTTL.Seconds()).Debugf("JOB_TTL overide found. Overriding with value %v", jobTTL.Seconds())
+		wcs.Options.JobTTL = jobTTL
+	}
+	if os.Getenv("MAX_FINISHED_JOBS") != "" {
+		maxFinishedJobs, err := env.GetEnvInt("MAX_FINISHED_JOBS")
+		wcs.logger.WithField("MAX_FINISHED_JOBS", maxFinishedJobs).Debugf("MAX_FINISHED_JOBS overide found. Overriding with value %v", maxFinishedJobs)
+		if err != nil {
+			return err
+		}
+		wcs.Options.MaxFinishedJobs = maxFinishedJobs
+	}
 	return nil
 }
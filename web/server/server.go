@@ -7,6 +7,7 @@ import (
 	"os/signal"
 	"time"
 
+	"github.com/cody6750/web-crawler/web/data"
 	"github.com/cody6750/web-crawler/web/handler"
 	"github.com/cody6750/web-crawler/web/options"
 	"github.com/gorilla/mux"
@@ -19,6 +20,10 @@ type WebCrawlerServer struct {
 	server  *http.Server
 	logger  *logrus.Logger
 	Options *options.Options
+
+	// JobStore stores the state of asynchronous crawl jobs. Defaults to an in memory job store that evicts finished
+	// jobs after Options.JobTTL and beyond Options.MaxFinishedJobs.
+	JobStore data.JobStore
 }
 
 // New creates and returns the web crawler server object with default options
@@ -41,6 +46,9 @@ func (wcs *WebCrawlerServer) init() {
 	if err != nil {
 		wcs.logger.Errorf("Error processing environment variables %e", err)
 	}
+	if wcs.JobStore == nil {
+		wcs.JobStore = NewMemoryJobStoreWithEviction(wcs.Options.JobTTL, wcs.Options.MaxFinishedJobs)
+	}
 	wcs.logger.Info("Successfully initialized web server")
 }
 
@@ -70,16 +78,17 @@ func (wcs *WebCrawlerServer) Run() {
 	wcs.logger.Info("Successfully started up web server, listening for traffic")
 
 	// trap sigterm or interupt and gracefully shutdown the server
-	sigChan := make(chan os.Signal)
+	sigChan := make(chan os.Signal, 1)
 	signal.Notify(sigChan, os.Interrupt)
 	signal.Notify(sigChan, os.Kill)
 
 	// Block until a signal is received.
 	sig := <-sigChan
-	wcs.logger.Infof("Recieved teriminate, graceful shutdown ", sig)
+	wcs.logger.Infof("Recieved teriminate, graceful shutdown %v", sig)
 
 	// gracefully shutdown the server, waiting max 30 seconds for current operations to complete
-	tc, _ := context.WithTimeout(context.Background(), 30*time.Second)
+	tc, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
 	wcs.server.Shutdown(tc)
 }
 
@@ -87,12 +96,24 @@ func (wcs *WebCrawlerServer) Run() {
 func (wcs *WebCrawlerServer) generateHandlers() *mux.Router {
 	wcs.logger.Debug("Starting to generate handlers for web server")
 	serverMux := mux.NewRouter()
-	crawler := handler.NewCrawler(wcs.logger)
+	crawler := handler.NewCrawler(wcs.logger, wcs.Options, wcs.JobStore)
 
 	getRouter := serverMux.Methods(http.MethodGet).Subrouter()
 	getRouter.HandleFunc("/crawler/item", crawler.GetItem)
 	getRouter.Use(crawler.MiddlewareItemValidation)
 
+	postRouter := serverMux.Methods(http.MethodPost).Subrouter()
+	postRouter.HandleFunc("/crawler/jobs", crawler.CreateJob)
+	postRouter.Use(crawler.MiddlewareItemValidation)
+
+	getJobRouter := serverMux.Methods(http.MethodGet).Subrouter()
+	getJobRouter.HandleFunc("/crawler/jobs/{id}", crawler.GetJob)
+	getJobRouter.HandleFunc("/crawler/jobs/{id}/results", crawler.GetJobResults)
+	getJobRouter.HandleFunc("/crawler/jobs/{id}/events", crawler.GetJobEvents)
+
+	deleteRouter := serverMux.Methods(http.MethodDelete).Subrouter()
+	deleteRouter.HandleFunc("/crawler/jobs/{id}", crawler.DeleteJob)
+
 	wcs.logger.WithField("Handlers", serverMux).Debug("Successfully generated handlers for web server")
 	return serverMux
 }
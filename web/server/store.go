@@ -0,0 +1,109 @@
+package server
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cody6750/web-crawler/web/data"
+)
+
+// MemoryJobStore is an in memory job store. Job state is lost once the web crawler server shuts down. Finished jobs
+// are evicted once they are older than the TTL of the store, and the oldest finished jobs are evicted once there are
+// more than the max finished jobs of the store. Queued and running jobs are never evicted.
+type MemoryJobStore struct {
+	jobs map[string]data.Job
+	lock sync.RWMutex
+
+	ttl             time.Duration
+	maxFinishedJobs int
+}
+
+// NewMemoryJobStore creates an empty in memory job store that keeps finished jobs until they are deleted.
+func NewMemoryJobStore() *MemoryJobStore {
+	return NewMemoryJobStoreWithEviction(0, 0)
+}
+
+// NewMemoryJobStoreWithEviction creates an empty in memory job store that evicts finished jobs after the ttl and
+// keeps at most maxFinishedJobs finished jobs. A ttl or maxFinishedJobs of 0 disables the limit.
+func NewMemoryJobStoreWithEviction(ttl time.Duration, maxFinishedJobs int) *MemoryJobStore {
+	return &MemoryJobStore{jobs: make(map[string]data.Job), ttl: ttl, maxFinishedJobs: maxFinishedJobs}
+}
+
+// Create stores a new job.
+func (s *MemoryJobStore) Create(job data.Job) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.jobs[job.ID] = job
+	s.evict(time.Now())
+	return nil
+}
+
+// Get returns the job with the given ID.
+func (s *MemoryJobStore) Get(id string) (data.Job, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	job, exist := s.jobs[id]
+	if !exist || s.expired(job, time.Now()) {
+		return data.Job{}, data.ErrJobNotFound
+	}
+	return job, nil
+}
+
+// Update replaces the stored job with the given job.
+func (s *MemoryJobStore) Update(job data.Job) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if _, exist := s.jobs[job.ID]; !exist {
+		return data.ErrJobNotFound
+	}
+	s.jobs[job.ID] = job
+	s.evict(time.Now())
+	return nil
+}
+
+// Delete removes the job with the given ID.
+func (s *MemoryJobStore) Delete(id string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if _, exist := s.jobs[id]; !exist {
+		return data.ErrJobNotFound
+	}
+	delete(s.jobs, id)
+	return nil
+}
+
+// expired returns whether the job finished longer than the TTL ago.
+func (s *MemoryJobStore) expired(job data.Job, now time.Time) bool {
+	return s.ttl > 0 && job.IsFinished() && job.FinishedAt != nil && now.Sub(*job.FinishedAt) > s.ttl
+}
+
+// evict removes the expired finished jobs, and the oldest finished jobs over the max finished jobs. Must be called
+// under the lock.
+func (s *MemoryJobStore) evict(now time.Time) {
+	var finished []data.Job
+	for id, job := range s.jobs {
+		if s.expired(job, now) {
+			delete(s.jobs, id)
+			continue
+		}
+		if job.IsFinished() {
+			finished = append(finished, job)
+		}
+	}
+	if s.maxFinishedJobs <= 0 || len(finished) <= s.maxFinishedJobs {
+		return
+	}
+	sort.Slice(finished, func(i, j int) bool { return finishedAt(finished[i]).Before(finishedAt(finished[j])) })
+	for _, job := range finished[:len(finished)-s.maxFinishedJobs] {
+		delete(s.jobs, job.ID)
+	}
+}
+
+// finishedAt returns when the job finished, falling back to when it was created.
+func finishedAt(job data.Job) time.Time {
+	if job.FinishedAt != nil {
+		return *job.FinishedAt
+	}
+	return job.CreatedAt
+}
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cody6750/web-crawler/web/data"
+)
+
+func TestMemoryJobStore_eviction(t *testing.T) {
+	now := time.Now()
+	job := func(id string, status data.JobStatus, finishedAgo time.Duration) data.Job {
+		job := data.Job{ID: id, Status: status, CreatedAt: now.Add(-time.Hour)}
+		if finishedAgo > 0 {
+			finishedAt := now.Add(-finishedAgo)
+			job.FinishedAt = &finishedAt
+		}
+		return job
+	}
+	tests := []struct {
+		name            string
+		ttl             time.Duration
+		maxFinishedJobs int
+		jobs            []data.Job
+		wantJobs        []string
+	}{
+		{
+			name:     "Expired finished jobs are evicted",
+			ttl:      time.Minute,
+			jobs:     []data.Job{job("expired", data.JobStatusSucceeded, time.Hour), job("recent", data.JobStatusFailed, time.Second), job("running", data.JobStatusRunning, 0)},
+			wantJobs: []string{"recent", "running"},
+		},
+		{
+			name:            "Oldest finished jobs are evicted",
+			maxFinishedJobs: 2,
+			jobs:            []data.Job{job("oldest", data.JobStatusSucceeded, 3*time.Hour), job("older", data.JobStatusCancelled, 2*time.Hour), job("old", data.JobStatusSucceeded, time.Hour), job("queued", data.JobStatusQueued, 0)},
+			wantJobs:        []string{"older", "old", "queued"},
+		},
+		{
+			name:     "Without limits jobs are kept",
+			jobs:     []data.Job{job("expired", data.JobStatusSucceeded, time.Hour), job("running", data.JobStatusRunning, 0)},
+			wantJobs: []string{"expired", "running"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := NewMemoryJobStoreWithEviction(tt.ttl, tt.maxFinishedJobs)
+			for _, job := range tt.jobs {
+				if err := store.Create(job); err != nil {
+					t.Fatalf("MemoryJobStore.Create() error = %v", err)
+				}
+			}
+			if len(store.jobs) != len(tt.wantJobs) {
+				t.Errorf("MemoryJobStore jobs = %v, want %v", len(store.jobs), tt.wantJobs)
+			}
+			for _, id := range tt.wantJobs {
+				if _, err := store.Get(id); err != nil {
+					t.Errorf("MemoryJobStore.Get(%v) error = %v, want the job", id, err)
+				}
+			}
+		})
+	}
+}
+
+func TestMemoryJobStore_Get_expired(t *testing.T) {
+	store := NewMemoryJobStoreWithEviction(time.Minute, 0)
+	if err := store.Create(data.Job{ID: "job", Status: data.JobStatusRunning}); err != nil {
+		t.Fatalf("MemoryJobStore.Create() error = %v", err)
+	}
+	// The job expires without any other write to the store.
+	finishedAt := time.Now().Add(-time.Hour)
+	store.jobs["job"] = data.Job{ID: "job", Status: data.JobStatusSucceeded, FinishedAt: &finishedAt}
+	if _, err := store.Get("job"); !errors.Is(err, data.ErrJobNotFound) {
+		t.Errorf("MemoryJobStore.Get() error = %v, want %v", err, data.ErrJobNotFound)
+	}
+}